@@ -2,17 +2,113 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// EnvProduction is the value of ENV in production deployments.
+const EnvProduction = "prod"
+
+// Signup modes, see Config.SignupMode.
+const (
+	// SignupAuto creates unknown users on /api/auth, registration is also available.
+	SignupAuto = "auto"
+	// SignupRegister creates users only via /api/auth/register.
+	SignupRegister = "register"
+	// SignupDisabled doesn't allow new users at all.
+	SignupDisabled = "disabled"
+)
+
 type Config struct {
+	// Env is the deployment environment: "local" or "prod".
+	Env         string `env:"ENV" env-default:"local"`
 	DBPort      string `env:"DATABASE_PORT" env-default:"5432"`
 	DBUser      string `env:"DATABASE_USER" env-default:"postgres"`
 	DBPass      string `env:"DATABASE_PASSWORD" env-default:"password"`
 	DBName      string `env:"DATABASE_NAME" env-default:"shop"`
 	DBHost      string `env:"DATABASE_HOST" env-default:"db"`
 	ServicePort string `env:"SERVER_PORT" env-default:"8080"`
+	// StorageType selects the storage backend: "postgres" or "memory".
+	StorageType string `env:"STORAGE_TYPE" env-default:"postgres"`
+	// AdminUsers get the admin role on signup or login, this is how the first admin is created.
+	AdminUsers []string `env:"ADMIN_USERS" env-separator:","`
+	// SignupMode is one of SignupAuto, SignupRegister or SignupDisabled.
+	SignupMode string `env:"SIGNUP_MODE" env-default:"auto"`
+	// TrustedProxies are the addresses or CIDRs of proxies whose X-Forwarded-For is used
+	// as the client address. By default no proxy is trusted.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" env-separator:","`
+	// IdempotencyKeyTTL is how long responses to sendCoin and buy requests with
+	// an Idempotency-Key header are kept for retries.
+	IdempotencyKeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL" env-default:"24h"`
+	// IdempotencyPendingTimeout is how long retries of a request that has not finished
+	// are told it is in progress. After it they are told its outcome is unknown, the
+	// request is never run again with the same key.
+	IdempotencyPendingTimeout time.Duration `env:"IDEMPOTENCY_PENDING_TIMEOUT" env-default:"1m"`
+	// MaxPurchaseQuantity is the most items a single buy request may buy.
+	MaxPurchaseQuantity int `env:"PURCHASE_MAX_QUANTITY" env-default:"100"`
+	// RefundWindow is how long after placing an order users may cancel it for a refund.
+	// Admins may refund orders at any time.
+	RefundWindow time.Duration `env:"ORDER_REFUND_WINDOW" env-default:"336h"`
+	JWT          JWTConfig
+	Lockout      LockoutConfig
+	Password     PasswordConfig
+	OIDC         OIDCConfig
+}
+
+// OIDCConfig lets users sign in with ID tokens of an external identity provider.
+// OIDC is off unless Issuer is set. Tokens must be issued by Issuer for Audience and
+// signed with an RSA key from the provider JWKS, which is fetched from JWKSURL and
+// refreshed every JWKSRefresh, or read once from JWKSFile. Users are matched by
+// UsernameClaim, "email" or "sub", and created on their first request unless signup
+// is disabled. Tokens are only accepted for users created by the same issuer.
+type OIDCConfig struct {
+	Issuer        string        `env:"OIDC_ISSUER"`
+	Audience      string        `env:"OIDC_AUDIENCE"`
+	JWKSURL       string        `env:"OIDC_JWKS_URL"`
+	JWKSFile      string        `env:"OIDC_JWKS_FILE"`
+	UsernameClaim string        `env:"OIDC_USERNAME_CLAIM" env-default:"email"`
+	JWKSRefresh   time.Duration `env:"OIDC_JWKS_REFRESH" env-default:"1h"`
+}
+
+// PasswordConfig is the policy for new passwords, existing passwords keep working.
+// MinClasses is the number of character classes (lower and upper case letters, digits,
+// other characters) a password must contain. BcryptCost may be raised at any time,
+// stored hashes are upgraded on the next login.
+type PasswordConfig struct {
+	MinLength  int `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MinClasses int `env:"PASSWORD_MIN_CLASSES" env-default:"2"`
+	BcryptCost int `env:"BCRYPT_COST" env-default:"10"`
+}
+
+// LockoutConfig limits failed logins. After MaxFailures failed logins of one user
+// (MaxIPFailures from one client address) within Window further attempts are locked
+// for LockDuration, which doubles with every next failure up to MaxLockDuration.
+type LockoutConfig struct {
+	MaxFailures     int           `env:"LOGIN_MAX_FAILURES" env-default:"5"`
+	MaxIPFailures   int           `env:"LOGIN_MAX_IP_FAILURES" env-default:"20"`
+	Window          time.Duration `env:"LOGIN_FAILURE_WINDOW" env-default:"1h"`
+	LockDuration    time.Duration `env:"LOGIN_LOCK_DURATION" env-default:"1m"`
+	MaxLockDuration time.Duration `env:"LOGIN_MAX_LOCK_DURATION" env-default:"1h"`
+}
+
+// JWTConfig holds the keys tokens are signed with. Tokens are signed either with
+// an HMAC secret, given directly or as a path to a file containing the secret,
+// or with an RSA private key (RS256) from a PEM file. Public RSA keys are published
+// at /.well-known/jwks.json. Tokens are signed with the current key, the previous
+// key only verifies tokens issued before rotation. Access tokens are short-lived,
+// clients renew them with single-use refresh tokens stored server-side.
+type JWTConfig struct {
+	Secret                string        `env:"JWT_SECRET"`
+	SecretFile            string        `env:"JWT_SECRET_FILE"`
+	PrivateKeyFile        string        `env:"JWT_PRIVATE_KEY_FILE"`
+	KeyID                 string        `env:"JWT_KEY_ID" env-default:"default"`
+	PreviousSecret        string        `env:"JWT_PREVIOUS_SECRET"`
+	PreviousSecretFile    string        `env:"JWT_PREVIOUS_SECRET_FILE"`
+	PreviousPublicKeyFile string        `env:"JWT_PREVIOUS_PUBLIC_KEY_FILE"`
+	PreviousKeyID         string        `env:"JWT_PREVIOUS_KEY_ID"`
+	AccessTokenTTL        time.Duration `env:"JWT_ACCESS_TTL" env-default:"15m"`
+	RefreshTokenTTL       time.Duration `env:"JWT_REFRESH_TTL" env-default:"720h"`
 }
 
 func MustLoad() *Config {
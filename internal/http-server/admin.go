@@ -0,0 +1,232 @@
+package httpserver
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) PostApiAdminMerch(ctx *gin.Context, req PostApiAdminMerchRequestObject) (PostApiAdminMerchResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminMerch401JSONResponse(errResp), nil
+	}
+	item := storage.MerchItem{Name: req.Body.Name, Price: req.Body.Price, Available: true}
+	if req.Body.Description != nil {
+		item.Description = *req.Body.Description
+	}
+	if req.Body.Available != nil {
+		item.Available = *req.Body.Available
+	}
+	if item.Name == "" {
+		errResp := ErrorResponse{Errors: &invalidItemNameErrMsg}
+		return PostApiAdminMerch400JSONResponse(errResp), nil
+	}
+	if item.Price <= 0 {
+		errResp := ErrorResponse{Errors: &invalidPriceErrMsg}
+		return PostApiAdminMerch400JSONResponse(errResp), nil
+	}
+	err := s.storage.AddMerchItem(item)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemExists) {
+			errResp := ErrorResponse{Errors: &itemExistsErrMsg}
+			return PostApiAdminMerch409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminMerch500JSONResponse(errResp), nil
+	}
+	return PostApiAdminMerch201JSONResponse(convertMerchItem(item)), nil
+}
+
+func (s *APIServer) PatchApiAdminMerchItem(ctx *gin.Context, req PatchApiAdminMerchItemRequestObject) (PatchApiAdminMerchItemResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PatchApiAdminMerchItem401JSONResponse(errResp), nil
+	}
+	update := storage.MerchUpdate{
+		Name:        req.Body.Name,
+		Price:       req.Body.Price,
+		Description: req.Body.Description,
+		Available:   req.Body.Available,
+	}
+	if update.Name != nil && *update.Name == "" {
+		errResp := ErrorResponse{Errors: &invalidItemNameErrMsg}
+		return PatchApiAdminMerchItem400JSONResponse(errResp), nil
+	}
+	if update.Price != nil && *update.Price <= 0 {
+		errResp := ErrorResponse{Errors: &invalidPriceErrMsg}
+		return PatchApiAdminMerchItem400JSONResponse(errResp), nil
+	}
+	item, err := s.storage.UpdateMerchItem(req.Item, update)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemNotFound) {
+			errResp := ErrorResponse{Errors: &noSuchItemErrMsg}
+			return PatchApiAdminMerchItem404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrItemExists) {
+			errResp := ErrorResponse{Errors: &itemExistsErrMsg}
+			return PatchApiAdminMerchItem409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PatchApiAdminMerchItem500JSONResponse(errResp), nil
+	}
+	return PatchApiAdminMerchItem200JSONResponse(convertMerchItem(*item)), nil
+}
+
+func (s *APIServer) DeleteApiAdminMerchItem(ctx *gin.Context, req DeleteApiAdminMerchItemRequestObject) (DeleteApiAdminMerchItemResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return DeleteApiAdminMerchItem401JSONResponse(errResp), nil
+	}
+	err := s.storage.RetireMerchItem(req.Item)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemNotFound) {
+			errResp := ErrorResponse{Errors: &noSuchItemErrMsg}
+			return DeleteApiAdminMerchItem404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiAdminMerchItem500JSONResponse(errResp), nil
+	}
+	return DeleteApiAdminMerchItem204Response{}, nil
+}
+
+func (s *APIServer) PostApiAdminUsersUsernameRevokeSessions(ctx *gin.Context, req PostApiAdminUsersUsernameRevokeSessionsRequestObject) (PostApiAdminUsersUsernameRevokeSessionsResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminUsersUsernameRevokeSessions401JSONResponse(errResp), nil
+	}
+	err := s.storage.RevokeUserTokens(req.Username, revocationTime())
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &userNotFoundErrMsg}
+			return PostApiAdminUsersUsernameRevokeSessions404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminUsersUsernameRevokeSessions500JSONResponse(errResp), nil
+	}
+	s.log.Info("user sessions revoked", "admin", ctx.GetString(usernameKey), "user", req.Username)
+	return PostApiAdminUsersUsernameRevokeSessions204Response{}, nil
+}
+
+func (s *APIServer) PutApiAdminUsersUsernameRole(ctx *gin.Context, req PutApiAdminUsersUsernameRoleRequestObject) (PutApiAdminUsersUsernameRoleResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PutApiAdminUsersUsernameRole401JSONResponse(errResp), nil
+	}
+	role := req.Body.Role
+	if role != Admin && role != User {
+		errResp := ErrorResponse{Errors: &invalidRoleErrMsg}
+		return PutApiAdminUsersUsernameRole400JSONResponse(errResp), nil
+	}
+	err := s.storage.SetUserRole(req.Username, string(role))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &userNotFoundErrMsg}
+			return PutApiAdminUsersUsernameRole404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrServiceAccountRole) {
+			errResp := ErrorResponse{Errors: &serviceAccountRoleErrMsg}
+			return PutApiAdminUsersUsernameRole400JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PutApiAdminUsersUsernameRole500JSONResponse(errResp), nil
+	}
+	return PutApiAdminUsersUsernameRole204Response{}, nil
+}
+
+// maxAmount is the largest number of coins moved at once, amounts are stored in INT columns.
+const maxAmount = math.MaxInt32
+
+func validAmount(amount int) bool {
+	return amount > 0 && amount <= maxAmount
+}
+
+func (s *APIServer) PostApiAdminCoinsGrant(ctx *gin.Context, req PostApiAdminCoinsGrantRequestObject) (PostApiAdminCoinsGrantResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminCoinsGrant401JSONResponse(errResp), nil
+	}
+	users, amount, reason := req.Body.Users, req.Body.Amount, strings.TrimSpace(req.Body.Reason)
+	if len(users) == 0 {
+		errResp := ErrorResponse{Errors: &emptyUsersErrMsg}
+		return PostApiAdminCoinsGrant400JSONResponse(errResp), nil
+	}
+	seen := make(map[string]bool, len(users))
+	for _, name := range users {
+		if seen[name] {
+			errResp := ErrorResponse{Errors: &duplicateUserErrMsg}
+			return PostApiAdminCoinsGrant400JSONResponse(errResp), nil
+		}
+		seen[name] = true
+	}
+	if !validAmount(amount) {
+		errResp := ErrorResponse{Errors: &invalidAmountErrMsg}
+		return PostApiAdminCoinsGrant400JSONResponse(errResp), nil
+	}
+	if reason == "" {
+		errResp := ErrorResponse{Errors: &emptyReasonErrMsg}
+		return PostApiAdminCoinsGrant400JSONResponse(errResp), nil
+	}
+	admin := ctx.GetString(usernameKey)
+	err := s.storage.GrantCoins(admin, users, amount, reason)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errMsg := err.Error()
+			errResp := ErrorResponse{Errors: &errMsg}
+			return PostApiAdminCoinsGrant404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminCoinsGrant500JSONResponse(errResp), nil
+	}
+	s.log.Info("coins granted", "admin", admin, "users", users, "amount", amount, "reason", reason)
+	return PostApiAdminCoinsGrant204Response{}, nil
+}
+
+func (s *APIServer) PostApiAdminCoinsClawback(ctx *gin.Context, req PostApiAdminCoinsClawbackRequestObject) (PostApiAdminCoinsClawbackResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminCoinsClawback401JSONResponse(errResp), nil
+	}
+	user, amount, reason := req.Body.User, req.Body.Amount, strings.TrimSpace(req.Body.Reason)
+	if !validAmount(amount) {
+		errResp := ErrorResponse{Errors: &invalidAmountErrMsg}
+		return PostApiAdminCoinsClawback400JSONResponse(errResp), nil
+	}
+	if reason == "" {
+		errResp := ErrorResponse{Errors: &emptyReasonErrMsg}
+		return PostApiAdminCoinsClawback400JSONResponse(errResp), nil
+	}
+	admin := ctx.GetString(usernameKey)
+	err := s.storage.ClawbackCoins(admin, user, amount, reason)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &userNotFoundErrMsg}
+			return PostApiAdminCoinsClawback404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrUnsufficientBalance) {
+			errResp := ErrorResponse{Errors: &insufficientBalanceErrMsg}
+			return PostApiAdminCoinsClawback400JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminCoinsClawback500JSONResponse(errResp), nil
+	}
+	s.log.Info("coins clawed back", "admin", admin, "user", user, "amount", amount, "reason", reason)
+	return PostApiAdminCoinsClawback204Response{}, nil
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/oapi-codegen/runtime"
@@ -15,9 +16,99 @@ import (
 )
 
 const (
+	ApiKeyAuthScopes = "ApiKeyAuth.Scopes"
 	BearerAuthScopes = "BearerAuth.Scopes"
 )
 
+// Defines values for AdjustmentType.
+const (
+	Clawback AdjustmentType = "clawback"
+	Grant    AdjustmentType = "grant"
+)
+
+// Defines values for ApiKeyScope.
+const (
+	BalancesRead  ApiKeyScope = "balances:read"
+	CoinsClawback ApiKeyScope = "coins:clawback"
+	CoinsGrant    ApiKeyScope = "coins:grant"
+)
+
+// Defines values for HistoryDirection.
+const (
+	Received HistoryDirection = "received"
+	Sent     HistoryDirection = "sent"
+)
+
+// Defines values for OrderStatus.
+const (
+	Cancelled  OrderStatus = "cancelled"
+	Delivered  OrderStatus = "delivered"
+	Placed     OrderStatus = "placed"
+	Processing OrderStatus = "processing"
+	Shipped    OrderStatus = "shipped"
+)
+
+// Defines values for Role.
+const (
+	Admin Role = "admin"
+	User  Role = "user"
+)
+
+// AdjustmentType Тип операции, grant - начисление, clawback - списание.
+type AdjustmentType string
+
+// ApiKey defines model for ApiKey.
+type ApiKey struct {
+	// CreatedAt Дата и время выпуска ключа.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// Description Описание ключа.
+	Description *string `json:"description,omitempty"`
+
+	// Id Идентификатор ключа.
+	Id *int `json:"id,omitempty"`
+
+	// LastUsedAt Дата и время последнего использования ключа.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
+	// Prefix Начало ключа, по которому его можно узнать.
+	Prefix *string        `json:"prefix,omitempty"`
+	Scopes *[]ApiKeyScope `json:"scopes,omitempty"`
+
+	// RevokedAt Дата и время отзыва ключа.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// ApiKeyCreateRequest defines model for ApiKeyCreateRequest.
+type ApiKeyCreateRequest struct {
+	// Description Описание ключа, например название интеграции.
+	Description *string `json:"description,omitempty"`
+
+	// Scopes Права ключа, должно быть указано хотя бы одно.
+	Scopes []ApiKeyScope `json:"scopes"`
+}
+
+// ApiKeyCreatedResponse defines model for ApiKeyCreatedResponse.
+type ApiKeyCreatedResponse struct {
+	// Id Идентификатор ключа.
+	Id *int `json:"id,omitempty"`
+
+	// Key API-ключ для заголовка X-API-Key. Показывается только один раз.
+	Key *string `json:"key,omitempty"`
+
+	// Prefix Начало ключа, по которому его можно узнать.
+	Prefix *string        `json:"prefix,omitempty"`
+	Scopes *[]ApiKeyScope `json:"scopes,omitempty"`
+}
+
+// ApiKeyListResponse defines model for ApiKeyListResponse.
+type ApiKeyListResponse struct {
+	Keys *[]ApiKey `json:"keys,omitempty"`
+}
+
+// ApiKeyScope Право API-ключа, coins:grant - начисление монет, coins:clawback - списание монет, balances:read - просмотр балансов.
+type ApiKeyScope string
+
 // AuthRequest defines model for AuthRequest.
 type AuthRequest struct {
 	// Password Пароль для аутентификации.
@@ -29,30 +120,223 @@ type AuthRequest struct {
 
 // AuthResponse defines model for AuthResponse.
 type AuthResponse struct {
+	// Created Пользователь был создан этим запросом.
+	Created *bool `json:"created,omitempty"`
+
+	// ExpiresIn Время жизни токена доступа в секундах.
+	ExpiresIn *int `json:"expiresIn,omitempty"`
+
+	// RefreshToken Токен обновления, обменивается на новую пару токенов через /api/auth/refresh. Используется один раз.
+	RefreshToken *string `json:"refreshToken,omitempty"`
+
 	// Token JWT-токен для доступа к защищенным ресурсам.
 	Token *string `json:"token,omitempty"`
 }
 
+// BalanceResponse defines model for BalanceResponse.
+type BalanceResponse struct {
+	// Coins Количество доступных монет.
+	Coins *int `json:"coins,omitempty"`
+
+	// Username Имя пользователя.
+	Username *string `json:"username,omitempty"`
+}
+
+// BuyRequest defines model for BuyRequest.
+type BuyRequest struct {
+	// Item Название предмета.
+	Item string `json:"item"`
+
+	// Quantity Количество предметов, не больше максимального за один запрос.
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+// Cart defines model for Cart.
+type Cart struct {
+	// Items Предметы корзины в порядке добавления.
+	Items *[]CartItem `json:"items,omitempty"`
+
+	// Total Стоимость всех предметов корзины.
+	Total *int `json:"total,omitempty"`
+}
+
+// CartItem defines model for CartItem.
+type CartItem struct {
+	// Available Можно ли купить предмет сейчас. Снятые с продажи и убранные из каталога предметы остаются в корзине, но не дают ее купить.
+	Available *bool `json:"available,omitempty"`
+
+	// Cost Стоимость всех предметов строки.
+	Cost *int `json:"cost,omitempty"`
+
+	// Item Название предмета.
+	Item *string `json:"item,omitempty"`
+
+	// Price Текущая цена одного предмета.
+	Price *int `json:"price,omitempty"`
+
+	// Quantity Количество предметов.
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+// CartItemRequest defines model for CartItemRequest.
+type CartItemRequest struct {
+	// Quantity Количество предметов, не больше максимального за один запрос.
+	Quantity int `json:"quantity"`
+}
+
+// CheckoutErrorResponse defines model for CheckoutErrorResponse.
+type CheckoutErrorResponse struct {
+	// Coins Количество доступных монет.
+	Coins *int `json:"coins,omitempty"`
+
+	// Errors Сообщение об ошибке, описывающее проблему.
+	Errors *string `json:"errors,omitempty"`
+
+	// Lines Предметы корзины, которые нельзя купить.
+	Lines *[]CheckoutLineError `json:"lines,omitempty"`
+
+	// Total Стоимость корзины.
+	Total *int `json:"total,omitempty"`
+}
+
+// CheckoutLineError defines model for CheckoutLineError.
+type CheckoutLineError struct {
+	// Error Почему предмет нельзя купить.
+	Error *string `json:"error,omitempty"`
+
+	// Item Название предмета.
+	Item *string `json:"item,omitempty"`
+}
+
+// ClawbackRequest defines model for ClawbackRequest.
+type ClawbackRequest struct {
+	// Amount Количество списываемых монет.
+	Amount int `json:"amount"`
+
+	// Reason Причина списания.
+	Reason string `json:"reason"`
+
+	// User Имя пользователя, у которого списываются монеты.
+	User string `json:"user"`
+}
+
+// CoinAdjustment defines model for CoinAdjustment.
+type CoinAdjustment struct {
+	// Amount Количество начисленных или списанных монет.
+	Amount *int `json:"amount,omitempty"`
+
+	// Date Дата и время операции.
+	Date *time.Time `json:"date,omitempty"`
+
+	// Id Идентификатор транзакции.
+	Id *int `json:"id,omitempty"`
+
+	// Reason Причина начисления или списания.
+	Reason *string `json:"reason,omitempty"`
+
+	// Type Тип операции, grant - начисление, clawback - списание.
+	Type *AdjustmentType `json:"type,omitempty"`
+}
+
+// CoinRefund defines model for CoinRefund.
+type CoinRefund struct {
+	// Amount Количество возвращенных монет.
+	Amount *int `json:"amount,omitempty"`
+
+	// Date Дата и время возврата.
+	Date *time.Time `json:"date,omitempty"`
+
+	// Id Идентификатор транзакции.
+	Id *int `json:"id,omitempty"`
+
+	// Order Идентификатор отмененного заказа.
+	Order *int `json:"order,omitempty"`
+}
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	// Errors Сообщение об ошибке, описывающее проблему.
 	Errors *string `json:"errors,omitempty"`
 }
 
+// GrantRequest defines model for GrantRequest.
+type GrantRequest struct {
+	// Amount Количество монет для каждого пользователя.
+	Amount int `json:"amount"`
+
+	// Reason Причина начисления.
+	Reason string `json:"reason"`
+
+	// Users Имена пользователей, которым начисляются монеты.
+	Users []string `json:"users"`
+}
+
+// HistoryDirection Направление перевода относительно пользователя.
+type HistoryDirection string
+
+// HistoryEntry defines model for HistoryEntry.
+type HistoryEntry struct {
+	// Amount Количество монет.
+	Amount *int `json:"amount,omitempty"`
+
+	// Counterparty Имя другого участника перевода.
+	Counterparty *string `json:"counterparty,omitempty"`
+
+	// Date Дата и время перевода.
+	Date *time.Time `json:"date,omitempty"`
+
+	// Direction Направление перевода относительно пользователя.
+	Direction *HistoryDirection `json:"direction,omitempty"`
+
+	// Id Идентификатор транзакции.
+	Id *int `json:"id,omitempty"`
+}
+
+// HistoryResponse defines model for HistoryResponse.
+type HistoryResponse struct {
+	// Items Переводы, от новых к старым.
+	Items *[]HistoryEntry `json:"items,omitempty"`
+
+	// NextCursor Курсор следующей страницы, отсутствует на последней странице.
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
 // InfoResponse defines model for InfoResponse.
 type InfoResponse struct {
+	// CoinHistory Последние переводы, не более 100 в каждом направлении. Полная история доступна в /api/history.
 	CoinHistory *struct {
+		// Adjustments Начисления и списания монет администраторами, от новых к старым.
+		Adjustments *[]CoinAdjustment `json:"adjustments,omitempty"`
+
+		// Received Полученные переводы, от новых к старым.
 		Received *[]struct {
 			// Amount Количество полученных монет.
 			Amount *int `json:"amount,omitempty"`
 
+			// Date Дата и время перевода.
+			Date *time.Time `json:"date,omitempty"`
+
 			// FromUser Имя пользователя, который отправил монеты.
 			FromUser *string `json:"fromUser,omitempty"`
+
+			// Id Идентификатор транзакции.
+			Id *int `json:"id,omitempty"`
 		} `json:"received,omitempty"`
+
+		// Refunds Возвраты монет за отмененные заказы, от новых к старым.
+		Refunds *[]CoinRefund `json:"refunds,omitempty"`
+
+		// Sent Отправленные переводы, от новых к старым.
 		Sent *[]struct {
 			// Amount Количество отправленных монет.
 			Amount *int `json:"amount,omitempty"`
 
+			// Date Дата и время перевода.
+			Date *time.Time `json:"date,omitempty"`
+
+			// Id Идентификатор транзакции.
+			Id *int `json:"id,omitempty"`
+
 			// ToUser Имя пользователя, которому отправлены монеты.
 			ToUser *string `json:"toUser,omitempty"`
 		} `json:"sent,omitempty"`
@@ -67,6 +351,187 @@ type InfoResponse struct {
 		// Type Тип предмета.
 		Type *string `json:"type,omitempty"`
 	} `json:"inventory,omitempty"`
+
+	// Purchases История покупок, от новых к старым.
+	Purchases *[]struct {
+		// Date Дата и время покупки.
+		Date *time.Time `json:"date,omitempty"`
+
+		// Item Название купленного предмета.
+		Item *string `json:"item,omitempty"`
+
+		// Price Цена одного предмета на момент покупки.
+		Price *int `json:"price,omitempty"`
+
+		// Quantity Количество купленных предметов.
+		Quantity *int `json:"quantity,omitempty"`
+	} `json:"purchases,omitempty"`
+}
+
+// JWK Публичный ключ в формате JSON Web Key (RFC 7517).
+type JWK struct {
+	// Alg Алгоритм подписи.
+	Alg string `json:"alg"`
+
+	// E Открытая экспонента RSA-ключа, base64url.
+	E string `json:"e"`
+
+	// Kid Идентификатор ключа из заголовка токена.
+	Kid string `json:"kid"`
+
+	// Kty Тип ключа.
+	Kty string `json:"kty"`
+
+	// N Модуль RSA-ключа, base64url.
+	N string `json:"n"`
+
+	// Use Назначение ключа.
+	Use string `json:"use"`
+}
+
+// JWKSet defines model for JWKSet.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LogoutRequest defines model for LogoutRequest.
+type LogoutRequest struct {
+	// RefreshToken Токен обновления текущей сессии, отзывается вместе со всеми токенами, выданными при том же входе.
+	RefreshToken *string `json:"refreshToken,omitempty"`
+}
+
+// MerchCreateRequest defines model for MerchCreateRequest.
+type MerchCreateRequest struct {
+	// Available Доступен ли предмет для покупки, по умолчанию true.
+	Available *bool `json:"available,omitempty"`
+
+	// Description Описание предмета.
+	Description *string `json:"description,omitempty"`
+
+	// Name Название предмета.
+	Name string `json:"name"`
+
+	// Price Цена в монетах.
+	Price int `json:"price"`
+}
+
+// MerchItem defines model for MerchItem.
+type MerchItem struct {
+	// Available Доступен ли предмет для покупки.
+	Available *bool `json:"available,omitempty"`
+
+	// Description Описание предмета.
+	Description *string `json:"description,omitempty"`
+
+	// Name Название предмета.
+	Name *string `json:"name,omitempty"`
+
+	// Price Цена в монетах.
+	Price *int `json:"price,omitempty"`
+}
+
+// MerchListResponse defines model for MerchListResponse.
+type MerchListResponse struct {
+	// Items Предметы каталога.
+	Items *[]MerchItem `json:"items,omitempty"`
+}
+
+// MerchUpdateRequest defines model for MerchUpdateRequest.
+type MerchUpdateRequest struct {
+	// Available Доступен ли предмет для покупки.
+	Available *bool `json:"available,omitempty"`
+
+	// Description Новое описание предмета.
+	Description *string `json:"description,omitempty"`
+
+	// Name Новое название предмета.
+	Name *string `json:"name,omitempty"`
+
+	// Price Новая цена в монетах.
+	Price *int `json:"price,omitempty"`
+}
+
+// Order defines model for Order.
+type Order struct {
+	// CancelledAt Дата и время отмены заказа.
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+
+	// DeliveredAt Дата и время доставки заказа.
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+
+	// Id Номер заказа.
+	Id    *int         `json:"id,omitempty"`
+	Items *[]OrderItem `json:"items,omitempty"`
+
+	// PlacedAt Дата и время оформления заказа.
+	PlacedAt *time.Time `json:"placedAt,omitempty"`
+
+	// ProcessingAt Дата и время начала сборки заказа.
+	ProcessingAt *time.Time `json:"processingAt,omitempty"`
+
+	// ShippedAt Дата и время отправки заказа.
+	ShippedAt *time.Time `json:"shippedAt,omitempty"`
+
+	// Status Статус заказа, placed - оформлен, processing - собирается, shipped - отправлен, delivered - доставлен, cancelled - отменен.
+	Status *OrderStatus `json:"status,omitempty"`
+
+	// Total Стоимость заказа.
+	Total *int `json:"total,omitempty"`
+
+	// User Имя покупателя.
+	User *string `json:"user,omitempty"`
+}
+
+// OrderItem defines model for OrderItem.
+type OrderItem struct {
+	// Item Название предмета.
+	Item *string `json:"item,omitempty"`
+
+	// Price Цена одного предмета на момент покупки.
+	Price *int `json:"price,omitempty"`
+
+	// Quantity Количество предметов.
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+// OrderListResponse defines model for OrderListResponse.
+type OrderListResponse struct {
+	// NextCursor Курсор следующей страницы, отсутствует на последней странице.
+	NextCursor *string  `json:"nextCursor,omitempty"`
+	Orders     *[]Order `json:"orders,omitempty"`
+}
+
+// OrderStatus Статус заказа, placed - оформлен, processing - собирается, shipped - отправлен, delivered - доставлен, cancelled - отменен.
+type OrderStatus string
+
+// OrderStatusRequest defines model for OrderStatusRequest.
+type OrderStatusRequest struct {
+	// Status Статус заказа, placed - оформлен, processing - собирается, shipped - отправлен, delivered - доставлен, cancelled - отменен.
+	Status OrderStatus `json:"status"`
+}
+
+// PasswordChangeRequest defines model for PasswordChangeRequest.
+type PasswordChangeRequest struct {
+	// NewPassword Новый пароль.
+	NewPassword string `json:"newPassword"`
+
+	// OldPassword Текущий пароль.
+	OldPassword string `json:"oldPassword"`
+}
+
+// RefreshRequest defines model for RefreshRequest.
+type RefreshRequest struct {
+	// RefreshToken Токен обновления, полученный при аутентификации или предыдущем обновлении.
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Role Роль пользователя.
+type Role string
+
+// RoleRequest defines model for RoleRequest.
+type RoleRequest struct {
+	// Role Роль пользователя.
+	Role Role `json:"role"`
 }
 
 // SendCoinRequest defines model for SendCoinRequest.
@@ -78,26 +543,236 @@ type SendCoinRequest struct {
 	ToUser string `json:"toUser"`
 }
 
+// ServiceAccount defines model for ServiceAccount.
+type ServiceAccount struct {
+	// Name Имя сервисной учетной записи.
+	Name *string `json:"name,omitempty"`
+}
+
+// ServiceAccountRequest defines model for ServiceAccountRequest.
+type ServiceAccountRequest struct {
+	// Name Имя сервисной учетной записи, те же правила, что и для имени пользователя.
+	Name string `json:"name"`
+}
+
+// GetApiAdminOrdersParams defines parameters for GetApiAdminOrders.
+type GetApiAdminOrdersParams struct {
+	// Status Только заказы в этом статусе.
+	Status *OrderStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// Cursor Курсор следующей страницы из предыдущего ответа.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit Размер страницы.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PostApiBuyParams defines parameters for PostApiBuy.
+type PostApiBuyParams struct {
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом в течение суток не выполняется снова, а получает сохраненный ответ.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// GetApiBuyItemParams defines parameters for GetApiBuyItem.
+type GetApiBuyItemParams struct {
+	// Quantity Количество предметов, не больше максимального за один запрос.
+	Quantity *int `form:"quantity,omitempty" json:"quantity,omitempty"`
+
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом в течение суток не выполняется снова, а получает сохраненный ответ.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// GetApiHistoryParams defines parameters for GetApiHistory.
+type GetApiHistoryParams struct {
+	// Direction Только отправленные или только полученные переводы.
+	Direction *HistoryDirection `form:"direction,omitempty" json:"direction,omitempty"`
+
+	// Counterparty Имя другого участника перевода.
+	Counterparty *string `form:"counterparty,omitempty" json:"counterparty,omitempty"`
+
+	// From Начало периода (включительно).
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Конец периода (не включительно).
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Cursor Курсор следующей страницы из предыдущего ответа.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit Размер страницы.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetApiOrdersParams defines parameters for GetApiOrders.
+type GetApiOrdersParams struct {
+	// Cursor Курсор следующей страницы из предыдущего ответа.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit Размер страницы.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PostApiSendCoinParams defines parameters for PostApiSendCoin.
+type PostApiSendCoinParams struct {
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом в течение суток не выполняется снова, а получает сохраненный ответ.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// PostApiAdminCoinsClawbackJSONRequestBody defines body for PostApiAdminCoinsClawback for application/json ContentType.
+type PostApiAdminCoinsClawbackJSONRequestBody = ClawbackRequest
+
+// PostApiAdminCoinsGrantJSONRequestBody defines body for PostApiAdminCoinsGrant for application/json ContentType.
+type PostApiAdminCoinsGrantJSONRequestBody = GrantRequest
+
+// PostApiAdminMerchJSONRequestBody defines body for PostApiAdminMerch for application/json ContentType.
+type PostApiAdminMerchJSONRequestBody = MerchCreateRequest
+
+// PatchApiAdminMerchItemJSONRequestBody defines body for PatchApiAdminMerchItem for application/json ContentType.
+type PatchApiAdminMerchItemJSONRequestBody = MerchUpdateRequest
+
+// PutApiAdminOrdersIdStatusJSONRequestBody defines body for PutApiAdminOrdersIdStatus for application/json ContentType.
+type PutApiAdminOrdersIdStatusJSONRequestBody = OrderStatusRequest
+
+// PostApiAdminServiceAccountsJSONRequestBody defines body for PostApiAdminServiceAccounts for application/json ContentType.
+type PostApiAdminServiceAccountsJSONRequestBody = ServiceAccountRequest
+
+// PostApiAdminServiceAccountsAccountKeysJSONRequestBody defines body for PostApiAdminServiceAccountsAccountKeys for application/json ContentType.
+type PostApiAdminServiceAccountsAccountKeysJSONRequestBody = ApiKeyCreateRequest
+
+// PutApiAdminUsersUsernameRoleJSONRequestBody defines body for PutApiAdminUsersUsernameRole for application/json ContentType.
+type PutApiAdminUsersUsernameRoleJSONRequestBody = RoleRequest
+
 // PostApiAuthJSONRequestBody defines body for PostApiAuth for application/json ContentType.
 type PostApiAuthJSONRequestBody = AuthRequest
 
+// PostApiAuthLogoutJSONRequestBody defines body for PostApiAuthLogout for application/json ContentType.
+type PostApiAuthLogoutJSONRequestBody = LogoutRequest
+
+// PostApiAuthPasswordJSONRequestBody defines body for PostApiAuthPassword for application/json ContentType.
+type PostApiAuthPasswordJSONRequestBody = PasswordChangeRequest
+
+// PostApiAuthRefreshJSONRequestBody defines body for PostApiAuthRefresh for application/json ContentType.
+type PostApiAuthRefreshJSONRequestBody = RefreshRequest
+
+// PostApiAuthRegisterJSONRequestBody defines body for PostApiAuthRegister for application/json ContentType.
+type PostApiAuthRegisterJSONRequestBody = AuthRequest
+
+// PostApiBuyJSONRequestBody defines body for PostApiBuy for application/json ContentType.
+type PostApiBuyJSONRequestBody = BuyRequest
+
+// PutApiCartItemJSONRequestBody defines body for PutApiCartItem for application/json ContentType.
+type PutApiCartItemJSONRequestBody = CartItemRequest
+
 // PostApiSendCoinJSONRequestBody defines body for PostApiSendCoin for application/json ContentType.
 type PostApiSendCoinJSONRequestBody = SendCoinRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// Аутентификация и получение JWT-токена. При первой аутентификации пользователь создается автоматически.
+	// Получить публичные ключи для проверки JWT-токенов. HMAC-ключи не публикуются.
+	// (GET /.well-known/jwks.json)
+	GetWellKnownJwksJson(c *gin.Context)
+	// Списать монеты у пользователя.
+	// (POST /api/admin/coins/clawback)
+	PostApiAdminCoinsClawback(c *gin.Context)
+	// Начислить монеты одному или нескольким пользователям. Начисление выполняется для всех пользователей или ни для кого.
+	// (POST /api/admin/coins/grant)
+	PostApiAdminCoinsGrant(c *gin.Context)
+	// Добавить предмет в каталог.
+	// (POST /api/admin/merch)
+	PostApiAdminMerch(c *gin.Context)
+	// Убрать предмет из каталога. Предмет остается в инвентаре пользователей.
+	// (DELETE /api/admin/merch/{item})
+	DeleteApiAdminMerchItem(c *gin.Context, item string)
+	// Изменить название, цену, описание или доступность предмета.
+	// (PATCH /api/admin/merch/{item})
+	PatchApiAdminMerchItem(c *gin.Context, item string)
+	// Получить заказы всех пользователей для склада постранично, от старых к новым.
+	// (GET /api/admin/orders)
+	GetApiAdminOrders(c *gin.Context, params GetApiAdminOrdersParams)
+	// Отменить заказ любого пользователя с возвратом монет, независимо от срока возврата.
+	// (POST /api/admin/orders/{id}/refund)
+	PostApiAdminOrdersIdRefund(c *gin.Context, id int)
+	// Перевести заказ в следующий статус. Статусы меняются только вперед, промежуточные можно пропускать.
+	// (PUT /api/admin/orders/{id}/status)
+	PutApiAdminOrdersIdStatus(c *gin.Context, id int)
+	// Создать сервисную учетную запись для интеграций. Сервисная учетная запись не может входить по паролю и работает только через API-ключи.
+	// (POST /api/admin/service-accounts)
+	PostApiAdminServiceAccounts(c *gin.Context)
+	// Получить список API-ключей сервисной учетной записи. Сами ключи не возвращаются.
+	// (GET /api/admin/service-accounts/{account}/keys)
+	GetApiAdminServiceAccountsAccountKeys(c *gin.Context, account string)
+	// Выпустить API-ключ для сервисной учетной записи. Ключ возвращается только в ответе на этот запрос.
+	// (POST /api/admin/service-accounts/{account}/keys)
+	PostApiAdminServiceAccountsAccountKeys(c *gin.Context, account string)
+	// Отозвать API-ключ сервисной учетной записи.
+	// (DELETE /api/admin/service-accounts/{account}/keys/{id})
+	DeleteApiAdminServiceAccountsAccountKeysId(c *gin.Context, account string, id int)
+	// Получить баланс монет пользователя.
+	// (GET /api/admin/users/{username}/balance)
+	GetApiAdminUsersUsernameBalance(c *gin.Context, username string)
+	// Снять блокировку входа с учетной записи пользователя.
+	// (DELETE /api/admin/users/{username}/lockout)
+	DeleteApiAdminUsersUsernameLockout(c *gin.Context, username string)
+	// Завершить все сессии пользователя. Выданные ранее токены доступа и обновления становятся недействительными.
+	// (POST /api/admin/users/{username}/revoke-sessions)
+	PostApiAdminUsersUsernameRevokeSessions(c *gin.Context, username string)
+	// Назначить роль пользователю.
+	// (PUT /api/admin/users/{username}/role)
+	PutApiAdminUsersUsernameRole(c *gin.Context, username string)
+	// Аутентификация и получение JWT-токена. В режиме SIGNUP_MODE=auto при первой аутентификации пользователь создается автоматически.
 	// (POST /api/auth)
 	PostApiAuth(c *gin.Context)
+	// Выйти из системы. Текущий токен доступа и переданный токен обновления становятся недействительными.
+	// (POST /api/auth/logout)
+	PostApiAuthLogout(c *gin.Context)
+	// Сменить пароль. Все сессии пользователя завершаются, в ответе выдается новая пара токенов.
+	// (POST /api/auth/password)
+	PostApiAuthPassword(c *gin.Context)
+	// Обменять токен обновления на новую пару токенов. Использованный токен обновления становится недействительным.
+	// (POST /api/auth/refresh)
+	PostApiAuthRefresh(c *gin.Context)
+	// Зарегистрировать нового пользователя и получить JWT-токен.
+	// (POST /api/auth/register)
+	PostApiAuthRegister(c *gin.Context)
+	// Купить один или несколько одинаковых предметов за монеты. Стоимость считается по текущей цене, покупка выполняется целиком или не выполняется.
+	// (POST /api/buy)
+	PostApiBuy(c *gin.Context, params PostApiBuyParams)
 	// Купить предмет за монеты.
 	// (GET /api/buy/{item})
-	GetApiBuyItem(c *gin.Context, item string)
+	GetApiBuyItem(c *gin.Context, item string, params GetApiBuyItemParams)
+	// Получить содержимое корзины по текущим ценам.
+	// (GET /api/cart)
+	GetApiCart(c *gin.Context)
+	// Купить все предметы корзины одной покупкой. Если хотя бы один предмет купить нельзя или монет не хватает, ничего не покупается.
+	// (POST /api/cart/checkout)
+	PostApiCartCheckout(c *gin.Context)
+	// Убрать предмет из корзины.
+	// (DELETE /api/cart/{item})
+	DeleteApiCartItem(c *gin.Context, item string)
+	// Положить предмет в корзину или изменить его количество.
+	// (PUT /api/cart/{item})
+	PutApiCartItem(c *gin.Context, item string)
+	// Получить историю переводов монет постранично, с фильтрами.
+	// (GET /api/history)
+	GetApiHistory(c *gin.Context, params GetApiHistoryParams)
 	// Получить информацию о монетах, инвентаре и истории транзакций.
 	// (GET /api/info)
 	GetApiInfo(c *gin.Context)
+	// Получить каталог мерча с ценами.
+	// (GET /api/merch)
+	GetApiMerch(c *gin.Context)
+	// Получить информацию о предмете из каталога.
+	// (GET /api/merch/{item})
+	GetApiMerchItem(c *gin.Context, item string)
+	// Получить заказы пользователя постранично, от новых к старым.
+	// (GET /api/orders)
+	GetApiOrders(c *gin.Context, params GetApiOrdersParams)
+	// Отменить свой заказ в течение срока возврата. Монеты возвращаются на баланс, предметы списываются из инвентаря.
+	// (POST /api/orders/{id}/cancel)
+	PostApiOrdersIdCancel(c *gin.Context, id int)
 	// Отправить монеты другому пользователю.
 	// (POST /api/sendCoin)
-	PostApiSendCoin(c *gin.Context)
+	PostApiSendCoin(c *gin.Context, params PostApiSendCoinParams)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -109,10 +784,8 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(c *gin.Context)
 
-// PostApiAuth operation middleware
-func (siw *ServerInterfaceWrapper) PostApiAuth(c *gin.Context) {
-
-	c.Set(BearerAuthScopes, []string{})
+// GetWellKnownJwksJson operation middleware
+func (siw *ServerInterfaceWrapper) GetWellKnownJwksJson(c *gin.Context) {
 
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
@@ -121,25 +794,33 @@ func (siw *ServerInterfaceWrapper) PostApiAuth(c *gin.Context) {
 		}
 	}
 
-	siw.Handler.PostApiAuth(c)
+	siw.Handler.GetWellKnownJwksJson(c)
 }
 
-// GetApiBuyItem operation middleware
-func (siw *ServerInterfaceWrapper) GetApiBuyItem(c *gin.Context) {
+// PostApiAdminCoinsClawback operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminCoinsClawback(c *gin.Context) {
 
-	var err error
+	c.Set(BearerAuthScopes, []string{})
 
-	// ------------- Path parameter "item" -------------
-	var item string
+	c.Set(ApiKeyAuthScopes, []string{})
 
-	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
-	if err != nil {
-		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
-		return
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
 	}
 
+	siw.Handler.PostApiAdminCoinsClawback(c)
+}
+
+// PostApiAdminCoinsGrant operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminCoinsGrant(c *gin.Context) {
+
 	c.Set(BearerAuthScopes, []string{})
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -147,11 +828,11 @@ func (siw *ServerInterfaceWrapper) GetApiBuyItem(c *gin.Context) {
 		}
 	}
 
-	siw.Handler.GetApiBuyItem(c, item)
+	siw.Handler.PostApiAdminCoinsGrant(c)
 }
 
-// GetApiInfo operation middleware
-func (siw *ServerInterfaceWrapper) GetApiInfo(c *gin.Context) {
+// PostApiAdminMerch operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminMerch(c *gin.Context) {
 
 	c.Set(BearerAuthScopes, []string{})
 
@@ -162,11 +843,22 @@ func (siw *ServerInterfaceWrapper) GetApiInfo(c *gin.Context) {
 		}
 	}
 
-	siw.Handler.GetApiInfo(c)
+	siw.Handler.PostApiAdminMerch(c)
 }
 
-// PostApiSendCoin operation middleware
-func (siw *ServerInterfaceWrapper) PostApiSendCoin(c *gin.Context) {
+// DeleteApiAdminMerchItem operation middleware
+func (siw *ServerInterfaceWrapper) DeleteApiAdminMerchItem(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
+	}
 
 	c.Set(BearerAuthScopes, []string{})
 
@@ -177,248 +869,3692 @@ func (siw *ServerInterfaceWrapper) PostApiSendCoin(c *gin.Context) {
 		}
 	}
 
-	siw.Handler.PostApiSendCoin(c)
+	siw.Handler.DeleteApiAdminMerchItem(c, item)
 }
 
-// GinServerOptions provides options for the Gin server.
-type GinServerOptions struct {
-	BaseURL      string
-	Middlewares  []MiddlewareFunc
-	ErrorHandler func(*gin.Context, error, int)
-}
+// PatchApiAdminMerchItem operation middleware
+func (siw *ServerInterfaceWrapper) PatchApiAdminMerchItem(c *gin.Context) {
 
-// RegisterHandlers creates http.Handler with routing matching OpenAPI spec.
-func RegisterHandlers(router gin.IRouter, si ServerInterface) {
-	RegisterHandlersWithOptions(router, si, GinServerOptions{})
-}
+	var err error
 
-// RegisterHandlersWithOptions creates http.Handler with additional options
-func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options GinServerOptions) {
-	errorHandler := options.ErrorHandler
-	if errorHandler == nil {
-		errorHandler = func(c *gin.Context, err error, statusCode int) {
-			c.JSON(statusCode, gin.H{"msg": err.Error()})
-		}
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
 	}
 
-	wrapper := ServerInterfaceWrapper{
-		Handler:            si,
-		HandlerMiddlewares: options.Middlewares,
-		ErrorHandler:       errorHandler,
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
 	}
 
-	router.POST(options.BaseURL+"/api/auth", wrapper.PostApiAuth)
-	router.GET(options.BaseURL+"/api/buy/:item", wrapper.GetApiBuyItem)
-	router.GET(options.BaseURL+"/api/info", wrapper.GetApiInfo)
-	router.POST(options.BaseURL+"/api/sendCoin", wrapper.PostApiSendCoin)
+	siw.Handler.PatchApiAdminMerchItem(c, item)
 }
 
-type PostApiAuthRequestObject struct {
-	Body *PostApiAuthJSONRequestBody
-}
+// GetApiAdminOrders operation middleware
+func (siw *ServerInterfaceWrapper) GetApiAdminOrders(c *gin.Context) {
 
-type PostApiAuthResponseObject interface {
-	VisitPostApiAuthResponse(w http.ResponseWriter) error
-}
+	var err error
 
-type PostApiAuth200JSONResponse AuthResponse
+	c.Set(BearerAuthScopes, []string{})
 
-func (response PostApiAuth200JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiAdminOrdersParams
 
-	return json.NewEncoder(w).Encode(response)
-}
+	// ------------- Optional query parameter "status" -------------
 
-type PostApiAuth400JSONResponse ErrorResponse
+	err = runtime.BindQueryParameter("form", true, false, "status", c.Request.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter status: %w", err), http.StatusBadRequest)
+		return
+	}
 
-func (response PostApiAuth400JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	// ------------- Optional query parameter "cursor" -------------
 
-	return json.NewEncoder(w).Encode(response)
-}
+	err = runtime.BindQueryParameter("form", true, false, "cursor", c.Request.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter cursor: %w", err), http.StatusBadRequest)
+		return
+	}
 
-type PostApiAuth401JSONResponse ErrorResponse
+	// ------------- Optional query parameter "limit" -------------
 
-func (response PostApiAuth401JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(401)
+	err = runtime.BindQueryParameter("form", true, false, "limit", c.Request.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter limit: %w", err), http.StatusBadRequest)
+		return
+	}
 
-	return json.NewEncoder(w).Encode(response)
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiAdminOrders(c, params)
 }
 
-type PostApiAuth500JSONResponse ErrorResponse
+// PostApiAdminOrdersIdRefund operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminOrdersIdRefund(c *gin.Context) {
 
-func (response PostApiAuth500JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	var err error
 
-	return json.NewEncoder(w).Encode(response)
-}
+	// ------------- Path parameter "id" -------------
+	var id int
 
-type GetApiBuyItemRequestObject struct {
-	Item string `json:"item"`
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAdminOrdersIdRefund(c, id)
 }
 
-type GetApiBuyItemResponseObject interface {
-	VisitGetApiBuyItemResponse(w http.ResponseWriter) error
+// PutApiAdminOrdersIdStatus operation middleware
+func (siw *ServerInterfaceWrapper) PutApiAdminOrdersIdStatus(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PutApiAdminOrdersIdStatus(c, id)
 }
 
-type GetApiBuyItem200Response struct {
+// PostApiAdminServiceAccounts operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminServiceAccounts(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAdminServiceAccounts(c)
 }
 
-func (response GetApiBuyItem200Response) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
-	w.WriteHeader(200)
-	return nil
+// GetApiAdminServiceAccountsAccountKeys operation middleware
+func (siw *ServerInterfaceWrapper) GetApiAdminServiceAccountsAccountKeys(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "account" -------------
+	var account string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "account", c.Param("account"), &account, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter account: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiAdminServiceAccountsAccountKeys(c, account)
 }
 
-type GetApiBuyItem400JSONResponse ErrorResponse
+// PostApiAdminServiceAccountsAccountKeys operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminServiceAccountsAccountKeys(c *gin.Context) {
 
-func (response GetApiBuyItem400JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	var err error
 
-	return json.NewEncoder(w).Encode(response)
+	// ------------- Path parameter "account" -------------
+	var account string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "account", c.Param("account"), &account, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter account: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAdminServiceAccountsAccountKeys(c, account)
 }
 
-type GetApiBuyItem401JSONResponse ErrorResponse
+// DeleteApiAdminServiceAccountsAccountKeysId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteApiAdminServiceAccountsAccountKeysId(c *gin.Context) {
 
-func (response GetApiBuyItem401JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(401)
+	var err error
 
-	return json.NewEncoder(w).Encode(response)
+	// ------------- Path parameter "account" -------------
+	var account string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "account", c.Param("account"), &account, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter account: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DeleteApiAdminServiceAccountsAccountKeysId(c, account, id)
 }
 
-type GetApiBuyItem500JSONResponse ErrorResponse
+// GetApiAdminUsersUsernameBalance operation middleware
+func (siw *ServerInterfaceWrapper) GetApiAdminUsersUsernameBalance(c *gin.Context) {
 
-func (response GetApiBuyItem500JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	var err error
 
-	return json.NewEncoder(w).Encode(response)
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "username", c.Param("username"), &username, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter username: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiAdminUsersUsernameBalance(c, username)
 }
 
-type GetApiInfoRequestObject struct {
+// DeleteApiAdminUsersUsernameLockout operation middleware
+func (siw *ServerInterfaceWrapper) DeleteApiAdminUsersUsernameLockout(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "username", c.Param("username"), &username, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter username: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DeleteApiAdminUsersUsernameLockout(c, username)
 }
 
-type GetApiInfoResponseObject interface {
-	VisitGetApiInfoResponse(w http.ResponseWriter) error
+// PostApiAdminUsersUsernameRevokeSessions operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAdminUsersUsernameRevokeSessions(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "username", c.Param("username"), &username, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter username: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAdminUsersUsernameRevokeSessions(c, username)
 }
 
-type GetApiInfo200JSONResponse InfoResponse
+// PutApiAdminUsersUsernameRole operation middleware
+func (siw *ServerInterfaceWrapper) PutApiAdminUsersUsernameRole(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "username", c.Param("username"), &username, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter username: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PutApiAdminUsersUsernameRole(c, username)
+}
+
+// PostApiAuth operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAuth(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAuth(c)
+}
+
+// PostApiAuthLogout operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAuthLogout(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAuthLogout(c)
+}
+
+// PostApiAuthPassword operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAuthPassword(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAuthPassword(c)
+}
+
+// PostApiAuthRefresh operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAuthRefresh(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAuthRefresh(c)
+}
+
+// PostApiAuthRegister operation middleware
+func (siw *ServerInterfaceWrapper) PostApiAuthRegister(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiAuthRegister(c)
+}
+
+// PostApiBuy operation middleware
+func (siw *ServerInterfaceWrapper) PostApiBuy(c *gin.Context) {
+
+	var err error
+
+	c.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostApiBuyParams
+
+	headers := c.Request.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandler(c, fmt.Errorf("Expected one value for Idempotency-Key, got %d", n), http.StatusBadRequest)
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter Idempotency-Key: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiBuy(c, params)
+}
+
+// GetApiBuyItem operation middleware
+func (siw *ServerInterfaceWrapper) GetApiBuyItem(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiBuyItemParams
+
+	// ------------- Optional query parameter "quantity" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "quantity", c.Request.URL.Query(), &params.Quantity)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter quantity: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	headers := c.Request.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandler(c, fmt.Errorf("Expected one value for Idempotency-Key, got %d", n), http.StatusBadRequest)
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter Idempotency-Key: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiBuyItem(c, item, params)
+}
+
+// GetApiCart operation middleware
+func (siw *ServerInterfaceWrapper) GetApiCart(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiCart(c)
+}
+
+// PostApiCartCheckout operation middleware
+func (siw *ServerInterfaceWrapper) PostApiCartCheckout(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiCartCheckout(c)
+}
+
+// DeleteApiCartItem operation middleware
+func (siw *ServerInterfaceWrapper) DeleteApiCartItem(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DeleteApiCartItem(c, item)
+}
+
+// PutApiCartItem operation middleware
+func (siw *ServerInterfaceWrapper) PutApiCartItem(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PutApiCartItem(c, item)
+}
+
+// GetApiHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetApiHistory(c *gin.Context) {
+
+	var err error
+
+	c.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiHistoryParams
+
+	// ------------- Optional query parameter "direction" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "direction", c.Request.URL.Query(), &params.Direction)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter direction: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "counterparty" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "counterparty", c.Request.URL.Query(), &params.Counterparty)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter counterparty: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", c.Request.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter from: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", c.Request.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter to: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", c.Request.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter cursor: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", c.Request.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter limit: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiHistory(c, params)
+}
+
+// GetApiInfo operation middleware
+func (siw *ServerInterfaceWrapper) GetApiInfo(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiInfo(c)
+}
+
+// GetApiMerch operation middleware
+func (siw *ServerInterfaceWrapper) GetApiMerch(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiMerch(c)
+}
+
+// GetApiMerchItem operation middleware
+func (siw *ServerInterfaceWrapper) GetApiMerchItem(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "item", c.Param("item"), &item, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter item: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiMerchItem(c, item)
+}
+
+// GetApiOrders operation middleware
+func (siw *ServerInterfaceWrapper) GetApiOrders(c *gin.Context) {
+
+	var err error
+
+	c.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiOrdersParams
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", c.Request.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter cursor: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", c.Request.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter limit: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetApiOrders(c, params)
+}
+
+// PostApiOrdersIdCancel operation middleware
+func (siw *ServerInterfaceWrapper) PostApiOrdersIdCancel(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiOrdersIdCancel(c, id)
+}
+
+// PostApiSendCoin operation middleware
+func (siw *ServerInterfaceWrapper) PostApiSendCoin(c *gin.Context) {
+
+	var err error
+
+	c.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostApiSendCoinParams
+
+	headers := c.Request.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandler(c, fmt.Errorf("Expected one value for Idempotency-Key, got %d", n), http.StatusBadRequest)
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter Idempotency-Key: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostApiSendCoin(c, params)
+}
+
+// GinServerOptions provides options for the Gin server.
+type GinServerOptions struct {
+	BaseURL      string
+	Middlewares  []MiddlewareFunc
+	ErrorHandler func(*gin.Context, error, int)
+}
+
+// RegisterHandlers creates http.Handler with routing matching OpenAPI spec.
+func RegisterHandlers(router gin.IRouter, si ServerInterface) {
+	RegisterHandlersWithOptions(router, si, GinServerOptions{})
+}
+
+// RegisterHandlersWithOptions creates http.Handler with additional options
+func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options GinServerOptions) {
+	errorHandler := options.ErrorHandler
+	if errorHandler == nil {
+		errorHandler = func(c *gin.Context, err error, statusCode int) {
+			c.JSON(statusCode, gin.H{"msg": err.Error()})
+		}
+	}
+
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandler:       errorHandler,
+	}
+
+	router.GET(options.BaseURL+"/.well-known/jwks.json", wrapper.GetWellKnownJwksJson)
+	router.POST(options.BaseURL+"/api/admin/coins/clawback", wrapper.PostApiAdminCoinsClawback)
+	router.POST(options.BaseURL+"/api/admin/coins/grant", wrapper.PostApiAdminCoinsGrant)
+	router.POST(options.BaseURL+"/api/admin/merch", wrapper.PostApiAdminMerch)
+	router.DELETE(options.BaseURL+"/api/admin/merch/:item", wrapper.DeleteApiAdminMerchItem)
+	router.PATCH(options.BaseURL+"/api/admin/merch/:item", wrapper.PatchApiAdminMerchItem)
+	router.GET(options.BaseURL+"/api/admin/orders", wrapper.GetApiAdminOrders)
+	router.POST(options.BaseURL+"/api/admin/orders/:id/refund", wrapper.PostApiAdminOrdersIdRefund)
+	router.PUT(options.BaseURL+"/api/admin/orders/:id/status", wrapper.PutApiAdminOrdersIdStatus)
+	router.POST(options.BaseURL+"/api/admin/service-accounts", wrapper.PostApiAdminServiceAccounts)
+	router.GET(options.BaseURL+"/api/admin/service-accounts/:account/keys", wrapper.GetApiAdminServiceAccountsAccountKeys)
+	router.POST(options.BaseURL+"/api/admin/service-accounts/:account/keys", wrapper.PostApiAdminServiceAccountsAccountKeys)
+	router.DELETE(options.BaseURL+"/api/admin/service-accounts/:account/keys/:id", wrapper.DeleteApiAdminServiceAccountsAccountKeysId)
+	router.GET(options.BaseURL+"/api/admin/users/:username/balance", wrapper.GetApiAdminUsersUsernameBalance)
+	router.DELETE(options.BaseURL+"/api/admin/users/:username/lockout", wrapper.DeleteApiAdminUsersUsernameLockout)
+	router.POST(options.BaseURL+"/api/admin/users/:username/revoke-sessions", wrapper.PostApiAdminUsersUsernameRevokeSessions)
+	router.PUT(options.BaseURL+"/api/admin/users/:username/role", wrapper.PutApiAdminUsersUsernameRole)
+	router.POST(options.BaseURL+"/api/auth", wrapper.PostApiAuth)
+	router.POST(options.BaseURL+"/api/auth/logout", wrapper.PostApiAuthLogout)
+	router.POST(options.BaseURL+"/api/auth/password", wrapper.PostApiAuthPassword)
+	router.POST(options.BaseURL+"/api/auth/refresh", wrapper.PostApiAuthRefresh)
+	router.POST(options.BaseURL+"/api/auth/register", wrapper.PostApiAuthRegister)
+	router.POST(options.BaseURL+"/api/buy", wrapper.PostApiBuy)
+	router.GET(options.BaseURL+"/api/buy/:item", wrapper.GetApiBuyItem)
+	router.GET(options.BaseURL+"/api/cart", wrapper.GetApiCart)
+	router.POST(options.BaseURL+"/api/cart/checkout", wrapper.PostApiCartCheckout)
+	router.DELETE(options.BaseURL+"/api/cart/:item", wrapper.DeleteApiCartItem)
+	router.PUT(options.BaseURL+"/api/cart/:item", wrapper.PutApiCartItem)
+	router.GET(options.BaseURL+"/api/history", wrapper.GetApiHistory)
+	router.GET(options.BaseURL+"/api/info", wrapper.GetApiInfo)
+	router.GET(options.BaseURL+"/api/merch", wrapper.GetApiMerch)
+	router.GET(options.BaseURL+"/api/merch/:item", wrapper.GetApiMerchItem)
+	router.GET(options.BaseURL+"/api/orders", wrapper.GetApiOrders)
+	router.POST(options.BaseURL+"/api/orders/:id/cancel", wrapper.PostApiOrdersIdCancel)
+	router.POST(options.BaseURL+"/api/sendCoin", wrapper.PostApiSendCoin)
+}
+
+type GetWellKnownJwksJsonRequestObject struct {
+}
+
+type GetWellKnownJwksJsonResponseObject interface {
+	VisitGetWellKnownJwksJsonResponse(w http.ResponseWriter) error
+}
+
+type GetWellKnownJwksJson200JSONResponse JWKSet
+
+func (response GetWellKnownJwksJson200JSONResponse) VisitGetWellKnownJwksJsonResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsClawbackRequestObject struct {
+	Body *PostApiAdminCoinsClawbackJSONRequestBody
+}
+
+type PostApiAdminCoinsClawbackResponseObject interface {
+	VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminCoinsClawback204Response struct {
+}
+
+func (response PostApiAdminCoinsClawback204Response) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PostApiAdminCoinsClawback400JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsClawback400JSONResponse) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsClawback401JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsClawback401JSONResponse) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsClawback403JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsClawback403JSONResponse) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsClawback404JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsClawback404JSONResponse) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsClawback500JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsClawback500JSONResponse) VisitPostApiAdminCoinsClawbackResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsGrantRequestObject struct {
+	Body *PostApiAdminCoinsGrantJSONRequestBody
+}
+
+type PostApiAdminCoinsGrantResponseObject interface {
+	VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminCoinsGrant204Response struct {
+}
+
+func (response PostApiAdminCoinsGrant204Response) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PostApiAdminCoinsGrant400JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsGrant400JSONResponse) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsGrant401JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsGrant401JSONResponse) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsGrant403JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsGrant403JSONResponse) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsGrant404JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsGrant404JSONResponse) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminCoinsGrant500JSONResponse ErrorResponse
+
+func (response PostApiAdminCoinsGrant500JSONResponse) VisitPostApiAdminCoinsGrantResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerchRequestObject struct {
+	Body *PostApiAdminMerchJSONRequestBody
+}
+
+type PostApiAdminMerchResponseObject interface {
+	VisitPostApiAdminMerchResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminMerch201JSONResponse MerchItem
+
+func (response PostApiAdminMerch201JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerch400JSONResponse ErrorResponse
+
+func (response PostApiAdminMerch400JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerch401JSONResponse ErrorResponse
+
+func (response PostApiAdminMerch401JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerch403JSONResponse ErrorResponse
+
+func (response PostApiAdminMerch403JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerch409JSONResponse ErrorResponse
+
+func (response PostApiAdminMerch409JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminMerch500JSONResponse ErrorResponse
+
+func (response PostApiAdminMerch500JSONResponse) VisitPostApiAdminMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminMerchItemRequestObject struct {
+	Item string `json:"item"`
+}
+
+type DeleteApiAdminMerchItemResponseObject interface {
+	VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error
+}
+
+type DeleteApiAdminMerchItem204Response struct {
+}
+
+func (response DeleteApiAdminMerchItem204Response) VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteApiAdminMerchItem401JSONResponse ErrorResponse
+
+func (response DeleteApiAdminMerchItem401JSONResponse) VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminMerchItem403JSONResponse ErrorResponse
+
+func (response DeleteApiAdminMerchItem403JSONResponse) VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminMerchItem404JSONResponse ErrorResponse
+
+func (response DeleteApiAdminMerchItem404JSONResponse) VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminMerchItem500JSONResponse ErrorResponse
+
+func (response DeleteApiAdminMerchItem500JSONResponse) VisitDeleteApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItemRequestObject struct {
+	Item string `json:"item"`
+	Body *PatchApiAdminMerchItemJSONRequestBody
+}
+
+type PatchApiAdminMerchItemResponseObject interface {
+	VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error
+}
+
+type PatchApiAdminMerchItem200JSONResponse MerchItem
+
+func (response PatchApiAdminMerchItem200JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem400JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem400JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem401JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem401JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem403JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem403JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem404JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem404JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem409JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem409JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchApiAdminMerchItem500JSONResponse ErrorResponse
+
+func (response PatchApiAdminMerchItem500JSONResponse) VisitPatchApiAdminMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminOrdersRequestObject struct {
+	Params GetApiAdminOrdersParams
+}
+
+type GetApiAdminOrdersResponseObject interface {
+	VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error
+}
+
+type GetApiAdminOrders200JSONResponse OrderListResponse
+
+func (response GetApiAdminOrders200JSONResponse) VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminOrders400JSONResponse ErrorResponse
+
+func (response GetApiAdminOrders400JSONResponse) VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminOrders401JSONResponse ErrorResponse
+
+func (response GetApiAdminOrders401JSONResponse) VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminOrders403JSONResponse ErrorResponse
+
+func (response GetApiAdminOrders403JSONResponse) VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminOrders500JSONResponse ErrorResponse
+
+func (response GetApiAdminOrders500JSONResponse) VisitGetApiAdminOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefundRequestObject struct {
+	Id int `json:"id"`
+}
+
+type PostApiAdminOrdersIdRefundResponseObject interface {
+	VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminOrdersIdRefund200JSONResponse Order
+
+func (response PostApiAdminOrdersIdRefund200JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefund401JSONResponse ErrorResponse
+
+func (response PostApiAdminOrdersIdRefund401JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefund403JSONResponse ErrorResponse
+
+func (response PostApiAdminOrdersIdRefund403JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefund404JSONResponse ErrorResponse
+
+func (response PostApiAdminOrdersIdRefund404JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefund409JSONResponse ErrorResponse
+
+func (response PostApiAdminOrdersIdRefund409JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminOrdersIdRefund500JSONResponse ErrorResponse
+
+func (response PostApiAdminOrdersIdRefund500JSONResponse) VisitPostApiAdminOrdersIdRefundResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatusRequestObject struct {
+	Id   int `json:"id"`
+	Body *PutApiAdminOrdersIdStatusJSONRequestBody
+}
+
+type PutApiAdminOrdersIdStatusResponseObject interface {
+	VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error
+}
+
+type PutApiAdminOrdersIdStatus200JSONResponse Order
+
+func (response PutApiAdminOrdersIdStatus200JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus400JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus400JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus401JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus401JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus403JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus403JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus404JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus404JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus409JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus409JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminOrdersIdStatus500JSONResponse ErrorResponse
+
+func (response PutApiAdminOrdersIdStatus500JSONResponse) VisitPutApiAdminOrdersIdStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsRequestObject struct {
+	Body *PostApiAdminServiceAccountsJSONRequestBody
+}
+
+type PostApiAdminServiceAccountsResponseObject interface {
+	VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminServiceAccounts201JSONResponse ServiceAccount
+
+func (response PostApiAdminServiceAccounts201JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccounts400JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccounts400JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccounts401JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccounts401JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccounts403JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccounts403JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccounts409JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccounts409JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccounts500JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccounts500JSONResponse) VisitPostApiAdminServiceAccountsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminServiceAccountsAccountKeysRequestObject struct {
+	Account string `json:"account"`
+}
+
+type GetApiAdminServiceAccountsAccountKeysResponseObject interface {
+	VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error
+}
+
+type GetApiAdminServiceAccountsAccountKeys200JSONResponse ApiKeyListResponse
+
+func (response GetApiAdminServiceAccountsAccountKeys200JSONResponse) VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminServiceAccountsAccountKeys401JSONResponse ErrorResponse
+
+func (response GetApiAdminServiceAccountsAccountKeys401JSONResponse) VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminServiceAccountsAccountKeys403JSONResponse ErrorResponse
+
+func (response GetApiAdminServiceAccountsAccountKeys403JSONResponse) VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminServiceAccountsAccountKeys404JSONResponse ErrorResponse
+
+func (response GetApiAdminServiceAccountsAccountKeys404JSONResponse) VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminServiceAccountsAccountKeys500JSONResponse ErrorResponse
+
+func (response GetApiAdminServiceAccountsAccountKeys500JSONResponse) VisitGetApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeysRequestObject struct {
+	Account string `json:"account"`
+	Body    *PostApiAdminServiceAccountsAccountKeysJSONRequestBody
+}
+
+type PostApiAdminServiceAccountsAccountKeysResponseObject interface {
+	VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminServiceAccountsAccountKeys201JSONResponse ApiKeyCreatedResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys201JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeys400JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys400JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeys401JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys401JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeys403JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys403JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeys404JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys404JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminServiceAccountsAccountKeys500JSONResponse ErrorResponse
+
+func (response PostApiAdminServiceAccountsAccountKeys500JSONResponse) VisitPostApiAdminServiceAccountsAccountKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysIdRequestObject struct {
+	Account string `json:"account"`
+	Id      int    `json:"id"`
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysIdResponseObject interface {
+	VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysId204Response struct {
+}
+
+func (response DeleteApiAdminServiceAccountsAccountKeysId204Response) VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysId401JSONResponse ErrorResponse
+
+func (response DeleteApiAdminServiceAccountsAccountKeysId401JSONResponse) VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysId403JSONResponse ErrorResponse
+
+func (response DeleteApiAdminServiceAccountsAccountKeysId403JSONResponse) VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysId404JSONResponse ErrorResponse
+
+func (response DeleteApiAdminServiceAccountsAccountKeysId404JSONResponse) VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminServiceAccountsAccountKeysId500JSONResponse ErrorResponse
+
+func (response DeleteApiAdminServiceAccountsAccountKeysId500JSONResponse) VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminUsersUsernameBalanceRequestObject struct {
+	Username string `json:"username"`
+}
+
+type GetApiAdminUsersUsernameBalanceResponseObject interface {
+	VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error
+}
+
+type GetApiAdminUsersUsernameBalance200JSONResponse BalanceResponse
+
+func (response GetApiAdminUsersUsernameBalance200JSONResponse) VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminUsersUsernameBalance401JSONResponse ErrorResponse
+
+func (response GetApiAdminUsersUsernameBalance401JSONResponse) VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminUsersUsernameBalance403JSONResponse ErrorResponse
+
+func (response GetApiAdminUsersUsernameBalance403JSONResponse) VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminUsersUsernameBalance404JSONResponse ErrorResponse
+
+func (response GetApiAdminUsersUsernameBalance404JSONResponse) VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiAdminUsersUsernameBalance500JSONResponse ErrorResponse
+
+func (response GetApiAdminUsersUsernameBalance500JSONResponse) VisitGetApiAdminUsersUsernameBalanceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminUsersUsernameLockoutRequestObject struct {
+	Username string `json:"username"`
+}
+
+type DeleteApiAdminUsersUsernameLockoutResponseObject interface {
+	VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error
+}
+
+type DeleteApiAdminUsersUsernameLockout204Response struct {
+}
+
+func (response DeleteApiAdminUsersUsernameLockout204Response) VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteApiAdminUsersUsernameLockout401JSONResponse ErrorResponse
+
+func (response DeleteApiAdminUsersUsernameLockout401JSONResponse) VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminUsersUsernameLockout403JSONResponse ErrorResponse
+
+func (response DeleteApiAdminUsersUsernameLockout403JSONResponse) VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminUsersUsernameLockout404JSONResponse ErrorResponse
+
+func (response DeleteApiAdminUsersUsernameLockout404JSONResponse) VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiAdminUsersUsernameLockout500JSONResponse ErrorResponse
+
+func (response DeleteApiAdminUsersUsernameLockout500JSONResponse) VisitDeleteApiAdminUsersUsernameLockoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminUsersUsernameRevokeSessionsRequestObject struct {
+	Username string `json:"username"`
+}
+
+type PostApiAdminUsersUsernameRevokeSessionsResponseObject interface {
+	VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error
+}
+
+type PostApiAdminUsersUsernameRevokeSessions204Response struct {
+}
+
+func (response PostApiAdminUsersUsernameRevokeSessions204Response) VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PostApiAdminUsersUsernameRevokeSessions401JSONResponse ErrorResponse
+
+func (response PostApiAdminUsersUsernameRevokeSessions401JSONResponse) VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminUsersUsernameRevokeSessions403JSONResponse ErrorResponse
+
+func (response PostApiAdminUsersUsernameRevokeSessions403JSONResponse) VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminUsersUsernameRevokeSessions404JSONResponse ErrorResponse
+
+func (response PostApiAdminUsersUsernameRevokeSessions404JSONResponse) VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAdminUsersUsernameRevokeSessions500JSONResponse ErrorResponse
+
+func (response PostApiAdminUsersUsernameRevokeSessions500JSONResponse) VisitPostApiAdminUsersUsernameRevokeSessionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminUsersUsernameRoleRequestObject struct {
+	Username string `json:"username"`
+	Body     *PutApiAdminUsersUsernameRoleJSONRequestBody
+}
+
+type PutApiAdminUsersUsernameRoleResponseObject interface {
+	VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error
+}
+
+type PutApiAdminUsersUsernameRole204Response struct {
+}
+
+func (response PutApiAdminUsersUsernameRole204Response) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PutApiAdminUsersUsernameRole400JSONResponse ErrorResponse
+
+func (response PutApiAdminUsersUsernameRole400JSONResponse) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminUsersUsernameRole401JSONResponse ErrorResponse
+
+func (response PutApiAdminUsersUsernameRole401JSONResponse) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminUsersUsernameRole403JSONResponse ErrorResponse
+
+func (response PutApiAdminUsersUsernameRole403JSONResponse) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminUsersUsernameRole404JSONResponse ErrorResponse
+
+func (response PutApiAdminUsersUsernameRole404JSONResponse) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiAdminUsersUsernameRole500JSONResponse ErrorResponse
+
+func (response PutApiAdminUsersUsernameRole500JSONResponse) VisitPutApiAdminUsersUsernameRoleResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRequestObject struct {
+	Body *PostApiAuthJSONRequestBody
+}
+
+type PostApiAuthResponseObject interface {
+	VisitPostApiAuthResponse(w http.ResponseWriter) error
+}
+
+type PostApiAuth200JSONResponse AuthResponse
+
+func (response PostApiAuth200JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuth400JSONResponse ErrorResponse
+
+func (response PostApiAuth400JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuth401JSONResponse ErrorResponse
+
+func (response PostApiAuth401JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuth423ResponseHeaders struct {
+	RetryAfter int
+}
+
+type PostApiAuth423JSONResponse struct {
+	Body    ErrorResponse
+	Headers PostApiAuth423ResponseHeaders
+}
+
+func (response PostApiAuth423JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(423)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostApiAuth429ResponseHeaders struct {
+	RetryAfter int
+}
+
+type PostApiAuth429JSONResponse struct {
+	Body    ErrorResponse
+	Headers PostApiAuth429ResponseHeaders
+}
+
+func (response PostApiAuth429JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostApiAuth500JSONResponse ErrorResponse
+
+func (response PostApiAuth500JSONResponse) VisitPostApiAuthResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthLogoutRequestObject struct {
+	Body *PostApiAuthLogoutJSONRequestBody
+}
+
+type PostApiAuthLogoutResponseObject interface {
+	VisitPostApiAuthLogoutResponse(w http.ResponseWriter) error
+}
+
+type PostApiAuthLogout204Response struct {
+}
+
+func (response PostApiAuthLogout204Response) VisitPostApiAuthLogoutResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PostApiAuthLogout400JSONResponse ErrorResponse
+
+func (response PostApiAuthLogout400JSONResponse) VisitPostApiAuthLogoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthLogout401JSONResponse ErrorResponse
+
+func (response PostApiAuthLogout401JSONResponse) VisitPostApiAuthLogoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthLogout500JSONResponse ErrorResponse
+
+func (response PostApiAuthLogout500JSONResponse) VisitPostApiAuthLogoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthPasswordRequestObject struct {
+	Body *PostApiAuthPasswordJSONRequestBody
+}
+
+type PostApiAuthPasswordResponseObject interface {
+	VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error
+}
+
+type PostApiAuthPassword200JSONResponse AuthResponse
+
+func (response PostApiAuthPassword200JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthPassword400JSONResponse ErrorResponse
+
+func (response PostApiAuthPassword400JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthPassword401JSONResponse ErrorResponse
+
+func (response PostApiAuthPassword401JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthPassword403JSONResponse ErrorResponse
+
+func (response PostApiAuthPassword403JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthPassword423ResponseHeaders struct {
+	RetryAfter int
+}
+
+type PostApiAuthPassword423JSONResponse struct {
+	Body    ErrorResponse
+	Headers PostApiAuthPassword423ResponseHeaders
+}
+
+func (response PostApiAuthPassword423JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(423)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostApiAuthPassword500JSONResponse ErrorResponse
+
+func (response PostApiAuthPassword500JSONResponse) VisitPostApiAuthPasswordResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRefreshRequestObject struct {
+	Body *PostApiAuthRefreshJSONRequestBody
+}
+
+type PostApiAuthRefreshResponseObject interface {
+	VisitPostApiAuthRefreshResponse(w http.ResponseWriter) error
+}
+
+type PostApiAuthRefresh200JSONResponse AuthResponse
+
+func (response PostApiAuthRefresh200JSONResponse) VisitPostApiAuthRefreshResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRefresh400JSONResponse ErrorResponse
+
+func (response PostApiAuthRefresh400JSONResponse) VisitPostApiAuthRefreshResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRefresh401JSONResponse ErrorResponse
+
+func (response PostApiAuthRefresh401JSONResponse) VisitPostApiAuthRefreshResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRefresh500JSONResponse ErrorResponse
+
+func (response PostApiAuthRefresh500JSONResponse) VisitPostApiAuthRefreshResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRegisterRequestObject struct {
+	Body *PostApiAuthRegisterJSONRequestBody
+}
+
+type PostApiAuthRegisterResponseObject interface {
+	VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error
+}
+
+type PostApiAuthRegister201JSONResponse AuthResponse
+
+func (response PostApiAuthRegister201JSONResponse) VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRegister400JSONResponse ErrorResponse
+
+func (response PostApiAuthRegister400JSONResponse) VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRegister403JSONResponse ErrorResponse
+
+func (response PostApiAuthRegister403JSONResponse) VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRegister409JSONResponse ErrorResponse
+
+func (response PostApiAuthRegister409JSONResponse) VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiAuthRegister500JSONResponse ErrorResponse
+
+func (response PostApiAuthRegister500JSONResponse) VisitPostApiAuthRegisterResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiBuyRequestObject struct {
+	Params PostApiBuyParams
+	Body   *PostApiBuyJSONRequestBody
+}
+
+type PostApiBuyResponseObject interface {
+	VisitPostApiBuyResponse(w http.ResponseWriter) error
+}
+
+type PostApiBuy200Response struct {
+}
+
+func (response PostApiBuy200Response) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type PostApiBuy400JSONResponse ErrorResponse
+
+func (response PostApiBuy400JSONResponse) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiBuy401JSONResponse ErrorResponse
+
+func (response PostApiBuy401JSONResponse) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiBuy409JSONResponse ErrorResponse
+
+func (response PostApiBuy409JSONResponse) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiBuy422JSONResponse ErrorResponse
+
+func (response PostApiBuy422JSONResponse) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiBuy500JSONResponse ErrorResponse
+
+func (response PostApiBuy500JSONResponse) VisitPostApiBuyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiBuyItemRequestObject struct {
+	Item   string `json:"item"`
+	Params GetApiBuyItemParams
+}
+
+type GetApiBuyItemResponseObject interface {
+	VisitGetApiBuyItemResponse(w http.ResponseWriter) error
+}
+
+type GetApiBuyItem200Response struct {
+}
+
+func (response GetApiBuyItem200Response) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type GetApiBuyItem400JSONResponse ErrorResponse
+
+func (response GetApiBuyItem400JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiBuyItem401JSONResponse ErrorResponse
+
+func (response GetApiBuyItem401JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiBuyItem409JSONResponse ErrorResponse
+
+func (response GetApiBuyItem409JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiBuyItem422JSONResponse ErrorResponse
+
+func (response GetApiBuyItem422JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiBuyItem500JSONResponse ErrorResponse
+
+func (response GetApiBuyItem500JSONResponse) VisitGetApiBuyItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiCartRequestObject struct {
+}
+
+type GetApiCartResponseObject interface {
+	VisitGetApiCartResponse(w http.ResponseWriter) error
+}
+
+type GetApiCart200JSONResponse Cart
+
+func (response GetApiCart200JSONResponse) VisitGetApiCartResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiCart401JSONResponse ErrorResponse
+
+func (response GetApiCart401JSONResponse) VisitGetApiCartResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiCart500JSONResponse ErrorResponse
+
+func (response GetApiCart500JSONResponse) VisitGetApiCartResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiCartCheckoutRequestObject struct {
+}
+
+type PostApiCartCheckoutResponseObject interface {
+	VisitPostApiCartCheckoutResponse(w http.ResponseWriter) error
+}
+
+type PostApiCartCheckout200JSONResponse Cart
+
+func (response PostApiCartCheckout200JSONResponse) VisitPostApiCartCheckoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiCartCheckout400JSONResponse CheckoutErrorResponse
+
+func (response PostApiCartCheckout400JSONResponse) VisitPostApiCartCheckoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiCartCheckout401JSONResponse ErrorResponse
+
+func (response PostApiCartCheckout401JSONResponse) VisitPostApiCartCheckoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiCartCheckout500JSONResponse ErrorResponse
+
+func (response PostApiCartCheckout500JSONResponse) VisitPostApiCartCheckoutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiCartItemRequestObject struct {
+	Item string `json:"item"`
+}
+
+type DeleteApiCartItemResponseObject interface {
+	VisitDeleteApiCartItemResponse(w http.ResponseWriter) error
+}
+
+type DeleteApiCartItem200JSONResponse Cart
+
+func (response DeleteApiCartItem200JSONResponse) VisitDeleteApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiCartItem401JSONResponse ErrorResponse
+
+func (response DeleteApiCartItem401JSONResponse) VisitDeleteApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiCartItem404JSONResponse ErrorResponse
+
+func (response DeleteApiCartItem404JSONResponse) VisitDeleteApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteApiCartItem500JSONResponse ErrorResponse
+
+func (response DeleteApiCartItem500JSONResponse) VisitDeleteApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiCartItemRequestObject struct {
+	Item string `json:"item"`
+	Body *PutApiCartItemJSONRequestBody
+}
+
+type PutApiCartItemResponseObject interface {
+	VisitPutApiCartItemResponse(w http.ResponseWriter) error
+}
+
+type PutApiCartItem200JSONResponse Cart
+
+func (response PutApiCartItem200JSONResponse) VisitPutApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiCartItem400JSONResponse ErrorResponse
+
+func (response PutApiCartItem400JSONResponse) VisitPutApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiCartItem401JSONResponse ErrorResponse
+
+func (response PutApiCartItem401JSONResponse) VisitPutApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutApiCartItem500JSONResponse ErrorResponse
+
+func (response PutApiCartItem500JSONResponse) VisitPutApiCartItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiHistoryRequestObject struct {
+	Params GetApiHistoryParams
+}
+
+type GetApiHistoryResponseObject interface {
+	VisitGetApiHistoryResponse(w http.ResponseWriter) error
+}
+
+type GetApiHistory200JSONResponse HistoryResponse
+
+func (response GetApiHistory200JSONResponse) VisitGetApiHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiHistory400JSONResponse ErrorResponse
+
+func (response GetApiHistory400JSONResponse) VisitGetApiHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiHistory401JSONResponse ErrorResponse
+
+func (response GetApiHistory401JSONResponse) VisitGetApiHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiHistory500JSONResponse ErrorResponse
+
+func (response GetApiHistory500JSONResponse) VisitGetApiHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiInfoRequestObject struct {
+}
+
+type GetApiInfoResponseObject interface {
+	VisitGetApiInfoResponse(w http.ResponseWriter) error
+}
+
+type GetApiInfo200JSONResponse InfoResponse
+
+func (response GetApiInfo200JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiInfo400JSONResponse ErrorResponse
+
+func (response GetApiInfo400JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiInfo401JSONResponse ErrorResponse
+
+func (response GetApiInfo401JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiInfo500JSONResponse ErrorResponse
+
+func (response GetApiInfo500JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerchRequestObject struct {
+}
+
+type GetApiMerchResponseObject interface {
+	VisitGetApiMerchResponse(w http.ResponseWriter) error
+}
+
+type GetApiMerch200JSONResponse MerchListResponse
+
+func (response GetApiMerch200JSONResponse) VisitGetApiMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerch401JSONResponse ErrorResponse
+
+func (response GetApiMerch401JSONResponse) VisitGetApiMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerch500JSONResponse ErrorResponse
+
+func (response GetApiMerch500JSONResponse) VisitGetApiMerchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerchItemRequestObject struct {
+	Item string `json:"item"`
+}
+
+type GetApiMerchItemResponseObject interface {
+	VisitGetApiMerchItemResponse(w http.ResponseWriter) error
+}
+
+type GetApiMerchItem200JSONResponse MerchItem
+
+func (response GetApiMerchItem200JSONResponse) VisitGetApiMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerchItem401JSONResponse ErrorResponse
+
+func (response GetApiMerchItem401JSONResponse) VisitGetApiMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerchItem404JSONResponse ErrorResponse
+
+func (response GetApiMerchItem404JSONResponse) VisitGetApiMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiMerchItem500JSONResponse ErrorResponse
+
+func (response GetApiMerchItem500JSONResponse) VisitGetApiMerchItemResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiOrdersRequestObject struct {
+	Params GetApiOrdersParams
+}
+
+type GetApiOrdersResponseObject interface {
+	VisitGetApiOrdersResponse(w http.ResponseWriter) error
+}
+
+type GetApiOrders200JSONResponse OrderListResponse
+
+func (response GetApiOrders200JSONResponse) VisitGetApiOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiOrders400JSONResponse ErrorResponse
+
+func (response GetApiOrders400JSONResponse) VisitGetApiOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiOrders401JSONResponse ErrorResponse
+
+func (response GetApiOrders401JSONResponse) VisitGetApiOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetApiOrders500JSONResponse ErrorResponse
+
+func (response GetApiOrders500JSONResponse) VisitGetApiOrdersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiOrdersIdCancelRequestObject struct {
+	Id int `json:"id"`
+}
+
+type PostApiOrdersIdCancelResponseObject interface {
+	VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error
+}
+
+type PostApiOrdersIdCancel200JSONResponse Order
+
+func (response PostApiOrdersIdCancel200JSONResponse) VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiOrdersIdCancel401JSONResponse ErrorResponse
+
+func (response PostApiOrdersIdCancel401JSONResponse) VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiOrdersIdCancel404JSONResponse ErrorResponse
+
+func (response PostApiOrdersIdCancel404JSONResponse) VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiOrdersIdCancel409JSONResponse ErrorResponse
+
+func (response PostApiOrdersIdCancel409JSONResponse) VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiOrdersIdCancel500JSONResponse ErrorResponse
+
+func (response PostApiOrdersIdCancel500JSONResponse) VisitPostApiOrdersIdCancelResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiSendCoinRequestObject struct {
+	Params PostApiSendCoinParams
+	Body   *PostApiSendCoinJSONRequestBody
+}
+
+type PostApiSendCoinResponseObject interface {
+	VisitPostApiSendCoinResponse(w http.ResponseWriter) error
+}
+
+type PostApiSendCoin200Response struct {
+}
+
+func (response PostApiSendCoin200Response) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type PostApiSendCoin400JSONResponse ErrorResponse
+
+func (response PostApiSendCoin400JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiSendCoin401JSONResponse ErrorResponse
+
+func (response PostApiSendCoin401JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiSendCoin409JSONResponse ErrorResponse
+
+func (response PostApiSendCoin409JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiSendCoin422JSONResponse ErrorResponse
+
+func (response PostApiSendCoin422JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostApiSendCoin500JSONResponse ErrorResponse
+
+func (response PostApiSendCoin500JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// Получить публичные ключи для проверки JWT-токенов. HMAC-ключи не публикуются.
+	// (GET /.well-known/jwks.json)
+	GetWellKnownJwksJson(ctx *gin.Context, request GetWellKnownJwksJsonRequestObject) (GetWellKnownJwksJsonResponseObject, error)
+	// Списать монеты у пользователя.
+	// (POST /api/admin/coins/clawback)
+	PostApiAdminCoinsClawback(ctx *gin.Context, request PostApiAdminCoinsClawbackRequestObject) (PostApiAdminCoinsClawbackResponseObject, error)
+	// Начислить монеты одному или нескольким пользователям. Начисление выполняется для всех пользователей или ни для кого.
+	// (POST /api/admin/coins/grant)
+	PostApiAdminCoinsGrant(ctx *gin.Context, request PostApiAdminCoinsGrantRequestObject) (PostApiAdminCoinsGrantResponseObject, error)
+	// Добавить предмет в каталог.
+	// (POST /api/admin/merch)
+	PostApiAdminMerch(ctx *gin.Context, request PostApiAdminMerchRequestObject) (PostApiAdminMerchResponseObject, error)
+	// Убрать предмет из каталога. Предмет остается в инвентаре пользователей.
+	// (DELETE /api/admin/merch/{item})
+	DeleteApiAdminMerchItem(ctx *gin.Context, request DeleteApiAdminMerchItemRequestObject) (DeleteApiAdminMerchItemResponseObject, error)
+	// Изменить название, цену, описание или доступность предмета.
+	// (PATCH /api/admin/merch/{item})
+	PatchApiAdminMerchItem(ctx *gin.Context, request PatchApiAdminMerchItemRequestObject) (PatchApiAdminMerchItemResponseObject, error)
+	// Получить заказы всех пользователей для склада постранично, от старых к новым.
+	// (GET /api/admin/orders)
+	GetApiAdminOrders(ctx *gin.Context, request GetApiAdminOrdersRequestObject) (GetApiAdminOrdersResponseObject, error)
+	// Отменить заказ любого пользователя с возвратом монет, независимо от срока возврата.
+	// (POST /api/admin/orders/{id}/refund)
+	PostApiAdminOrdersIdRefund(ctx *gin.Context, request PostApiAdminOrdersIdRefundRequestObject) (PostApiAdminOrdersIdRefundResponseObject, error)
+	// Перевести заказ в следующий статус. Статусы меняются только вперед, промежуточные можно пропускать.
+	// (PUT /api/admin/orders/{id}/status)
+	PutApiAdminOrdersIdStatus(ctx *gin.Context, request PutApiAdminOrdersIdStatusRequestObject) (PutApiAdminOrdersIdStatusResponseObject, error)
+	// Создать сервисную учетную запись для интеграций. Сервисная учетная запись не может входить по паролю и работает только через API-ключи.
+	// (POST /api/admin/service-accounts)
+	PostApiAdminServiceAccounts(ctx *gin.Context, request PostApiAdminServiceAccountsRequestObject) (PostApiAdminServiceAccountsResponseObject, error)
+	// Получить список API-ключей сервисной учетной записи. Сами ключи не возвращаются.
+	// (GET /api/admin/service-accounts/{account}/keys)
+	GetApiAdminServiceAccountsAccountKeys(ctx *gin.Context, request GetApiAdminServiceAccountsAccountKeysRequestObject) (GetApiAdminServiceAccountsAccountKeysResponseObject, error)
+	// Выпустить API-ключ для сервисной учетной записи. Ключ возвращается только в ответе на этот запрос.
+	// (POST /api/admin/service-accounts/{account}/keys)
+	PostApiAdminServiceAccountsAccountKeys(ctx *gin.Context, request PostApiAdminServiceAccountsAccountKeysRequestObject) (PostApiAdminServiceAccountsAccountKeysResponseObject, error)
+	// Отозвать API-ключ сервисной учетной записи.
+	// (DELETE /api/admin/service-accounts/{account}/keys/{id})
+	DeleteApiAdminServiceAccountsAccountKeysId(ctx *gin.Context, request DeleteApiAdminServiceAccountsAccountKeysIdRequestObject) (DeleteApiAdminServiceAccountsAccountKeysIdResponseObject, error)
+	// Получить баланс монет пользователя.
+	// (GET /api/admin/users/{username}/balance)
+	GetApiAdminUsersUsernameBalance(ctx *gin.Context, request GetApiAdminUsersUsernameBalanceRequestObject) (GetApiAdminUsersUsernameBalanceResponseObject, error)
+	// Снять блокировку входа с учетной записи пользователя.
+	// (DELETE /api/admin/users/{username}/lockout)
+	DeleteApiAdminUsersUsernameLockout(ctx *gin.Context, request DeleteApiAdminUsersUsernameLockoutRequestObject) (DeleteApiAdminUsersUsernameLockoutResponseObject, error)
+	// Завершить все сессии пользователя. Выданные ранее токены доступа и обновления становятся недействительными.
+	// (POST /api/admin/users/{username}/revoke-sessions)
+	PostApiAdminUsersUsernameRevokeSessions(ctx *gin.Context, request PostApiAdminUsersUsernameRevokeSessionsRequestObject) (PostApiAdminUsersUsernameRevokeSessionsResponseObject, error)
+	// Назначить роль пользователю.
+	// (PUT /api/admin/users/{username}/role)
+	PutApiAdminUsersUsernameRole(ctx *gin.Context, request PutApiAdminUsersUsernameRoleRequestObject) (PutApiAdminUsersUsernameRoleResponseObject, error)
+	// Аутентификация и получение JWT-токена. В режиме SIGNUP_MODE=auto при первой аутентификации пользователь создается автоматически.
+	// (POST /api/auth)
+	PostApiAuth(ctx *gin.Context, request PostApiAuthRequestObject) (PostApiAuthResponseObject, error)
+	// Выйти из системы. Текущий токен доступа и переданный токен обновления становятся недействительными.
+	// (POST /api/auth/logout)
+	PostApiAuthLogout(ctx *gin.Context, request PostApiAuthLogoutRequestObject) (PostApiAuthLogoutResponseObject, error)
+	// Сменить пароль. Все сессии пользователя завершаются, в ответе выдается новая пара токенов.
+	// (POST /api/auth/password)
+	PostApiAuthPassword(ctx *gin.Context, request PostApiAuthPasswordRequestObject) (PostApiAuthPasswordResponseObject, error)
+	// Обменять токен обновления на новую пару токенов. Использованный токен обновления становится недействительным.
+	// (POST /api/auth/refresh)
+	PostApiAuthRefresh(ctx *gin.Context, request PostApiAuthRefreshRequestObject) (PostApiAuthRefreshResponseObject, error)
+	// Зарегистрировать нового пользователя и получить JWT-токен.
+	// (POST /api/auth/register)
+	PostApiAuthRegister(ctx *gin.Context, request PostApiAuthRegisterRequestObject) (PostApiAuthRegisterResponseObject, error)
+	// Купить один или несколько одинаковых предметов за монеты. Стоимость считается по текущей цене, покупка выполняется целиком или не выполняется.
+	// (POST /api/buy)
+	PostApiBuy(ctx *gin.Context, request PostApiBuyRequestObject) (PostApiBuyResponseObject, error)
+	// Купить предмет за монеты.
+	// (GET /api/buy/{item})
+	GetApiBuyItem(ctx *gin.Context, request GetApiBuyItemRequestObject) (GetApiBuyItemResponseObject, error)
+	// Получить содержимое корзины по текущим ценам.
+	// (GET /api/cart)
+	GetApiCart(ctx *gin.Context, request GetApiCartRequestObject) (GetApiCartResponseObject, error)
+	// Купить все предметы корзины одной покупкой. Если хотя бы один предмет купить нельзя или монет не хватает, ничего не покупается.
+	// (POST /api/cart/checkout)
+	PostApiCartCheckout(ctx *gin.Context, request PostApiCartCheckoutRequestObject) (PostApiCartCheckoutResponseObject, error)
+	// Убрать предмет из корзины.
+	// (DELETE /api/cart/{item})
+	DeleteApiCartItem(ctx *gin.Context, request DeleteApiCartItemRequestObject) (DeleteApiCartItemResponseObject, error)
+	// Положить предмет в корзину или изменить его количество.
+	// (PUT /api/cart/{item})
+	PutApiCartItem(ctx *gin.Context, request PutApiCartItemRequestObject) (PutApiCartItemResponseObject, error)
+	// Получить историю переводов монет постранично, с фильтрами.
+	// (GET /api/history)
+	GetApiHistory(ctx *gin.Context, request GetApiHistoryRequestObject) (GetApiHistoryResponseObject, error)
+	// Получить информацию о монетах, инвентаре и истории транзакций.
+	// (GET /api/info)
+	GetApiInfo(ctx *gin.Context, request GetApiInfoRequestObject) (GetApiInfoResponseObject, error)
+	// Получить каталог мерча с ценами.
+	// (GET /api/merch)
+	GetApiMerch(ctx *gin.Context, request GetApiMerchRequestObject) (GetApiMerchResponseObject, error)
+	// Получить информацию о предмете из каталога.
+	// (GET /api/merch/{item})
+	GetApiMerchItem(ctx *gin.Context, request GetApiMerchItemRequestObject) (GetApiMerchItemResponseObject, error)
+	// Получить заказы пользователя постранично, от новых к старым.
+	// (GET /api/orders)
+	GetApiOrders(ctx *gin.Context, request GetApiOrdersRequestObject) (GetApiOrdersResponseObject, error)
+	// Отменить свой заказ в течение срока возврата. Монеты возвращаются на баланс, предметы списываются из инвентаря.
+	// (POST /api/orders/{id}/cancel)
+	PostApiOrdersIdCancel(ctx *gin.Context, request PostApiOrdersIdCancelRequestObject) (PostApiOrdersIdCancelResponseObject, error)
+	// Отправить монеты другому пользователю.
+	// (POST /api/sendCoin)
+	PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinRequestObject) (PostApiSendCoinResponseObject, error)
+}
+
+type StrictHandlerFunc = strictgin.StrictGinHandlerFunc
+type StrictMiddlewareFunc = strictgin.StrictGinMiddlewareFunc
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+}
+
+// GetWellKnownJwksJson operation middleware
+func (sh *strictHandler) GetWellKnownJwksJson(ctx *gin.Context) {
+	var request GetWellKnownJwksJsonRequestObject
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetWellKnownJwksJson(ctx, request.(GetWellKnownJwksJsonRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetWellKnownJwksJson")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetWellKnownJwksJsonResponseObject); ok {
+		if err := validResponse.VisitGetWellKnownJwksJsonResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminCoinsClawback operation middleware
+func (sh *strictHandler) PostApiAdminCoinsClawback(ctx *gin.Context) {
+	var request PostApiAdminCoinsClawbackRequestObject
+
+	var body PostApiAdminCoinsClawbackJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminCoinsClawback(ctx, request.(PostApiAdminCoinsClawbackRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminCoinsClawback")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminCoinsClawbackResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminCoinsClawbackResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminCoinsGrant operation middleware
+func (sh *strictHandler) PostApiAdminCoinsGrant(ctx *gin.Context) {
+	var request PostApiAdminCoinsGrantRequestObject
+
+	var body PostApiAdminCoinsGrantJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminCoinsGrant(ctx, request.(PostApiAdminCoinsGrantRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminCoinsGrant")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminCoinsGrantResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminCoinsGrantResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminMerch operation middleware
+func (sh *strictHandler) PostApiAdminMerch(ctx *gin.Context) {
+	var request PostApiAdminMerchRequestObject
+
+	var body PostApiAdminMerchJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminMerch(ctx, request.(PostApiAdminMerchRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminMerch")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminMerchResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminMerchResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteApiAdminMerchItem operation middleware
+func (sh *strictHandler) DeleteApiAdminMerchItem(ctx *gin.Context, item string) {
+	var request DeleteApiAdminMerchItemRequestObject
+
+	request.Item = item
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteApiAdminMerchItem(ctx, request.(DeleteApiAdminMerchItemRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteApiAdminMerchItem")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(DeleteApiAdminMerchItemResponseObject); ok {
+		if err := validResponse.VisitDeleteApiAdminMerchItemResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PatchApiAdminMerchItem operation middleware
+func (sh *strictHandler) PatchApiAdminMerchItem(ctx *gin.Context, item string) {
+	var request PatchApiAdminMerchItemRequestObject
+
+	request.Item = item
+
+	var body PatchApiAdminMerchItemJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PatchApiAdminMerchItem(ctx, request.(PatchApiAdminMerchItemRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PatchApiAdminMerchItem")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PatchApiAdminMerchItemResponseObject); ok {
+		if err := validResponse.VisitPatchApiAdminMerchItemResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetApiAdminOrders operation middleware
+func (sh *strictHandler) GetApiAdminOrders(ctx *gin.Context, params GetApiAdminOrdersParams) {
+	var request GetApiAdminOrdersRequestObject
+
+	request.Params = params
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiAdminOrders(ctx, request.(GetApiAdminOrdersRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiAdminOrders")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiAdminOrdersResponseObject); ok {
+		if err := validResponse.VisitGetApiAdminOrdersResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminOrdersIdRefund operation middleware
+func (sh *strictHandler) PostApiAdminOrdersIdRefund(ctx *gin.Context, id int) {
+	var request PostApiAdminOrdersIdRefundRequestObject
+
+	request.Id = id
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminOrdersIdRefund(ctx, request.(PostApiAdminOrdersIdRefundRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminOrdersIdRefund")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminOrdersIdRefundResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminOrdersIdRefundResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PutApiAdminOrdersIdStatus operation middleware
+func (sh *strictHandler) PutApiAdminOrdersIdStatus(ctx *gin.Context, id int) {
+	var request PutApiAdminOrdersIdStatusRequestObject
+
+	request.Id = id
+
+	var body PutApiAdminOrdersIdStatusJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PutApiAdminOrdersIdStatus(ctx, request.(PutApiAdminOrdersIdStatusRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PutApiAdminOrdersIdStatus")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PutApiAdminOrdersIdStatusResponseObject); ok {
+		if err := validResponse.VisitPutApiAdminOrdersIdStatusResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminServiceAccounts operation middleware
+func (sh *strictHandler) PostApiAdminServiceAccounts(ctx *gin.Context) {
+	var request PostApiAdminServiceAccountsRequestObject
+
+	var body PostApiAdminServiceAccountsJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminServiceAccounts(ctx, request.(PostApiAdminServiceAccountsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminServiceAccounts")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminServiceAccountsResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminServiceAccountsResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetApiAdminServiceAccountsAccountKeys operation middleware
+func (sh *strictHandler) GetApiAdminServiceAccountsAccountKeys(ctx *gin.Context, account string) {
+	var request GetApiAdminServiceAccountsAccountKeysRequestObject
+
+	request.Account = account
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiAdminServiceAccountsAccountKeys(ctx, request.(GetApiAdminServiceAccountsAccountKeysRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiAdminServiceAccountsAccountKeys")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiAdminServiceAccountsAccountKeysResponseObject); ok {
+		if err := validResponse.VisitGetApiAdminServiceAccountsAccountKeysResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminServiceAccountsAccountKeys operation middleware
+func (sh *strictHandler) PostApiAdminServiceAccountsAccountKeys(ctx *gin.Context, account string) {
+	var request PostApiAdminServiceAccountsAccountKeysRequestObject
+
+	request.Account = account
+
+	var body PostApiAdminServiceAccountsAccountKeysJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminServiceAccountsAccountKeys(ctx, request.(PostApiAdminServiceAccountsAccountKeysRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminServiceAccountsAccountKeys")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminServiceAccountsAccountKeysResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminServiceAccountsAccountKeysResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteApiAdminServiceAccountsAccountKeysId operation middleware
+func (sh *strictHandler) DeleteApiAdminServiceAccountsAccountKeysId(ctx *gin.Context, account string, id int) {
+	var request DeleteApiAdminServiceAccountsAccountKeysIdRequestObject
+
+	request.Account = account
+	request.Id = id
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteApiAdminServiceAccountsAccountKeysId(ctx, request.(DeleteApiAdminServiceAccountsAccountKeysIdRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteApiAdminServiceAccountsAccountKeysId")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(DeleteApiAdminServiceAccountsAccountKeysIdResponseObject); ok {
+		if err := validResponse.VisitDeleteApiAdminServiceAccountsAccountKeysIdResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetApiAdminUsersUsernameBalance operation middleware
+func (sh *strictHandler) GetApiAdminUsersUsernameBalance(ctx *gin.Context, username string) {
+	var request GetApiAdminUsersUsernameBalanceRequestObject
+
+	request.Username = username
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiAdminUsersUsernameBalance(ctx, request.(GetApiAdminUsersUsernameBalanceRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiAdminUsersUsernameBalance")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiAdminUsersUsernameBalanceResponseObject); ok {
+		if err := validResponse.VisitGetApiAdminUsersUsernameBalanceResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteApiAdminUsersUsernameLockout operation middleware
+func (sh *strictHandler) DeleteApiAdminUsersUsernameLockout(ctx *gin.Context, username string) {
+	var request DeleteApiAdminUsersUsernameLockoutRequestObject
+
+	request.Username = username
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteApiAdminUsersUsernameLockout(ctx, request.(DeleteApiAdminUsersUsernameLockoutRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteApiAdminUsersUsernameLockout")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(DeleteApiAdminUsersUsernameLockoutResponseObject); ok {
+		if err := validResponse.VisitDeleteApiAdminUsersUsernameLockoutResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAdminUsersUsernameRevokeSessions operation middleware
+func (sh *strictHandler) PostApiAdminUsersUsernameRevokeSessions(ctx *gin.Context, username string) {
+	var request PostApiAdminUsersUsernameRevokeSessionsRequestObject
+
+	request.Username = username
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAdminUsersUsernameRevokeSessions(ctx, request.(PostApiAdminUsersUsernameRevokeSessionsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAdminUsersUsernameRevokeSessions")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAdminUsersUsernameRevokeSessionsResponseObject); ok {
+		if err := validResponse.VisitPostApiAdminUsersUsernameRevokeSessionsResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PutApiAdminUsersUsernameRole operation middleware
+func (sh *strictHandler) PutApiAdminUsersUsernameRole(ctx *gin.Context, username string) {
+	var request PutApiAdminUsersUsernameRoleRequestObject
+
+	request.Username = username
+
+	var body PutApiAdminUsersUsernameRoleJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PutApiAdminUsersUsernameRole(ctx, request.(PutApiAdminUsersUsernameRoleRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PutApiAdminUsersUsernameRole")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PutApiAdminUsersUsernameRoleResponseObject); ok {
+		if err := validResponse.VisitPutApiAdminUsersUsernameRoleResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAuth operation middleware
+func (sh *strictHandler) PostApiAuth(ctx *gin.Context) {
+	var request PostApiAuthRequestObject
+
+	var body PostApiAuthJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAuth(ctx, request.(PostApiAuthRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAuth")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAuthResponseObject); ok {
+		if err := validResponse.VisitPostApiAuthResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAuthLogout operation middleware
+func (sh *strictHandler) PostApiAuthLogout(ctx *gin.Context) {
+	var request PostApiAuthLogoutRequestObject
+
+	var body PostApiAuthLogoutJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAuthLogout(ctx, request.(PostApiAuthLogoutRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAuthLogout")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAuthLogoutResponseObject); ok {
+		if err := validResponse.VisitPostApiAuthLogoutResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAuthPassword operation middleware
+func (sh *strictHandler) PostApiAuthPassword(ctx *gin.Context) {
+	var request PostApiAuthPasswordRequestObject
+
+	var body PostApiAuthPasswordJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAuthPassword(ctx, request.(PostApiAuthPasswordRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAuthPassword")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAuthPasswordResponseObject); ok {
+		if err := validResponse.VisitPostApiAuthPasswordResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAuthRefresh operation middleware
+func (sh *strictHandler) PostApiAuthRefresh(ctx *gin.Context) {
+	var request PostApiAuthRefreshRequestObject
+
+	var body PostApiAuthRefreshJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAuthRefresh(ctx, request.(PostApiAuthRefreshRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAuthRefresh")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAuthRefreshResponseObject); ok {
+		if err := validResponse.VisitPostApiAuthRefreshResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiAuthRegister operation middleware
+func (sh *strictHandler) PostApiAuthRegister(ctx *gin.Context) {
+	var request PostApiAuthRegisterRequestObject
+
+	var body PostApiAuthRegisterJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiAuthRegister(ctx, request.(PostApiAuthRegisterRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiAuthRegister")
+	}
 
-func (response GetApiInfo200JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	response, err := handler(ctx, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiAuthRegisterResponseObject); ok {
+		if err := validResponse.VisitPostApiAuthRegisterResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetApiInfo400JSONResponse ErrorResponse
+// PostApiBuy operation middleware
+func (sh *strictHandler) PostApiBuy(ctx *gin.Context, params PostApiBuyParams) {
+	var request PostApiBuyRequestObject
 
-func (response GetApiInfo400JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	request.Params = params
 
-	return json.NewEncoder(w).Encode(response)
-}
+	var body PostApiBuyJSONRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		ctx.Error(err)
+		return
+	}
+	request.Body = &body
 
-type GetApiInfo401JSONResponse ErrorResponse
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiBuy(ctx, request.(PostApiBuyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiBuy")
+	}
 
-func (response GetApiInfo401JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(401)
+	response, err := handler(ctx, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiBuyResponseObject); ok {
+		if err := validResponse.VisitPostApiBuyResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetApiInfo500JSONResponse ErrorResponse
-
-func (response GetApiInfo500JSONResponse) VisitGetApiInfoResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+// GetApiBuyItem operation middleware
+func (sh *strictHandler) GetApiBuyItem(ctx *gin.Context, item string, params GetApiBuyItemParams) {
+	var request GetApiBuyItemRequestObject
 
-	return json.NewEncoder(w).Encode(response)
-}
+	request.Item = item
+	request.Params = params
 
-type PostApiSendCoinRequestObject struct {
-	Body *PostApiSendCoinJSONRequestBody
-}
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiBuyItem(ctx, request.(GetApiBuyItemRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiBuyItem")
+	}
 
-type PostApiSendCoinResponseObject interface {
-	VisitPostApiSendCoinResponse(w http.ResponseWriter) error
-}
+	response, err := handler(ctx, request)
 
-type PostApiSendCoin200Response struct {
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiBuyItemResponseObject); ok {
+		if err := validResponse.VisitGetApiBuyItemResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-func (response PostApiSendCoin200Response) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
-	w.WriteHeader(200)
-	return nil
-}
+// GetApiCart operation middleware
+func (sh *strictHandler) GetApiCart(ctx *gin.Context) {
+	var request GetApiCartRequestObject
 
-type PostApiSendCoin400JSONResponse ErrorResponse
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiCart(ctx, request.(GetApiCartRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiCart")
+	}
 
-func (response PostApiSendCoin400JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	response, err := handler(ctx, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiCartResponseObject); ok {
+		if err := validResponse.VisitGetApiCartResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type PostApiSendCoin401JSONResponse ErrorResponse
+// PostApiCartCheckout operation middleware
+func (sh *strictHandler) PostApiCartCheckout(ctx *gin.Context) {
+	var request PostApiCartCheckoutRequestObject
 
-func (response PostApiSendCoin401JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(401)
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiCartCheckout(ctx, request.(PostApiCartCheckoutRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiCartCheckout")
+	}
 
-	return json.NewEncoder(w).Encode(response)
-}
+	response, err := handler(ctx, request)
 
-type PostApiSendCoin500JSONResponse ErrorResponse
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiCartCheckoutResponseObject); ok {
+		if err := validResponse.VisitPostApiCartCheckoutResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
 
-func (response PostApiSendCoin500JSONResponse) VisitPostApiSendCoinResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+// DeleteApiCartItem operation middleware
+func (sh *strictHandler) DeleteApiCartItem(ctx *gin.Context, item string) {
+	var request DeleteApiCartItemRequestObject
 
-	return json.NewEncoder(w).Encode(response)
-}
+	request.Item = item
 
-// StrictServerInterface represents all server handlers.
-type StrictServerInterface interface {
-	// Аутентификация и получение JWT-токена. При первой аутентификации пользователь создается автоматически.
-	// (POST /api/auth)
-	PostApiAuth(ctx *gin.Context, request PostApiAuthRequestObject) (PostApiAuthResponseObject, error)
-	// Купить предмет за монеты.
-	// (GET /api/buy/{item})
-	GetApiBuyItem(ctx *gin.Context, request GetApiBuyItemRequestObject) (GetApiBuyItemResponseObject, error)
-	// Получить информацию о монетах, инвентаре и истории транзакций.
-	// (GET /api/info)
-	GetApiInfo(ctx *gin.Context, request GetApiInfoRequestObject) (GetApiInfoResponseObject, error)
-	// Отправить монеты другому пользователю.
-	// (POST /api/sendCoin)
-	PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinRequestObject) (PostApiSendCoinResponseObject, error)
-}
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteApiCartItem(ctx, request.(DeleteApiCartItemRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteApiCartItem")
+	}
 
-type StrictHandlerFunc = strictgin.StrictGinHandlerFunc
-type StrictMiddlewareFunc = strictgin.StrictGinMiddlewareFunc
+	response, err := handler(ctx, request)
 
-func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares}
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(DeleteApiCartItemResponseObject); ok {
+		if err := validResponse.VisitDeleteApiCartItemResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type strictHandler struct {
-	ssi         StrictServerInterface
-	middlewares []StrictMiddlewareFunc
-}
+// PutApiCartItem operation middleware
+func (sh *strictHandler) PutApiCartItem(ctx *gin.Context, item string) {
+	var request PutApiCartItemRequestObject
 
-// PostApiAuth operation middleware
-func (sh *strictHandler) PostApiAuth(ctx *gin.Context) {
-	var request PostApiAuthRequestObject
+	request.Item = item
 
-	var body PostApiAuthJSONRequestBody
+	var body PutApiCartItemJSONRequestBody
 	if err := ctx.ShouldBindJSON(&body); err != nil {
 		ctx.Status(http.StatusBadRequest)
 		ctx.Error(err)
@@ -427,10 +4563,10 @@ func (sh *strictHandler) PostApiAuth(ctx *gin.Context) {
 	request.Body = &body
 
 	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
-		return sh.ssi.PostApiAuth(ctx, request.(PostApiAuthRequestObject))
+		return sh.ssi.PutApiCartItem(ctx, request.(PutApiCartItemRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "PostApiAuth")
+		handler = middleware(handler, "PutApiCartItem")
 	}
 
 	response, err := handler(ctx, request)
@@ -438,8 +4574,8 @@ func (sh *strictHandler) PostApiAuth(ctx *gin.Context) {
 	if err != nil {
 		ctx.Error(err)
 		ctx.Status(http.StatusInternalServerError)
-	} else if validResponse, ok := response.(PostApiAuthResponseObject); ok {
-		if err := validResponse.VisitPostApiAuthResponse(ctx.Writer); err != nil {
+	} else if validResponse, ok := response.(PutApiCartItemResponseObject); ok {
+		if err := validResponse.VisitPutApiCartItemResponse(ctx.Writer); err != nil {
 			ctx.Error(err)
 		}
 	} else if response != nil {
@@ -447,17 +4583,17 @@ func (sh *strictHandler) PostApiAuth(ctx *gin.Context) {
 	}
 }
 
-// GetApiBuyItem operation middleware
-func (sh *strictHandler) GetApiBuyItem(ctx *gin.Context, item string) {
-	var request GetApiBuyItemRequestObject
+// GetApiHistory operation middleware
+func (sh *strictHandler) GetApiHistory(ctx *gin.Context, params GetApiHistoryParams) {
+	var request GetApiHistoryRequestObject
 
-	request.Item = item
+	request.Params = params
 
 	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
-		return sh.ssi.GetApiBuyItem(ctx, request.(GetApiBuyItemRequestObject))
+		return sh.ssi.GetApiHistory(ctx, request.(GetApiHistoryRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "GetApiBuyItem")
+		handler = middleware(handler, "GetApiHistory")
 	}
 
 	response, err := handler(ctx, request)
@@ -465,8 +4601,8 @@ func (sh *strictHandler) GetApiBuyItem(ctx *gin.Context, item string) {
 	if err != nil {
 		ctx.Error(err)
 		ctx.Status(http.StatusInternalServerError)
-	} else if validResponse, ok := response.(GetApiBuyItemResponseObject); ok {
-		if err := validResponse.VisitGetApiBuyItemResponse(ctx.Writer); err != nil {
+	} else if validResponse, ok := response.(GetApiHistoryResponseObject); ok {
+		if err := validResponse.VisitGetApiHistoryResponse(ctx.Writer); err != nil {
 			ctx.Error(err)
 		}
 	} else if response != nil {
@@ -499,10 +4635,118 @@ func (sh *strictHandler) GetApiInfo(ctx *gin.Context) {
 	}
 }
 
+// GetApiMerch operation middleware
+func (sh *strictHandler) GetApiMerch(ctx *gin.Context) {
+	var request GetApiMerchRequestObject
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiMerch(ctx, request.(GetApiMerchRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiMerch")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiMerchResponseObject); ok {
+		if err := validResponse.VisitGetApiMerchResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetApiMerchItem operation middleware
+func (sh *strictHandler) GetApiMerchItem(ctx *gin.Context, item string) {
+	var request GetApiMerchItemRequestObject
+
+	request.Item = item
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiMerchItem(ctx, request.(GetApiMerchItemRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiMerchItem")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiMerchItemResponseObject); ok {
+		if err := validResponse.VisitGetApiMerchItemResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetApiOrders operation middleware
+func (sh *strictHandler) GetApiOrders(ctx *gin.Context, params GetApiOrdersParams) {
+	var request GetApiOrdersRequestObject
+
+	request.Params = params
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetApiOrders(ctx, request.(GetApiOrdersRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetApiOrders")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(GetApiOrdersResponseObject); ok {
+		if err := validResponse.VisitGetApiOrdersResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostApiOrdersIdCancel operation middleware
+func (sh *strictHandler) PostApiOrdersIdCancel(ctx *gin.Context, id int) {
+	var request PostApiOrdersIdCancelRequestObject
+
+	request.Id = id
+
+	handler := func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PostApiOrdersIdCancel(ctx, request.(PostApiOrdersIdCancelRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostApiOrdersIdCancel")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+	} else if validResponse, ok := response.(PostApiOrdersIdCancelResponseObject); ok {
+		if err := validResponse.VisitPostApiOrdersIdCancelResponse(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	} else if response != nil {
+		ctx.Error(fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // PostApiSendCoin operation middleware
-func (sh *strictHandler) PostApiSendCoin(ctx *gin.Context) {
+func (sh *strictHandler) PostApiSendCoin(ctx *gin.Context, params PostApiSendCoinParams) {
 	var request PostApiSendCoinRequestObject
 
+	request.Params = params
+
 	var body PostApiSendCoinJSONRequestBody
 	if err := ctx.ShouldBindJSON(&body); err != nil {
 		ctx.Status(http.StatusBadRequest)
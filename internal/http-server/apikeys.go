@@ -0,0 +1,208 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyPrefix = "msk_"
+	// apiKeyPrefixLen is the number of leading key characters shown in key lists
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+var apiKeyScopes = []ApiKeyScope{CoinsGrant, CoinsClawback, BalancesRead}
+
+func (s *APIServer) PostApiAdminServiceAccounts(ctx *gin.Context, req PostApiAdminServiceAccountsRequestObject) (PostApiAdminServiceAccountsResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminServiceAccounts401JSONResponse(errResp), nil
+	}
+	name := req.Body.Name
+	if !validUsername(name) {
+		errResp := ErrorResponse{Errors: &invalidUsernameErrMsg}
+		return PostApiAdminServiceAccounts400JSONResponse(errResp), nil
+	}
+	err := s.storage.AddServiceAccount(name)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			errResp := ErrorResponse{Errors: &userExistsErrMsg}
+			return PostApiAdminServiceAccounts409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminServiceAccounts500JSONResponse(errResp), nil
+	}
+	s.log.Info("service account created", "admin", ctx.GetString(usernameKey), "account", name)
+	return PostApiAdminServiceAccounts201JSONResponse(ServiceAccount{Name: &name}), nil
+}
+
+func (s *APIServer) GetApiAdminServiceAccountsAccountKeys(ctx *gin.Context, req GetApiAdminServiceAccountsAccountKeysRequestObject) (GetApiAdminServiceAccountsAccountKeysResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiAdminServiceAccountsAccountKeys401JSONResponse(errResp), nil
+	}
+	keys, err := s.storage.APIKeys(req.Account)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &serviceAccountNotFoundErrMsg}
+			return GetApiAdminServiceAccountsAccountKeys404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiAdminServiceAccountsAccountKeys500JSONResponse(errResp), nil
+	}
+	list := make([]ApiKey, len(keys))
+	for i, key := range keys {
+		list[i] = convertAPIKey(key)
+	}
+	return GetApiAdminServiceAccountsAccountKeys200JSONResponse(ApiKeyListResponse{Keys: &list}), nil
+}
+
+func (s *APIServer) PostApiAdminServiceAccountsAccountKeys(ctx *gin.Context, req PostApiAdminServiceAccountsAccountKeysRequestObject) (PostApiAdminServiceAccountsAccountKeysResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminServiceAccountsAccountKeys401JSONResponse(errResp), nil
+	}
+	if len(req.Body.Scopes) == 0 {
+		errResp := ErrorResponse{Errors: &emptyScopesErrMsg}
+		return PostApiAdminServiceAccountsAccountKeys400JSONResponse(errResp), nil
+	}
+	scopes := make([]string, 0, len(req.Body.Scopes))
+	for _, scope := range req.Body.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			errResp := ErrorResponse{Errors: &invalidScopeErrMsg}
+			return PostApiAdminServiceAccountsAccountKeys400JSONResponse(errResp), nil
+		}
+		if !slices.Contains(scopes, string(scope)) {
+			scopes = append(scopes, string(scope))
+		}
+	}
+	plain, hash, err := newAPIKey()
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminServiceAccountsAccountKeys500JSONResponse(errResp), err
+	}
+	key := storage.APIKey{Account: req.Account, Prefix: plain[:apiKeyPrefixLen], Hash: hash, Scopes: scopes}
+	if req.Body.Description != nil {
+		key.Description = *req.Body.Description
+	}
+	id, err := s.storage.AddAPIKey(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &serviceAccountNotFoundErrMsg}
+			return PostApiAdminServiceAccountsAccountKeys404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminServiceAccountsAccountKeys500JSONResponse(errResp), nil
+	}
+	s.log.Info("api key created", "admin", ctx.GetString(usernameKey), "account", req.Account, "key", id, "scopes", scopes)
+	keyScopes := convertScopes(scopes)
+	return PostApiAdminServiceAccountsAccountKeys201JSONResponse(ApiKeyCreatedResponse{
+		Id:     &id,
+		Key:    &plain,
+		Prefix: &key.Prefix,
+		Scopes: &keyScopes,
+	}), nil
+}
+
+func (s *APIServer) DeleteApiAdminServiceAccountsAccountKeysId(ctx *gin.Context, req DeleteApiAdminServiceAccountsAccountKeysIdRequestObject) (DeleteApiAdminServiceAccountsAccountKeysIdResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return DeleteApiAdminServiceAccountsAccountKeysId401JSONResponse(errResp), nil
+	}
+	err := s.storage.RevokeAPIKey(req.Account, req.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			errResp := ErrorResponse{Errors: &apiKeyNotFoundErrMsg}
+			return DeleteApiAdminServiceAccountsAccountKeysId404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiAdminServiceAccountsAccountKeysId500JSONResponse(errResp), nil
+	}
+	s.log.Info("api key revoked", "admin", ctx.GetString(usernameKey), "account", req.Account, "key", req.Id)
+	return DeleteApiAdminServiceAccountsAccountKeysId204Response{}, nil
+}
+
+func (s *APIServer) GetApiAdminUsersUsernameBalance(ctx *gin.Context, req GetApiAdminUsersUsernameBalanceRequestObject) (GetApiAdminUsersUsernameBalanceResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiAdminUsersUsernameBalance401JSONResponse(errResp), nil
+	}
+	coins, err := s.storage.UserBalance(req.Username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &userNotFoundErrMsg}
+			return GetApiAdminUsersUsernameBalance404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiAdminUsersUsernameBalance500JSONResponse(errResp), nil
+	}
+	return GetApiAdminUsersUsernameBalance200JSONResponse(BalanceResponse{Username: &req.Username, Coins: &coins}), nil
+}
+
+// authenticateAPIKey marks the request as made by the service account owning the key.
+// Unknown and revoked keys leave the request unauthorized.
+func (s *APIServer) authenticateAPIKey(ctx *gin.Context, plain string) error {
+	key, err := s.storage.UseAPIKey(hashSecret(strings.TrimSpace(plain)))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			ctx.Set(authorizedKey, false)
+			return nil
+		}
+		return err
+	}
+	ctx.Set(authorizedKey, true)
+	ctx.Set(usernameKey, key.Account)
+	ctx.Set(roleKey, storage.RoleService)
+	ctx.Set(scopesKey, key.Scopes)
+	return nil
+}
+
+// newAPIKey returns an API key and the hash it is stored under.
+func newAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, hashSecret(key), nil
+}
+
+func convertAPIKey(key storage.APIKey) ApiKey {
+	scopes := convertScopes(key.Scopes)
+	return ApiKey{
+		Id:          &key.ID,
+		Prefix:      &key.Prefix,
+		Description: &key.Description,
+		Scopes:      &scopes,
+		CreatedAt:   &key.CreatedAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+	}
+}
+
+func convertScopes(scopes []string) []ApiKeyScope {
+	converted := make([]ApiKeyScope, len(scopes))
+	for i, scope := range scopes {
+		converted[i] = ApiKeyScope(scope)
+	}
+	return converted
+}
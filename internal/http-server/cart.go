@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"errors"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) GetApiCart(ctx *gin.Context, req GetApiCartRequestObject) (GetApiCartResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiCart401JSONResponse(errResp), nil
+	}
+	cart, err := s.storage.Cart(ctx.GetString(usernameKey))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+			return GetApiCart401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiCart500JSONResponse(errResp), nil
+	}
+	return GetApiCart200JSONResponse(convertCart(cart)), nil
+}
+
+func (s *APIServer) PutApiCartItem(ctx *gin.Context, req PutApiCartItemRequestObject) (PutApiCartItemResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PutApiCartItem401JSONResponse(errResp), nil
+	}
+	if req.Body.Quantity < 1 || req.Body.Quantity > s.maxPurchaseQuantity {
+		errMsg := s.invalidQuantityErrMsg()
+		return PutApiCartItem400JSONResponse(ErrorResponse{Errors: &errMsg}), nil
+	}
+	user := ctx.GetString(usernameKey)
+	err := s.storage.SetCartItem(user, req.Item, req.Body.Quantity)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemNotFound) {
+			errResp := ErrorResponse{Errors: &noSuchItemErrMsg}
+			return PutApiCartItem400JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrItemUnavailable) {
+			errResp := ErrorResponse{Errors: &itemUnavailableErrMsg}
+			return PutApiCartItem400JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+			return PutApiCartItem401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PutApiCartItem500JSONResponse(errResp), nil
+	}
+	cart, err := s.storage.Cart(user)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PutApiCartItem500JSONResponse(errResp), nil
+	}
+	return PutApiCartItem200JSONResponse(convertCart(cart)), nil
+}
+
+func (s *APIServer) DeleteApiCartItem(ctx *gin.Context, req DeleteApiCartItemRequestObject) (DeleteApiCartItemResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return DeleteApiCartItem401JSONResponse(errResp), nil
+	}
+	user := ctx.GetString(usernameKey)
+	err := s.storage.RemoveCartItem(user, req.Item)
+	if err != nil {
+		if errors.Is(err, storage.ErrCartItemNotFound) {
+			errResp := ErrorResponse{Errors: &cartItemNotFoundErrMsg}
+			return DeleteApiCartItem404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+			return DeleteApiCartItem401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiCartItem500JSONResponse(errResp), nil
+	}
+	cart, err := s.storage.Cart(user)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiCartItem500JSONResponse(errResp), nil
+	}
+	return DeleteApiCartItem200JSONResponse(convertCart(cart)), nil
+}
+
+func (s *APIServer) PostApiCartCheckout(ctx *gin.Context, req PostApiCartCheckoutRequestObject) (PostApiCartCheckoutResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiCartCheckout401JSONResponse(errResp), nil
+	}
+	cart, err := s.storage.Checkout(ctx.GetString(usernameKey))
+	if err != nil {
+		var checkoutErr *storage.CheckoutError
+		if errors.As(err, &checkoutErr) {
+			return PostApiCartCheckout400JSONResponse(convertCheckoutError(checkoutErr)), nil
+		}
+		if errors.Is(err, storage.ErrCartEmpty) {
+			return PostApiCartCheckout400JSONResponse(CheckoutErrorResponse{Errors: &emptyCartErrMsg}), nil
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+			return PostApiCartCheckout401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiCartCheckout500JSONResponse(errResp), nil
+	}
+	return PostApiCartCheckout200JSONResponse(convertCart(cart)), nil
+}
+
+func convertCart(cart *storage.Cart) Cart {
+	items := make([]CartItem, len(cart.Items))
+	for i, item := range cart.Items {
+		cost := item.Price * item.Quantity
+		items[i] = CartItem{
+			Item:      &item.Item,
+			Price:     &item.Price,
+			Quantity:  &item.Quantity,
+			Cost:      &cost,
+			Available: &item.Available,
+		}
+	}
+	return Cart{Items: &items, Total: &cart.Total}
+}
+
+// convertCheckoutError tells which items cannot be bought, or that the coins are not enough for the cart.
+func convertCheckoutError(err *storage.CheckoutError) CheckoutErrorResponse {
+	lines := make([]CheckoutLineError, len(err.Lines))
+	for i, line := range err.Lines {
+		errMsg := itemUnavailableErrMsg
+		if errors.Is(line.Err, storage.ErrItemNotFound) {
+			errMsg = noSuchItemErrMsg
+		}
+		lines[i] = CheckoutLineError{Item: &line.Item, Error: &errMsg}
+	}
+	errMsg := insufficientBalanceErrMsg
+	if len(lines) > 0 {
+		errMsg = cartItemsUnavailableErrMsg
+	}
+	return CheckoutErrorResponse{
+		Errors: &errMsg,
+		Lines:  &lines,
+		Total:  &err.Total,
+		Coins:  &err.Balance,
+	}
+}
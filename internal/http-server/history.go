@@ -0,0 +1,114 @@
+package httpserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+func (s *APIServer) GetApiHistory(ctx *gin.Context, req GetApiHistoryRequestObject) (GetApiHistoryResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiHistory401JSONResponse(errResp), nil
+	}
+	name := ctx.GetString(usernameKey)
+
+	filter := storage.HistoryFilter{Limit: defaultHistoryLimit}
+	params := req.Params
+	if params.Direction != nil {
+		switch *params.Direction {
+		case Sent, Received:
+			filter.Direction = string(*params.Direction)
+		default:
+			errResp := ErrorResponse{Errors: &invalidDirectionErrMsg}
+			return GetApiHistory400JSONResponse(errResp), nil
+		}
+	}
+	if params.Counterparty != nil {
+		filter.Counterparty = *params.Counterparty
+	}
+	if params.From != nil {
+		filter.From = *params.From
+	}
+	if params.To != nil {
+		filter.To = *params.To
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxHistoryLimit {
+			errResp := ErrorResponse{Errors: &invalidLimitErrMsg}
+			return GetApiHistory400JSONResponse(errResp), nil
+		}
+		filter.Limit = *params.Limit
+	}
+	if params.Cursor != nil {
+		cursor, err := parseHistoryCursor(*params.Cursor)
+		if err != nil {
+			errResp := ErrorResponse{Errors: &invalidCursorErrMsg}
+			return GetApiHistory400JSONResponse(errResp), nil
+		}
+		filter.After = cursor
+	}
+
+	exists, err := s.storage.UserExist(name)
+	if !exists || err != nil {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiHistory401JSONResponse(errResp), nil
+	}
+	page, err := s.storage.CoinHistory(name, filter)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiHistory500JSONResponse(errResp), nil
+	}
+
+	items := make([]HistoryEntry, len(page.Entries))
+	for i, entry := range page.Entries {
+		id := entry.ID
+		direction := HistoryDirection(entry.Direction)
+		counterparty := entry.Counterparty
+		amount := entry.Amount
+		date := entry.CreatedAt
+		items[i] = HistoryEntry{
+			Id:           &id,
+			Direction:    &direction,
+			Counterparty: &counterparty,
+			Amount:       &amount,
+			Date:         &date,
+		}
+	}
+	resp := HistoryResponse{Items: &items}
+	if page.Next != nil {
+		next := formatHistoryCursor(page.Next)
+		resp.NextCursor = &next
+	}
+	return GetApiHistory200JSONResponse(resp), nil
+}
+
+// History cursors have the form "<transaction id>-<direction>".
+func formatHistoryCursor(c *storage.HistoryCursor) string {
+	return fmt.Sprintf("%d-%s", c.ID, c.Direction)
+}
+
+func parseHistoryCursor(s string) (*storage.HistoryCursor, error) {
+	idPart, direction, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	if direction != storage.DirectionSent && direction != storage.DirectionReceived {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	return &storage.HistoryCursor{ID: id, Direction: direction}, nil
+}
@@ -0,0 +1,23 @@
+package httpserver
+
+import (
+	"testing"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryCursor(t *testing.T) {
+	// Сценарий 1: Курсор восстанавливается после форматирования
+	cursor := &storage.HistoryCursor{ID: 42, Direction: storage.DirectionReceived}
+	parsed, err := parseHistoryCursor(formatHistoryCursor(cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+
+	// Сценарий 2: Некорректные курсоры
+	for _, s := range []string{"", "42", "abc-sent", "-1-sent", "0-sent", "42-sideways"} {
+		parsed, err = parseHistoryCursor(s)
+		assert.Error(t, err, s)
+		assert.Nil(t, parsed)
+	}
+}
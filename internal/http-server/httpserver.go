@@ -2,52 +2,245 @@ package httpserver
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
 	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage/memory"
 	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	internalServerErrorMsg     string = "Internal server error"
-	wrongPassOrUsernameErrMsg  string = "Wrong password or username"
-	insufficientBalanceErrMsg  string = "Insufficient balance"
-	recieverDoesNotExistErrMsg string = "User to send coins to does not exist"
-	unauthorizedErrMsg         string = "Unauthorized"
-	noSuchItemErrMsg           string = "Requested merch not found"
+	internalServerErrorMsg         string = "Internal server error"
+	wrongPassOrUsernameErrMsg      string = "Wrong password or username"
+	insufficientBalanceErrMsg      string = "Insufficient balance"
+	recieverDoesNotExistErrMsg     string = "User to send coins to does not exist"
+	unauthorizedErrMsg             string = "Unauthorized"
+	noSuchItemErrMsg               string = "Requested merch not found"
+	invalidCursorErrMsg            string = "Invalid cursor"
+	invalidLimitErrMsg             string = "Limit must be between 1 and 100"
+	invalidDirectionErrMsg         string = "Direction must be either sent or received"
+	itemUnavailableErrMsg          string = "Requested merch is not available"
+	forbiddenErrMsg                string = "Forbidden"
+	itemExistsErrMsg               string = "Merch with this name already exists"
+	invalidItemNameErrMsg          string = "Item name must not be empty"
+	invalidPriceErrMsg             string = "Price must be positive"
+	invalidRoleErrMsg              string = "Role must be either user or admin"
+	serviceAccountRoleErrMsg       string = "Role of a service account can't be changed"
+	userNotFoundErrMsg             string = "User not found"
+	invalidAmountErrMsg            string = "Amount must be between 1 and 2147483647"
+	emptyReasonErrMsg              string = "Reason must not be empty"
+	emptyUsersErrMsg               string = "Users list must not be empty"
+	duplicateUserErrMsg            string = "Users list contains duplicates"
+	invalidRefreshTokenErrMsg      string = "Invalid refresh token"
+	emptyRefreshTokenErrMsg        string = "Refresh token must not be empty"
+	invalidUsernameErrMsg          string = "Username must be 3 to 32 letters, digits, '.', '_' or '-' and start with a letter or digit"
+	registrationDisabledErrMsg     string = "Registration is disabled"
+	userExistsErrMsg               string = "User already exists"
+	accountLockedErrMsg            string = "Account is temporarily locked after failed logins"
+	tooManyAttemptsErrMsg          string = "Too many failed logins, try again later"
+	wrongPasswordErrMsg            string = "Wrong password"
+	samePasswordErrMsg             string = "New password must differ from the old one"
+	serviceAccountNotFoundErrMsg   string = "Service account not found"
+	apiKeyNotFoundErrMsg           string = "API key not found"
+	emptyScopesErrMsg              string = "Scopes must not be empty"
+	invalidScopeErrMsg             string = "Scope must be one of coins:grant, coins:clawback, balances:read"
+	invalidIdempotencyKeyErrMsg    string = "Idempotency key must be 1 to 255 characters long"
+	idempotencyKeyReusedErrMsg     string = "Idempotency key was already used for a different request"
+	idempotencyKeyInProgressErrMsg string = "A request with this idempotency key is still in progress"
+	idempotencyKeyUnknownErrMsg    string = "A request with this idempotency key did not finish and may have been executed, check the balance and history before retrying with a new key"
+	emptyCartErrMsg                string = "Cart is empty"
+	cartItemNotFoundErrMsg         string = "Item is not in the cart"
+	cartItemsUnavailableErrMsg     string = "Some items in the cart cannot be bought"
+	orderNotFoundErrMsg            string = "Order not found"
+	invalidOrderStatusErrMsg       string = "Status must be one of placed, processing, shipped, delivered, cancelled"
+	invalidNextOrderStatusErrMsg   string = "Status must be one of processing, shipped, delivered"
+	orderStatusErrMsg              string = "Order has already reached this status"
+	orderCancelledErrMsg           string = "Order is already cancelled"
+	refundWindowExpiredErrMsg      string = "Order can no longer be cancelled"
 )
 
 var (
 	usernameKey   string = "username"
 	authorizedKey string = "authorized"
+	roleKey       string = "role"
+	// tokenIDKey and tokenExpiresKey identify the access token of the request for logout
+	tokenIDKey      string = "tokenID"
+	tokenExpiresKey string = "tokenExpires"
+	// scopesKey holds the scopes of the API key the request was made with
+	scopesKey string = "scopes"
 )
 
 type Storage interface {
 	SendCoins(fromUser string, toUser string, amount int) error
-	Buy(item string, user string) error
+	Buy(item string, user string, quantity int) error
 	AddUser(name, passHash string) error
+	AddExternalUser(name, issuer string) error
+	UserIssuer(name string) (string, error)
 	UserPassHash(name string) (string, error)
+	SetUserPassHash(name, passHash string) error
+	ChangeUserPassHash(name, passHash string, at time.Time) error
 	UserExist(name string) (bool, error)
-	UserInfo(user string) (*postgres.UserInfo, error)
+	UserInfo(user string) (*storage.UserInfo, error)
 	ItemExist(name string) (bool, error)
+	CoinHistory(user string, filter storage.HistoryFilter) (*storage.HistoryPage, error)
+	MerchList() ([]storage.MerchItem, error)
+	MerchItem(name string) (*storage.MerchItem, error)
+	AddMerchItem(item storage.MerchItem) error
+	UpdateMerchItem(name string, update storage.MerchUpdate) (*storage.MerchItem, error)
+	RetireMerchItem(name string) error
+	UserRole(name string) (string, error)
+	SetUserRole(name, role string) error
+	GrantCoins(admin string, users []string, amount int, reason string) error
+	ClawbackCoins(admin string, user string, amount int, reason string) error
+	AddRefreshToken(user string, token storage.RefreshToken) error
+	RotateRefreshToken(hash, nextHash string, nextExpiresAt time.Time) (string, error)
+	RevokeRefreshToken(hash string) error
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeUserTokens(user string, at time.Time) error
+	TokenRevoked(user, jti string, issuedAt time.Time) (bool, error)
+	LoginAttempts(key string) (*storage.LoginAttempts, error)
+	AddLoginFailure(key string, since time.Time, lockout storage.LoginLockout) (*storage.LoginAttempts, error)
+	ResetLoginAttempts(key string) error
+	UserBalance(name string) (int, error)
+	AddServiceAccount(name string) error
+	AddAPIKey(key storage.APIKey) (int, error)
+	APIKeys(account string) ([]storage.APIKey, error)
+	RevokeAPIKey(account string, id int) error
+	UseAPIKey(hash string) (*storage.APIKey, error)
+	ReserveIdempotencyKey(user, key, fingerprint string, since, pendingSince time.Time) (*storage.IdempotentResponse, error)
+	CompleteIdempotencyKey(user, key string, resp storage.IdempotentResponse) error
+	ReleaseIdempotencyKey(user, key string) error
+	SetCartItem(user, item string, quantity int) error
+	RemoveCartItem(user, item string) error
+	Cart(user string) (*storage.Cart, error)
+	Checkout(user string) (*storage.Cart, error)
+	Orders(user string, filter storage.OrderFilter) (*storage.OrderPage, error)
+	AllOrders(filter storage.OrderFilter) (*storage.OrderPage, error)
+	SetOrderStatus(id int, status string) (*storage.Order, error)
+	CancelOrder(id int, user string, since time.Time) (*storage.Order, error)
 }
 type APIServer struct {
-	jwtSecret []byte
-	storage   Storage
-	log       *slog.Logger
+	jwtKeys keySet
+	storage Storage
+	log     *slog.Logger
+	// adminUsers get the admin role on signup or login
+	adminUsers map[string]bool
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	signupMode string
+	lockout    lockoutPolicy
+	passwords  passwordPolicy
+	// trustedProxies may set X-Forwarded-For, which gives the client address for lockouts
+	trustedProxies []string
+	// oidc validates tokens of the external identity provider, nil if OIDC is off
+	oidc              *oidcVerifier
+	idempotencyKeyTTL time.Duration
+	// idempotencyPendingTimeout is how long retries wait for an unfinished request before
+	// its outcome is reported unknown
+	idempotencyPendingTimeout time.Duration
+	// maxPurchaseQuantity is the most items a single buy request may buy
+	maxPurchaseQuantity int
+	// refundWindow is how long after placing an order users may cancel it
+	refundWindow time.Duration
 }
 
-func New(cfg *config.Config) *APIServer {
+func New(cfg *config.Config) (*APIServer, error) {
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	storage, err := postgres.New(cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBHost)
+	var st Storage
+	switch cfg.StorageType {
+	case "memory":
+		st = memory.New()
+	case "", "postgres":
+		pg, err := postgres.New(cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBHost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+		st = pg
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", cfg.StorageType)
+	}
+	adminUsers := make(map[string]bool, len(cfg.AdminUsers))
+	for _, name := range cfg.AdminUsers {
+		adminUsers[name] = true
+	}
+	keys, generated, err := loadKeys(cfg)
 	if err != nil {
-		log.Error(err.Error())
+		return nil, err
+	}
+	if generated {
+		log.Warn("JWT_SECRET is not set, using a random key, tokens won't survive a restart")
+	}
+	signupMode := cfg.SignupMode
+	switch signupMode {
+	case "":
+		signupMode = config.SignupAuto
+	case config.SignupAuto, config.SignupRegister, config.SignupDisabled:
+	default:
+		return nil, fmt.Errorf("unknown signup mode %q", signupMode)
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+		}
+	}
+	passwords, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	oidc, err := newOIDCVerifier(cfg.OIDC)
+	if err != nil {
+		return nil, err
+	}
+	accessTTL, refreshTTL := cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTTL
 	}
-	return &APIServer{jwtSecret: []byte("jwtSecretKey"), storage: storage, log: log}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTTL
+	}
+	idempotencyKeyTTL := cfg.IdempotencyKeyTTL
+	if idempotencyKeyTTL <= 0 {
+		idempotencyKeyTTL = defaultIdempotencyKeyTTL
+	}
+	idempotencyPendingTimeout := cfg.IdempotencyPendingTimeout
+	if idempotencyPendingTimeout <= 0 {
+		idempotencyPendingTimeout = defaultIdempotencyPendingTimeout
+	}
+	maxPurchaseQuantity := cfg.MaxPurchaseQuantity
+	if maxPurchaseQuantity <= 0 {
+		maxPurchaseQuantity = defaultMaxPurchaseQuantity
+	}
+	refundWindow := cfg.RefundWindow
+	if refundWindow <= 0 {
+		refundWindow = defaultRefundWindow
+	}
+	return &APIServer{
+		jwtKeys:                   keys,
+		storage:                   st,
+		log:                       log,
+		adminUsers:                adminUsers,
+		accessTTL:                 accessTTL,
+		refreshTTL:                refreshTTL,
+		signupMode:                signupMode,
+		lockout:                   newLockoutPolicy(cfg.Lockout),
+		passwords:                 passwords,
+		trustedProxies:            cfg.TrustedProxies,
+		oidc:                      oidc,
+		idempotencyKeyTTL:         idempotencyKeyTTL,
+		idempotencyPendingTimeout: idempotencyPendingTimeout,
+		maxPurchaseQuantity:       maxPurchaseQuantity,
+		refundWindow:              refundWindow,
+	}, nil
 }
 func (s *APIServer) PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinRequestObject) (PostApiSendCoinResponseObject, error) {
 	authorized := ctx.GetBool(authorizedKey)
@@ -57,6 +250,10 @@ func (s *APIServer) PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinReq
 	}
 	fromUser := ctx.GetString("username")
 	amount, toUser := request.Body.Amount, request.Body.ToUser
+	if !validAmount(amount) {
+		errResp := ErrorResponse{Errors: &invalidAmountErrMsg}
+		return PostApiSendCoin400JSONResponse(errResp), nil
+	}
 	exists, err := s.storage.UserExist(toUser)
 	if err != nil {
 		s.log.Error(err.Error())
@@ -73,6 +270,10 @@ func (s *APIServer) PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinReq
 			errResp := ErrorResponse{Errors: &insufficientBalanceErrMsg}
 			return PostApiSendCoin400JSONResponse(errResp), nil
 		}
+		if errors.Is(err, storage.ErrInvalidAmount) {
+			errResp := ErrorResponse{Errors: &invalidAmountErrMsg}
+			return PostApiSendCoin400JSONResponse(errResp), nil
+		}
 		s.log.Error(err.Error())
 		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 		return PostApiSendCoin500JSONResponse(errResp), err
@@ -82,7 +283,28 @@ func (s *APIServer) PostApiSendCoin(ctx *gin.Context, request PostApiSendCoinReq
 }
 func (s *APIServer) PostApiAuth(ctx *gin.Context, req PostApiAuthRequestObject) (PostApiAuthResponseObject, error) {
 	name, pass := req.Body.Username, req.Body.Password
-	var authResp AuthResponse
+	ip := ctx.ClientIP()
+	//locks are checked before the password, so guessing it during a lock is pointless
+	locked, err := s.loginLocked(ipLoginKey(ip))
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuth500JSONResponse(errResp), err
+	}
+	if locked > 0 {
+		errResp := ErrorResponse{Errors: &tooManyAttemptsErrMsg}
+		return PostApiAuth429JSONResponse{Body: errResp, Headers: PostApiAuth429ResponseHeaders{RetryAfter: retryAfter(locked)}}, nil
+	}
+	locked, err = s.loginAttempt(name)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuth500JSONResponse(errResp), err
+	}
+	if locked > 0 {
+		errResp := ErrorResponse{Errors: &accountLockedErrMsg}
+		return PostApiAuth423JSONResponse{Body: errResp, Headers: PostApiAuth423ResponseHeaders{RetryAfter: retryAfter(locked)}}, nil
+	}
 	//check if user exists
 	exists, err := s.storage.UserExist(name)
 	if err != nil {
@@ -97,74 +319,157 @@ func (s *APIServer) PostApiAuth(ctx *gin.Context, req PostApiAuthRequestObject)
 			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 			return PostApiAuth500JSONResponse(errResp), err
 		}
+		//accounts without a password answer as slowly as the others
+		if passHash == "" {
+			s.passwords.compareDummy(pass)
+		}
 		err = bcrypt.CompareHashAndPassword([]byte(passHash), []byte(pass))
 		if err != nil {
+			if err := s.loginFailed(ip); err != nil {
+				s.log.Error(err.Error())
+				errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+				return PostApiAuth500JSONResponse(errResp), err
+			}
 			errResp := ErrorResponse{Errors: &wrongPassOrUsernameErrMsg}
 			return PostApiAuth401JSONResponse(errResp), nil
 		}
+		if err := s.storage.ResetLoginAttempts(userLoginKey(name)); err != nil {
+			s.log.Error(err.Error())
+			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+			return PostApiAuth500JSONResponse(errResp), err
+		}
+		s.upgradeHash(name, passHash, pass)
 		//return jwt token here
-		token, err := createToken(name, s.jwtSecret)
+		authResp, err := s.issueTokens(name)
 		if err != nil {
 			s.log.Error(err.Error())
 			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 			return PostApiAuth500JSONResponse(errResp), err
 		}
-		authResp.Token = &token
+		created := false
+		authResp.Created = &created
 		return PostApiAuth200JSONResponse(authResp), nil
 	} else {
-		bPas, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+		//unknown users are only created here in the auto signup mode, kept for backward compatibility
+		if s.signupMode != config.SignupAuto {
+			s.passwords.compareDummy(pass)
+			if err := s.loginFailed(ip); err != nil {
+				s.log.Error(err.Error())
+				errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+				return PostApiAuth500JSONResponse(errResp), err
+			}
+			errResp := ErrorResponse{Errors: &wrongPassOrUsernameErrMsg}
+			return PostApiAuth401JSONResponse(errResp), nil
+		}
+		if !validUsername(name) {
+			errResp := ErrorResponse{Errors: &invalidUsernameErrMsg}
+			return PostApiAuth400JSONResponse(errResp), nil
+		}
+		if msg := s.passwords.violation(pass); msg != "" {
+			errResp := ErrorResponse{Errors: &msg}
+			return PostApiAuth400JSONResponse(errResp), nil
+		}
+		err = s.createUser(name, pass)
 		if err != nil {
 			s.log.Error(err.Error())
 			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 			return PostApiAuth500JSONResponse(errResp), err
 		}
-		err = s.storage.AddUser(name, string(bPas))
-		if err != nil {
+		if err := s.storage.ResetLoginAttempts(userLoginKey(name)); err != nil {
 			s.log.Error(err.Error())
 			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 			return PostApiAuth500JSONResponse(errResp), err
 		}
 		//return jwt token here
-		token, err := createToken(name, s.jwtSecret)
+		authResp, err := s.issueTokens(name)
 		if err != nil {
 			s.log.Error(err.Error())
 			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
 			return PostApiAuth500JSONResponse(errResp), err
 		}
-		authResp.Token = &token
+		created := true
+		authResp.Created = &created
 		return PostApiAuth200JSONResponse(authResp), nil
 	}
 }
+func (s *APIServer) PostApiBuy(ctx *gin.Context, req PostApiBuyRequestObject) (PostApiBuyResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiBuy401JSONResponse(errResp), nil
+	}
+	status, errMsg := s.buy(ctx.GetString(usernameKey), req.Body.Item, req.Body.Quantity)
+	errResp := ErrorResponse{Errors: &errMsg}
+	switch status {
+	case http.StatusOK:
+		return PostApiBuy200Response{}, nil
+	case http.StatusBadRequest:
+		return PostApiBuy400JSONResponse(errResp), nil
+	case http.StatusUnauthorized:
+		return PostApiBuy401JSONResponse(errResp), nil
+	}
+	return PostApiBuy500JSONResponse(errResp), nil
+}
 func (s *APIServer) GetApiBuyItem(ctx *gin.Context, req GetApiBuyItemRequestObject) (GetApiBuyItemResponseObject, error) {
 	authorized := ctx.GetBool(authorizedKey)
 	if !authorized {
 		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
 		return GetApiBuyItem401JSONResponse(errResp), nil
 	}
+	status, errMsg := s.buy(ctx.GetString(usernameKey), req.Item, req.Params.Quantity)
+	errResp := ErrorResponse{Errors: &errMsg}
+	switch status {
+	case http.StatusOK:
+		return GetApiBuyItem200Response{}, nil
+	case http.StatusBadRequest:
+		return GetApiBuyItem400JSONResponse(errResp), nil
+	case http.StatusUnauthorized:
+		return GetApiBuyItem401JSONResponse(errResp), nil
+	}
+	return GetApiBuyItem500JSONResponse(errResp), nil
+}
+
+const defaultMaxPurchaseQuantity = 100
 
-	exists, err := s.storage.ItemExist(req.Item)
+func (s *APIServer) invalidQuantityErrMsg() string {
+	return fmt.Sprintf("Quantity must be between 1 and %d", s.maxPurchaseQuantity)
+}
+
+// buy makes a purchase for both buy operations and returns the response status
+// with the error message. A missing quantity means a single item.
+func (s *APIServer) buy(buyer, item string, quantity *int) (int, string) {
+	count := 1
+	if quantity != nil {
+		count = *quantity
+	}
+	if count < 1 || count > s.maxPurchaseQuantity {
+		return http.StatusBadRequest, s.invalidQuantityErrMsg()
+	}
+
+	exists, err := s.storage.ItemExist(item)
 	if err != nil || !exists {
-		errResp := ErrorResponse{Errors: &noSuchItemErrMsg}
-		return GetApiBuyItem400JSONResponse(errResp), nil
+		return http.StatusBadRequest, noSuchItemErrMsg
 	}
 
-	buyer := ctx.GetString(usernameKey)
 	exists, err = s.storage.UserExist(buyer)
 	if !exists || err != nil {
-		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
-		return GetApiBuyItem401JSONResponse(errResp), nil
+		return http.StatusUnauthorized, unauthorizedErrMsg
 	}
-	err = s.storage.Buy(req.Item, buyer)
+	err = s.storage.Buy(item, buyer, count)
 	if err != nil {
 		if errors.Is(err, storage.ErrUnsufficientBalance) {
-			errResp := ErrorResponse{Errors: &insufficientBalanceErrMsg}
-			return GetApiBuyItem400JSONResponse(errResp), nil
+			return http.StatusBadRequest, insufficientBalanceErrMsg
+		}
+		if errors.Is(err, storage.ErrItemNotFound) {
+			return http.StatusBadRequest, noSuchItemErrMsg
+		}
+		if errors.Is(err, storage.ErrItemUnavailable) {
+			return http.StatusBadRequest, itemUnavailableErrMsg
 		}
 		s.log.Error(err.Error())
-		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
-		return GetApiBuyItem500JSONResponse(errResp), nil
+		return http.StatusInternalServerError, internalServerErrorMsg
 	}
-	return GetApiBuyItem200Response{}, nil
+	return http.StatusOK, ""
 }
 func (s *APIServer) GetApiInfo(ctx *gin.Context, request GetApiInfoRequestObject) (GetApiInfoResponseObject, error) {
 	authorized := ctx.GetBool(authorizedKey)
@@ -203,89 +508,240 @@ func (s *APIServer) GetApiInfo(ctx *gin.Context, request GetApiInfoRequestObject
 	}
 	respInfo.Inventory = &inv
 	respInfo.CoinHistory = convertCoinHistory(dbUserInfo.CoinHistory)
+	respInfo.Purchases = convertPurchases(dbUserInfo.Purchases)
 	return GetApiInfo200JSONResponse(respInfo), nil
 }
 func (s *APIServer) AuthMiddleware(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
-	if operationID == "PostApiAuth" {
+	//public operations
+	switch operationID {
+	case "PostApiAuth", "PostApiAuthRegister", "PostApiAuthRefresh", "GetWellKnownJwksJson":
 		return f
 	}
 
 	return func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		//service accounts authenticate with an API key instead of a token
+		if key := ctx.GetHeader(apiKeyHeader); key != "" {
+			if err := s.authenticateAPIKey(ctx, key); err != nil {
+				s.log.Error(err.Error())
+				return nil, err
+			}
+			return f(ctx, request)
+		}
 		token, err := GetTokenFromContext(ctx)
 		if err != nil {
 			s.log.Error(err.Error())
 			ctx.Set(authorizedKey, false)
 			return f(ctx, request)
 		}
-		name, err := GetUserFromToken(token, s.jwtSecret)
+		var claims tokenClaims
+		if s.oidc != nil && s.oidc.issuedBy(token) {
+			claims, err = s.oidc.verify(token)
+		} else {
+			claims, err = parseToken(token, s.jwtKeys)
+		}
 		if err != nil {
 			s.log.Error(err.Error())
 			ctx.Set(authorizedKey, false)
 			return f(ctx, request)
 		}
+		//identity provider tokens carry no role, the user is looked up and created on first sight
+		if claims.role == "" {
+			claims.role, err = s.oidcUserRole(claims.name)
+			if errors.Is(err, errOIDCUserRejected) {
+				s.log.Warn(err.Error(), "user", claims.name)
+				ctx.Set(authorizedKey, false)
+				return f(ctx, request)
+			}
+			if err != nil {
+				s.log.Error(err.Error())
+				return nil, err
+			}
+			//service accounts only authenticate with API keys
+			if claims.role == storage.RoleService {
+				ctx.Set(authorizedKey, false)
+				return f(ctx, request)
+			}
+		}
+		revoked, err := s.storage.TokenRevoked(claims.name, claims.id, claims.issuedAt)
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			s.log.Error(err.Error())
+			return nil, err
+		}
+		//tokens of deleted users are treated as revoked
+		if revoked || err != nil {
+			ctx.Set(authorizedKey, false)
+			return f(ctx, request)
+		}
 		ctx.Set(authorizedKey, true)
-		ctx.Set(usernameKey, name)
+		ctx.Set(usernameKey, claims.name)
+		ctx.Set(roleKey, claims.role)
+		ctx.Set(tokenIDKey, claims.id)
+		ctx.Set(tokenExpiresKey, claims.expiresAt)
 		return f(ctx, request)
 	}
 }
-func convertCoinHistory(coinHistory postgres.CoinHistory) *struct {
-	Received *[]struct {
-		Amount   *int    `json:"amount,omitempty"`
-		FromUser *string `json:"fromUser,omitempty"`
+
+// userRole returns the role to put into a new token of the user.
+// Users listed in ADMIN_USERS are promoted to admins here.
+func (s *APIServer) userRole(name string) (string, error) {
+	if s.adminUsers[name] {
+		if err := s.storage.SetUserRole(name, storage.RoleAdmin); err != nil {
+			return "", err
+		}
+		return storage.RoleAdmin, nil
+	}
+	return s.storage.UserRole(name)
+}
+func convertCoinHistory(coinHistory storage.CoinHistory) *struct {
+	Adjustments *[]CoinAdjustment `json:"adjustments,omitempty"`
+	Received    *[]struct {
+		Amount   *int       `json:"amount,omitempty"`
+		Date     *time.Time `json:"date,omitempty"`
+		FromUser *string    `json:"fromUser,omitempty"`
+		Id       *int       `json:"id,omitempty"`
 	} `json:"received,omitempty"`
-	Sent *[]struct {
-		Amount *int    `json:"amount,omitempty"`
-		ToUser *string `json:"toUser,omitempty"`
+	Refunds *[]CoinRefund `json:"refunds,omitempty"`
+	Sent    *[]struct {
+		Amount *int       `json:"amount,omitempty"`
+		Date   *time.Time `json:"date,omitempty"`
+		Id     *int       `json:"id,omitempty"`
+		ToUser *string    `json:"toUser,omitempty"`
 	} `json:"sent,omitempty"`
 } {
 	received := make([]struct {
-		Amount   *int    `json:"amount,omitempty"`
-		FromUser *string `json:"fromUser,omitempty"`
+		Amount   *int       `json:"amount,omitempty"`
+		Date     *time.Time `json:"date,omitempty"`
+		FromUser *string    `json:"fromUser,omitempty"`
+		Id       *int       `json:"id,omitempty"`
 	}, len(coinHistory.Received))
 
 	for i, transaction := range coinHistory.Received {
 		amount := transaction.Amount
+		date := transaction.CreatedAt
 		fromUser := transaction.FromUser
+		id := transaction.ID
 		received[i] = struct {
-			Amount   *int    `json:"amount,omitempty"`
-			FromUser *string `json:"fromUser,omitempty"`
+			Amount   *int       `json:"amount,omitempty"`
+			Date     *time.Time `json:"date,omitempty"`
+			FromUser *string    `json:"fromUser,omitempty"`
+			Id       *int       `json:"id,omitempty"`
 		}{
 			Amount:   &amount,
+			Date:     &date,
 			FromUser: &fromUser,
+			Id:       &id,
 		}
 	}
 
 	sent := make([]struct {
-		Amount *int    `json:"amount,omitempty"`
-		ToUser *string `json:"toUser,omitempty"`
+		Amount *int       `json:"amount,omitempty"`
+		Date   *time.Time `json:"date,omitempty"`
+		Id     *int       `json:"id,omitempty"`
+		ToUser *string    `json:"toUser,omitempty"`
 	}, len(coinHistory.Sent))
 
 	for i, transaction := range coinHistory.Sent {
 		amount := transaction.Amount
+		date := transaction.CreatedAt
+		id := transaction.ID
 		toUser := transaction.ToUser
 		sent[i] = struct {
-			Amount *int    `json:"amount,omitempty"`
-			ToUser *string `json:"toUser,omitempty"`
+			Amount *int       `json:"amount,omitempty"`
+			Date   *time.Time `json:"date,omitempty"`
+			Id     *int       `json:"id,omitempty"`
+			ToUser *string    `json:"toUser,omitempty"`
 		}{
 			Amount: &amount,
+			Date:   &date,
+			Id:     &id,
 			ToUser: &toUser,
 		}
 	}
 
+	adjustments := make([]CoinAdjustment, len(coinHistory.Adjustments))
+	for i, adjustment := range coinHistory.Adjustments {
+		amount := adjustment.Amount
+		date := adjustment.CreatedAt
+		id := adjustment.ID
+		reason := adjustment.Reason
+		kind := AdjustmentType(adjustment.Kind)
+		adjustments[i] = CoinAdjustment{
+			Amount: &amount,
+			Date:   &date,
+			Id:     &id,
+			Reason: &reason,
+			Type:   &kind,
+		}
+	}
+
+	refunds := make([]CoinRefund, len(coinHistory.Refunds))
+	for i, refund := range coinHistory.Refunds {
+		amount := refund.Amount
+		date := refund.CreatedAt
+		id := refund.ID
+		order := refund.OrderID
+		refunds[i] = CoinRefund{
+			Amount: &amount,
+			Date:   &date,
+			Id:     &id,
+			Order:  &order,
+		}
+	}
+
 	return &struct {
-		Received *[]struct {
-			Amount   *int    `json:"amount,omitempty"`
-			FromUser *string `json:"fromUser,omitempty"`
+		Adjustments *[]CoinAdjustment `json:"adjustments,omitempty"`
+		Received    *[]struct {
+			Amount   *int       `json:"amount,omitempty"`
+			Date     *time.Time `json:"date,omitempty"`
+			FromUser *string    `json:"fromUser,omitempty"`
+			Id       *int       `json:"id,omitempty"`
 		} `json:"received,omitempty"`
-		Sent *[]struct {
-			Amount *int    `json:"amount,omitempty"`
-			ToUser *string `json:"toUser,omitempty"`
+		Refunds *[]CoinRefund `json:"refunds,omitempty"`
+		Sent    *[]struct {
+			Amount *int       `json:"amount,omitempty"`
+			Date   *time.Time `json:"date,omitempty"`
+			Id     *int       `json:"id,omitempty"`
+			ToUser *string    `json:"toUser,omitempty"`
 		} `json:"sent,omitempty"`
 	}{
-		Received: &received,
-		Sent:     &sent,
+		Adjustments: &adjustments,
+		Received:    &received,
+		Refunds:     &refunds,
+		Sent:        &sent,
 	}
 }
+func convertPurchases(purchases []storage.Purchase) *[]struct {
+	Date     *time.Time `json:"date,omitempty"`
+	Item     *string    `json:"item,omitempty"`
+	Price    *int       `json:"price,omitempty"`
+	Quantity *int       `json:"quantity,omitempty"`
+} {
+	resp := make([]struct {
+		Date     *time.Time `json:"date,omitempty"`
+		Item     *string    `json:"item,omitempty"`
+		Price    *int       `json:"price,omitempty"`
+		Quantity *int       `json:"quantity,omitempty"`
+	}, len(purchases))
+
+	for i, purchase := range purchases {
+		date := purchase.CreatedAt
+		item := purchase.Item
+		price := purchase.Price
+		quantity := purchase.Quantity
+		resp[i] = struct {
+			Date     *time.Time `json:"date,omitempty"`
+			Item     *string    `json:"item,omitempty"`
+			Price    *int       `json:"price,omitempty"`
+			Quantity *int       `json:"quantity,omitempty"`
+		}{
+			Date:     &date,
+			Item:     &item,
+			Price:    &price,
+			Quantity: &quantity,
+		}
+	}
+	return &resp
+}
 
 func Run() {
 	cfg := config.MustLoad()
@@ -293,10 +749,24 @@ func Run() {
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	log.Info("starting service")
 
-	s := New(cfg)
-	r := gin.Default()
-	handler := NewStrictHandler(s, []StrictMiddlewareFunc{s.AuthMiddleware})
-	RegisterHandlers(r, handler)
+	s, err := New(cfg)
+	if err != nil {
+		log.Error("failed to create server", "error", err.Error())
+		os.Exit(1)
+	}
+	r := s.Router()
 
 	r.Run(":" + cfg.ServicePort)
 }
+
+// Router builds a gin engine with all API handlers registered.
+func (s *APIServer) Router() *gin.Engine {
+	r := gin.Default()
+	//addresses were validated in New
+	_ = r.SetTrustedProxies(s.trustedProxies)
+	//the last middleware is the outermost one, so AuthMiddleware runs first
+	//and IdempotencyMiddleware only records requests that passed PolicyMiddleware
+	handler := NewStrictHandler(s, []StrictMiddlewareFunc{s.IdempotencyMiddleware, s.PolicyMiddleware, s.AuthMiddleware})
+	RegisterHandlers(r, handler)
+	return r
+}
@@ -4,11 +4,39 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
-const baseURL = "http://localhost:8080"
+var baseURL string
+
+// TestMain runs the whole API in-process on top of the in-memory storage.
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	api, err := New(&config.Config{StorageType: "memory", AdminUsers: []string{"merchadmin"}})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	srv := httptest.NewServer(api.Router())
+	baseURL = srv.URL
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
 
 func TestBuyMerch(t *testing.T) {
 	//Auth, token
@@ -86,6 +114,17 @@ func TestSendCoins(t *testing.T) {
 	if resp.StatusCode != http.StatusOK {
 		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
 	}
+
+	for _, amount := range []int{0, -10} {
+		resp, err := doRequest("POST", "/api/sendCoin", *authResponse.Token, SendCoinRequest{ToUser: "reciever", Amount: amount})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Amount %d: expected status 400 Bad Request, got %v", amount, resp.Status)
+		}
+	}
 }
 func TestGetInfo(t *testing.T) {
 	//Auth, token
@@ -129,27 +168,1829 @@ func TestGetInfo(t *testing.T) {
 	if infoResponse.CoinHistory == nil {
 		t.Error("Expected coin history to be present in the response")
 	}
+	if infoResponse.Purchases == nil {
+		t.Error("Expected purchases to be present in the response")
+	}
 }
-func authenticate(authRequest AuthRequest) (*AuthResponse, error) {
-	body, err := json.Marshal(authRequest)
+func TestGetHistory(t *testing.T) {
+	sender, err := authenticate(AuthRequest{Username: "historysender", Password: "pass"})
 	if err != nil {
-		return nil, err
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	if _, err := authenticate(AuthRequest{Username: "historyreceiver", Password: "pass"}); err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := doRequest("POST", "/api/sendCoin", *sender.Token, SendCoinRequest{ToUser: "historyreceiver", Amount: 1})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
 	}
 
-	resp, err := http.Post(baseURL+"/api/auth", "application/json", bytes.NewBuffer(body))
+	//first page
+	var page HistoryResponse
+	resp, err := doRequest("GET", "/api/history?limit=2", *sender.Token, nil)
 	if err != nil {
-		return nil, err
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if page.Items == nil || len(*page.Items) != 2 {
+		t.Fatalf("Expected 2 items on the first page, got %v", page.Items)
+	}
+	if page.NextCursor == nil {
+		t.Fatal("Expected next cursor on the first page")
+	}
+
+	//second page
+	resp, err = doRequest("GET", "/api/history?limit=2&cursor="+*page.NextCursor, *sender.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	page = HistoryResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if page.Items == nil || len(*page.Items) != 1 {
+		t.Fatalf("Expected 1 item on the second page, got %v", page.Items)
+	}
+	if page.NextCursor != nil {
+		t.Errorf("Expected no next cursor on the last page, got %v", *page.NextCursor)
+	}
+	entry := (*page.Items)[0]
+	if *entry.Direction != Sent || *entry.Counterparty != "historyreceiver" || *entry.Amount != 1 {
+		t.Errorf("Unexpected history entry: %+v", entry)
+	}
+
+	//filters
+	resp, err = doRequest("GET", "/api/history?direction=received", *sender.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	page = HistoryResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if page.Items == nil || len(*page.Items) != 0 {
+		t.Errorf("Expected no received transfers, got %v", page.Items)
+	}
+
+	//bad requests
+	for _, query := range []string{"limit=0", "limit=101", "cursor=bogus", "direction=sideways"} {
+		resp, err = doRequest("GET", "/api/history?"+query, *sender.Token, nil)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %v", query, resp.Status)
+		}
+	}
+}
+
+func TestGetMerch(t *testing.T) {
+	auth, err := authenticate(AuthRequest{Username: "merchviewer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	//catalog
+	var list MerchListResponse
+	resp, err := doRequest("GET", "/api/merch", *auth.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if list.Items == nil || len(*list.Items) == 0 {
+		t.Fatal("Expected non-empty merch catalog")
+	}
+
+	//single item
+	var item MerchItem
+	resp, err = doRequest("GET", "/api/merch/cup", *auth.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if *item.Name != "cup" || *item.Price != 20 || !*item.Available {
+		t.Errorf("Unexpected merch item: %+v", item)
+	}
+
+	//unknown item
+	resp, err = doRequest("GET", "/api/merch/nonexistingitem", *auth.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+
+	//unauthorized
+	resp, err = doRequest("GET", "/api/merch", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 Unauthorized, got %v", resp.Status)
+	}
+}
+
+func TestAdminMerch(t *testing.T) {
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	buyer, err := authenticate(AuthRequest{Username: "merchbuyer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	//regular users can't manage the catalog
+	resp, err := doRequest("POST", "/api/admin/merch", *buyer.Token, MerchCreateRequest{Name: "sticker", Price: 5})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+
+	//create
+	resp, err = doRequest("POST", "/api/admin/merch", *admin.Token, MerchCreateRequest{Name: "sticker", Price: 5})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 Created, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/merch", *admin.Token, MerchCreateRequest{Name: "sticker", Price: 5})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", resp.Status)
+	}
+	resp, err = doRequest("GET", "/api/buy/sticker", *buyer.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+
+	//reprice and rename
+	newName, newPrice := "big-sticker", 7
+	var item MerchItem
+	resp, err = doRequest("PATCH", "/api/admin/merch/sticker", *admin.Token, MerchUpdateRequest{Name: &newName, Price: &newPrice})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if *item.Name != newName || *item.Price != newPrice {
+		t.Errorf("Unexpected merch item: %+v", item)
+	}
+	badPrice := 0
+	resp, err = doRequest("PATCH", "/api/admin/merch/"+newName, *admin.Token, MerchUpdateRequest{Price: &badPrice})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+
+	//retire
+	resp, err = doRequest("DELETE", "/api/admin/merch/"+newName, *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+	resp, err = doRequest("DELETE", "/api/admin/merch/"+newName, *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+	resp, err = doRequest("GET", "/api/buy/"+newName, *buyer.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+
+	//the retired item stays in the inventory
+	var info InfoResponse
+	resp, err = doRequest("GET", "/api/info", *buyer.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if info.Inventory == nil || len(*info.Inventory) != 1 || *(*info.Inventory)[0].Type != newName {
+		t.Errorf("Expected %s in the inventory, got %v", newName, info.Inventory)
+	}
+}
+
+func TestAdminRoles(t *testing.T) {
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	user, err := authenticate(AuthRequest{Username: "promoteduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	//regular users can't assign roles
+	resp, err := doRequest("PUT", "/api/admin/users/promoteduser/role", *user.Token, RoleRequest{Role: Admin})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("PUT", "/api/admin/users/nonexistinguser/role", *admin.Token, RoleRequest{Role: Admin})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+	resp, err = doRequest("PUT", "/api/admin/users/promoteduser/role", *admin.Token, RoleRequest{Role: "owner"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("PUT", "/api/admin/users/promoteduser/role", *admin.Token, RoleRequest{Role: Admin})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+
+	//the new role is in the next token
+	user, err = authenticate(AuthRequest{Username: "promoteduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	resp, err = doRequest("POST", "/api/admin/merch", *user.Token, MerchCreateRequest{Name: "badge", Price: 15})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201 Created, got %v", resp.Status)
+	}
+}
+
+func TestAdminCoins(t *testing.T) {
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	user, err := authenticate(AuthRequest{Username: "bonususer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	resp, err := doRequest("POST", "/api/admin/coins/grant", *user.Token, GrantRequest{Users: []string{"bonususer"}, Amount: 100, Reason: "self-service"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("POST", "/api/admin/coins/grant", *admin.Token, GrantRequest{Users: []string{"bonususer"}, Amount: 100, Reason: "quarterly bonus"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/coins/clawback", *admin.Token, ClawbackRequest{User: "bonususer", Amount: 30, Reason: "returned hoody"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+
+	//bad requests
+	for _, body := range []any{
+		GrantRequest{Users: []string{"bonususer"}, Amount: 100},
+		GrantRequest{Users: []string{"bonususer"}, Amount: -1, Reason: "bonus"},
+		GrantRequest{Users: []string{"bonususer", "bonususer"}, Amount: 1, Reason: "bonus"},
+		GrantRequest{Users: []string{}, Amount: 1, Reason: "bonus"},
+		GrantRequest{Users: []string{"bonususer"}, Amount: math.MaxInt32 + 1, Reason: "bonus"},
+	} {
+		resp, err = doRequest("POST", "/api/admin/coins/grant", *admin.Token, body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %+v, got %v", body, resp.Status)
+		}
+	}
+	//amounts are stored in INT columns
+	resp, err = doRequest("POST", "/api/admin/coins/clawback", *admin.Token, ClawbackRequest{User: "bonususer", Amount: math.MaxInt32 + 1, Reason: "overflow"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/coins/grant", *admin.Token, GrantRequest{Users: []string{"bonususer", "nonexistinguser"}, Amount: 100, Reason: "bonus"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+
+	var info InfoResponse
+	resp, err = doRequest("GET", "/api/info", *user.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if *info.Coins != 1000+100-30 {
+		t.Errorf("Expected 1070 coins, got %d", *info.Coins)
+	}
+	adjustments := *info.CoinHistory.Adjustments
+	if len(adjustments) != 2 || *adjustments[0].Type != Clawback || *adjustments[1].Type != Grant || *adjustments[1].Reason != "quarterly bonus" {
+		t.Errorf("Unexpected adjustments: %+v", adjustments)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	resp, err := http.Get(baseURL + "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	var jwks JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	//the test server signs with a generated HMAC secret, which must not be published
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("Expected an empty key list, got %+v", jwks.Keys)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	auth, err := authenticate(AuthRequest{Username: "refreshuser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	if auth.RefreshToken == nil || auth.ExpiresIn == nil || *auth.ExpiresIn != int(defaultAccessTTL.Seconds()) {
+		t.Fatalf("Expected a refresh token and the access token lifetime, got %+v", auth)
+	}
 
+	refreshed, err := refresh(*auth.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if *refreshed.RefreshToken == *auth.RefreshToken {
+		t.Fatalf("Expected the refresh token to be rotated")
+	}
+	resp, err := doRequest("GET", "/api/info", *refreshed.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("authentication failed with status: %v", resp.Status)
+		t.Errorf("Expected status 200 OK, got %v", resp.Status)
 	}
 
-	var authResponse AuthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&authResponse); err != nil {
-		return nil, err
+	//reusing the old token revokes the rotated one as well
+	for _, token := range []string{*auth.RefreshToken, *refreshed.RefreshToken} {
+		resp, err = doRequest("POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: token})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 Unauthorized, got %v", resp.Status)
+		}
 	}
 
-	return &authResponse, nil
+	resp, err = doRequest("POST", "/api/auth/refresh", "", RefreshRequest{})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	auth, err := authenticate(AuthRequest{Username: "logoutuser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	other, err := authenticate(AuthRequest{Username: "logoutuser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	resp, err := doRequest("POST", "/api/auth/logout", *auth.Token, LogoutRequest{RefreshToken: auth.RefreshToken})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("GET", "/api/info", *auth.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 Unauthorized, got %v", resp.Status)
+	}
+	if _, err := refresh(*auth.RefreshToken); err == nil {
+		t.Errorf("Expected the refresh token to be revoked")
+	}
+
+	//other sessions of the user are not affected
+	resp, err = doRequest("GET", "/api/info", *other.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 OK, got %v", resp.Status)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	user, err := authenticate(AuthRequest{Username: "offboardeduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	resp, err := doRequest("POST", "/api/admin/users/offboardeduser/revoke-sessions", *user.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/users/nonexistinguser/revoke-sessions", *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("POST", "/api/admin/users/offboardeduser/revoke-sessions", *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+
+	resp, err = doRequest("GET", "/api/info", *user.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 Unauthorized, got %v", resp.Status)
+	}
+	if _, err := refresh(*user.RefreshToken); err == nil {
+		t.Errorf("Expected the refresh token to be revoked")
+	}
+
+	//signing in again starts a new session
+	user, err = authenticate(AuthRequest{Username: "offboardeduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	resp, err = doRequest("GET", "/api/info", *user.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 OK, got %v", resp.Status)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	resp, err := doRequest("POST", "/api/auth/register", "", AuthRequest{Username: "newuser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 Created, got %v", resp.Status)
+	}
+	var authResp AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if authResp.Token == nil || authResp.Created == nil || !*authResp.Created {
+		t.Errorf("Expected a token for a created user, got %+v", authResp)
+	}
+
+	resp, err = doRequest("POST", "/api/auth/register", "", AuthRequest{Username: "newuser", Password: "other"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", resp.Status)
+	}
+
+	for _, name := range []string{"ab", "new user", ".newuser", "ежик"} {
+		resp, err = doRequest("POST", "/api/auth/register", "", AuthRequest{Username: name, Password: "pass"})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 Bad Request for %q, got %v", name, resp.Status)
+		}
+	}
+
+	//login tells existing users apart from created ones
+	auth, err := authenticate(AuthRequest{Username: "newuser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	if auth.Created == nil || *auth.Created {
+		t.Errorf("Expected created to be false on login, got %v", auth.Created)
+	}
+	auth, err = authenticate(AuthRequest{Username: "autocreateduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	if auth.Created == nil || !*auth.Created {
+		t.Errorf("Expected created to be true on auto signup, got %v", auth.Created)
+	}
+}
+
+func TestUnknownStorageType(t *testing.T) {
+	if _, err := New(&config.Config{StorageType: "mysql"}); err == nil {
+		t.Errorf("Expected an error for an unknown storage type")
+	}
+}
+
+func TestPostgresUnavailable(t *testing.T) {
+	cfg := &config.Config{StorageType: "postgres", DBHost: "127.0.0.1", DBPort: "1", DBUser: "user", DBName: "shop"}
+	if _, err := New(cfg); err == nil {
+		t.Errorf("Expected an error when postgres is unavailable")
+	}
+}
+
+func TestSignupModes(t *testing.T) {
+	if _, err := New(&config.Config{StorageType: "memory", SignupMode: "open"}); err == nil {
+		t.Errorf("Expected an error for an unknown signup mode")
+	}
+
+	tests := []struct {
+		mode         string
+		authStatus   int
+		registerCode int
+	}{
+		{config.SignupRegister, http.StatusUnauthorized, http.StatusCreated},
+		{config.SignupDisabled, http.StatusUnauthorized, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			api, err := New(&config.Config{StorageType: "memory", SignupMode: tt.mode})
+			if err != nil {
+				t.Fatalf("Failed to create server: %v", err)
+			}
+			srv := httptest.NewServer(api.Router())
+			defer srv.Close()
+
+			body, _ := json.Marshal(AuthRequest{Username: "typoeduser", Password: "pass"})
+			resp, err := http.Post(srv.URL+"/api/auth", "application/json", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.authStatus {
+				t.Errorf("Expected status %d from /api/auth, got %v", tt.authStatus, resp.Status)
+			}
+			resp, err = http.Post(srv.URL+"/api/auth/register", "application/json", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.registerCode {
+				t.Errorf("Expected status %d from /api/auth/register, got %v", tt.registerCode, resp.Status)
+			}
+		})
+	}
+}
+
+func TestLockout(t *testing.T) {
+	api, err := New(&config.Config{
+		StorageType: "memory",
+		AdminUsers:  []string{"lockadmin"},
+		Lockout:     config.LockoutConfig{MaxFailures: 3, MaxIPFailures: 100, LockDuration: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+	login := func(name, pass string) *http.Response {
+		t.Helper()
+		body, _ := json.Marshal(AuthRequest{Username: name, Password: pass})
+		resp, err := http.Post(srv.URL+"/api/auth", "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	login("lockeduser", "pass")
+	for i := 0; i < 3; i++ {
+		if resp := login("lockeduser", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 Unauthorized, got %v", resp.Status)
+		}
+	}
+	//the right password doesn't help while the account is locked
+	resp := login("lockeduser", "pass")
+	if resp.StatusCode != http.StatusLocked {
+		t.Fatalf("Expected status 423 Locked, got %v", resp.Status)
+	}
+	if retry, _ := strconv.Atoi(resp.Header.Get("Retry-After")); retry < 1 || retry > 60 {
+		t.Errorf("Expected Retry-After within the lock duration, got %q", resp.Header.Get("Retry-After"))
+	}
+
+	body, _ := json.Marshal(AuthRequest{Username: "lockadmin", Password: "pass"})
+	adminResp, err := http.Post(srv.URL+"/api/auth", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	var admin AuthResponse
+	if err := json.NewDecoder(adminResp.Body).Decode(&admin); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	adminResp.Body.Close()
+	for path, status := range map[string]int{
+		"/api/admin/users/nonexistinguser/lockout": http.StatusNotFound,
+		"/api/admin/users/lockeduser/lockout":      http.StatusNoContent,
+	} {
+		req, _ := http.NewRequest("DELETE", srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+*admin.Token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Expected status %d for %s, got %v", status, path, resp.Status)
+		}
+	}
+	if resp := login("lockeduser", "pass"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 OK after unlock, got %v", resp.Status)
+	}
+}
+
+func TestLockoutUnknownUser(t *testing.T) {
+	api, err := New(&config.Config{
+		StorageType: "memory",
+		SignupMode:  config.SignupRegister,
+		Lockout:     config.LockoutConfig{MaxFailures: 3, MaxIPFailures: 100, LockDuration: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+	post := func(path, name, pass string) int {
+		t.Helper()
+		body, _ := json.Marshal(AuthRequest{Username: name, Password: pass})
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post("/api/auth/register", "realuser", "pass"); status != http.StatusCreated && status != http.StatusOK {
+		t.Fatalf("Failed to register: %d", status)
+	}
+
+	//locks must not tell existing accounts from unknown names
+	for _, name := range []string{"realuser", "ghostuser"} {
+		for i := 0; i < 3; i++ {
+			if status := post("/api/auth", name, "wrong"); status != http.StatusUnauthorized {
+				t.Fatalf("%s: expected status 401 Unauthorized, got %d", name, status)
+			}
+		}
+		if status := post("/api/auth", name, "wrong"); status != http.StatusLocked {
+			t.Errorf("%s: expected status 423 Locked, got %d", name, status)
+		}
+	}
+}
+
+func TestIPLockout(t *testing.T) {
+	api, err := New(&config.Config{
+		StorageType: "memory",
+		SignupMode:  config.SignupRegister,
+		Lockout:     config.LockoutConfig{MaxFailures: 100, MaxIPFailures: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+
+	statuses := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range statuses {
+		body, _ := json.Marshal(AuthRequest{Username: fmt.Sprintf("guess%d", i), Password: "pass"})
+		req, _ := http.NewRequest("POST", srv.URL+"/api/auth", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		//no proxy is trusted, so the header can't be used to get a fresh address
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.0.%d", i))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Expected status %d, got %v", status, resp.Status)
+		}
+	}
+}
+
+func TestLockFor(t *testing.T) {
+	p := newLockoutPolicy(config.LockoutConfig{MaxFailures: 3, LockDuration: time.Minute, MaxLockDuration: 5 * time.Minute})
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 5 * time.Minute},
+		{60, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.userLockout().LockFor(tt.failures); got != tt.want {
+			t.Errorf("LockFor(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	auth, err := authenticate(AuthRequest{Username: "passworduser", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		req    PasswordChangeRequest
+		status int
+	}{
+		{"wrong old password", PasswordChangeRequest{OldPassword: "wrong", NewPassword: "newpass"}, http.StatusForbidden},
+		{"empty new password", PasswordChangeRequest{OldPassword: "pass", NewPassword: ""}, http.StatusBadRequest},
+		{"same password", PasswordChangeRequest{OldPassword: "pass", NewPassword: "pass"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := doRequest("POST", "/api/auth/password", *auth.Token, tt.req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %v", tt.name, tt.status, resp.Status)
+		}
+	}
+
+	resp, err := doRequest("POST", "/api/auth/password", *auth.Token, PasswordChangeRequest{OldPassword: "pass", NewPassword: "newpass"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	var changed AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&changed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	//sessions started with the old password are ended
+	for token, status := range map[string]int{*auth.Token: http.StatusUnauthorized, *changed.Token: http.StatusOK} {
+		resp, err := doRequest("GET", "/api/info", token, nil)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Expected status %d, got %v", status, resp.Status)
+		}
+	}
+	if _, err := authenticate(AuthRequest{Username: "passworduser", Password: "pass"}); err == nil {
+		t.Errorf("Expected the old password to be rejected")
+	}
+	if _, err := authenticate(AuthRequest{Username: "passworduser", Password: "newpass"}); err != nil {
+		t.Errorf("Authentication with the new password failed: %v", err)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	p, err := newPasswordPolicy(config.PasswordConfig{MinLength: 8, MinClasses: 2})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+	tests := []struct {
+		pass string
+		ok   bool
+	}{
+		{"", false},
+		{"short1", false},
+		{"lowercaseonly", false},
+		{"ёжиквтумане", false},
+		{"lowercase1", true},
+		{"ЁжикВТумане", true},
+		{"pass phrase", true},
+		{strings.Repeat("a1", 37), false},
+	}
+	for _, tt := range tests {
+		if got := p.violation(tt.pass) == ""; got != tt.ok {
+			t.Errorf("violation(%q) = %q", tt.pass, p.violation(tt.pass))
+		}
+	}
+
+	//unknown users are checked against a dummy hash of the same cost
+	if cost, err := bcrypt.Cost(p.dummyHash); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("Expected a dummy hash of cost %d, got %d %v", bcrypt.DefaultCost, cost, err)
+	}
+	if _, err := newPasswordPolicy(config.PasswordConfig{BcryptCost: 64}); err == nil {
+		t.Errorf("Expected an error for an invalid bcrypt cost")
+	}
+}
+
+func TestUpgradeHash(t *testing.T) {
+	st := memory.New()
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err := st.AddUser("rehasheduser", string(oldHash)); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	s := &APIServer{storage: st, log: slog.Default(), passwords: passwordPolicy{cost: bcrypt.MinCost + 1}}
+
+	s.upgradeHash("rehasheduser", string(oldHash), "pass")
+	newHash, _ := st.UserPassHash("rehasheduser")
+	if cost, _ := bcrypt.Cost([]byte(newHash)); cost != bcrypt.MinCost+1 {
+		t.Errorf("Expected the hash to be upgraded to cost %d, got %d", bcrypt.MinCost+1, cost)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(newHash), []byte("pass")); err != nil {
+		t.Errorf("Upgraded hash doesn't match the password: %v", err)
+	}
+}
+
+// doRequest sends an authorized request, body is encoded as JSON if not nil.
+func TestServiceAccounts(t *testing.T) {
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	user, err := authenticate(AuthRequest{Username: "hrgrantee", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+
+	resp, err := doRequest("POST", "/api/admin/service-accounts", *user.Token, ServiceAccountRequest{Name: "hr-sync"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/service-accounts", *admin.Token, ServiceAccountRequest{Name: "hr-sync"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 Created, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/service-accounts", *admin.Token, ServiceAccountRequest{Name: "hrgrantee"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", resp.Status)
+	}
+	//service accounts have no password to sign in with
+	if _, err := authenticate(AuthRequest{Username: "hr-sync", Password: "pass"}); err == nil {
+		t.Errorf("Expected service account login to fail")
+	}
+
+	resp, err = doRequest("POST", "/api/admin/service-accounts/hrgrantee/keys", *admin.Token, ApiKeyCreateRequest{Scopes: []ApiKeyScope{CoinsGrant}})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/service-accounts/hr-sync/keys", *admin.Token, ApiKeyCreateRequest{Scopes: []ApiKeyScope{"coins:burn"}})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+	}
+	resp, err = doRequest("POST", "/api/admin/service-accounts/hr-sync/keys", *admin.Token, ApiKeyCreateRequest{Scopes: []ApiKeyScope{CoinsGrant, BalancesRead}})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 Created, got %v", resp.Status)
+	}
+	var created ApiKeyCreatedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	key := *created.Key
+	if !strings.HasPrefix(key, *created.Prefix) {
+		t.Errorf("Expected key %q to start with prefix %q", key, *created.Prefix)
+	}
+
+	// Сценарий 1: Ключ с правом coins:grant начисляет монеты и читает баланс
+	resp, err = doKeyRequest("POST", "/api/admin/coins/grant", key, GrantRequest{Users: []string{"hrgrantee"}, Amount: 50, Reason: "referral bonus"})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+	resp, err = doKeyRequest("GET", "/api/admin/users/hrgrantee/balance", key, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+	}
+	var balance BalanceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&balance); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if *balance.Coins != 1050 {
+		t.Errorf("Expected balance 1050, got %v", *balance.Coins)
+	}
+
+	// Сценарий 2: Операции вне прав ключа запрещены
+	for _, path := range []string{"/api/admin/coins/clawback", "/api/admin/service-accounts"} {
+		resp, err = doKeyRequest("POST", path, key, ClawbackRequest{User: "hrgrantee", Amount: 10, Reason: "mistake"})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected status 403 Forbidden, got %v", path, resp.Status)
+		}
+	}
+	resp, err = doKeyRequest("GET", "/api/info", key, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 Forbidden, got %v", resp.Status)
+	}
+
+	// Сценарий 3: Отозванный ключ больше не принимается
+	resp, err = doRequest("GET", "/api/admin/service-accounts/hr-sync/keys", *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	var list ApiKeyListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if len(*list.Keys) != 1 || (*list.Keys)[0].LastUsedAt == nil || (*list.Keys)[0].RevokedAt != nil {
+		t.Fatalf("Expected one used active key, got %+v", *list.Keys)
+	}
+	resp, err = doRequest("DELETE", fmt.Sprintf("/api/admin/service-accounts/hr-sync/keys/%d", *created.Id), *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204 No Content, got %v", resp.Status)
+	}
+	resp, err = doRequest("DELETE", fmt.Sprintf("/api/admin/service-accounts/hr-sync/keys/%d", *created.Id), *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", resp.Status)
+	}
+	resp, err = doKeyRequest("GET", "/api/admin/users/hrgrantee/balance", key, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 Unauthorized, got %v", resp.Status)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	sender, err := authenticate(AuthRequest{Username: "idemsender", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	if _, err := authenticate(AuthRequest{Username: "idemreceiver", Password: "pass"}); err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	coins := func() int {
+		resp, err := doRequest("GET", "/api/info", *sender.Token, nil)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		var info InfoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return *info.Coins
+	}
+	send := func(key string, amount int) *http.Response {
+		resp, err := doIdempotentRequest("POST", "/api/sendCoin", *sender.Token, key, SendCoinRequest{ToUser: "idemreceiver", Amount: amount})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	//a retry with the same key is not executed again
+	for i := 0; i < 2; i++ {
+		resp := send("transfer-1", 100)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
+		if replayed := resp.Header.Get("Idempotent-Replayed") == "true"; replayed != (i == 1) {
+			t.Errorf("Attempt %d: expected replayed %v", i+1, i == 1)
+		}
+	}
+	if got := coins(); got != 900 {
+		t.Errorf("Expected 900 coins after a retried transfer, got %v", got)
+	}
+	if resp := send("transfer-1", 200); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 Unprocessable Entity, got %v", resp.Status)
+	}
+
+	//errors are recorded as well
+	for i := 0; i < 2; i++ {
+		if resp := send("transfer-2", 100000); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 Bad Request, got %v", resp.Status)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := doIdempotentRequest("GET", "/api/buy/pen", *sender.Token, "purchase-1", nil)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
+	}
+	if got := coins(); got != 890 {
+		t.Errorf("Expected 890 coins after a retried purchase, got %v", got)
+	}
+	//a missing quantity is the same request as a single item
+	one := 1
+	for i, body := range []BuyRequest{{Item: "pen"}, {Item: "pen", Quantity: &one}} {
+		resp, err := doIdempotentRequest("POST", "/api/buy", *sender.Token, "purchase-2", body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
+		if replayed := resp.Header.Get("Idempotent-Replayed") == "true"; replayed != (i == 1) {
+			t.Errorf("Attempt %d: expected replayed %v", i+1, i == 1)
+		}
+	}
+	if got := coins(); got != 880 {
+		t.Errorf("Expected 880 coins after a retried purchase, got %v", got)
+	}
+	//keys are not shared between operations
+	resp, err := doIdempotentRequest("GET", "/api/buy/pen", *sender.Token, "transfer-1", nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 Unprocessable Entity, got %v", resp.Status)
+	}
+
+	//requests without a key are executed every time
+	for i := 0; i < 2; i++ {
+		if resp := send("", 10); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
+	}
+	if got := coins(); got != 860 {
+		t.Errorf("Expected 860 coins, got %v", got)
+	}
+}
+
+func TestIdempotencyOutcomeUnknown(t *testing.T) {
+	api, err := New(&config.Config{StorageType: "memory", IdempotencyPendingTimeout: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+	login := func(name string) string {
+		body, _ := json.Marshal(AuthRequest{Username: name, Password: "pass"})
+		resp, err := http.Post(srv.URL+"/api/auth", "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		var auth AuthResponse
+		if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return *auth.Token
+	}
+	token := login("crashsender")
+	login("crashreceiver")
+
+	//the transfer was committed, but the server stopped before recording the response
+	transfer := SendCoinRequest{ToUser: "crashreceiver", Amount: 100}
+	fingerprint, err := requestFingerprint("PostApiSendCoin", transfer)
+	if err != nil {
+		t.Fatalf("Failed to fingerprint request: %v", err)
+	}
+	if _, err := api.storage.ReserveIdempotencyKey("crashsender", "crash-1", fingerprint, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to reserve key: %v", err)
+	}
+	if err := api.storage.SendCoins("crashsender", "crashreceiver", transfer.Amount); err != nil {
+		t.Fatalf("Failed to send coins: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	body, _ := json.Marshal(transfer)
+	req, _ := http.NewRequest("POST", srv.URL+"/api/sendCoin", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "crash-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", resp.Status)
+	}
+	info, err := api.storage.UserInfo("crashsender")
+	if err != nil {
+		t.Fatalf("Failed to get user info: %v", err)
+	}
+	if info.Coins != 900 {
+		t.Errorf("Expected the transfer to be made once, got %d coins", info.Coins)
+	}
+}
+
+func TestBuyQuantity(t *testing.T) {
+	buyer, err := authenticate(AuthRequest{Username: "bulkbuyer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	info := func() InfoResponse {
+		resp, err := doRequest("GET", "/api/info", *buyer.Token, nil)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		var info InfoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return info
+	}
+	buy := func(method, path string, body any) int {
+		resp, err := doRequest(method, path, *buyer.Token, body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	//several items are bought at once at the current price
+	if status := buy("GET", "/api/buy/pen?quantity=5", nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	quantity := 3
+	if status := buy("POST", "/api/buy", BuyRequest{Item: "pen", Quantity: &quantity}); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if status := buy("POST", "/api/buy", BuyRequest{Item: "pen"}); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	got := info()
+	if *got.Coins != 910 {
+		t.Errorf("Expected 910 coins, got %v", *got.Coins)
+	}
+	if len(*got.Inventory) != 1 || *(*got.Inventory)[0].Quantity != 9 {
+		t.Errorf("Expected 9 pens in the inventory, got %+v", *got.Inventory)
+	}
+	//a single record for every purchase, newest first
+	purchases := *got.Purchases
+	if len(purchases) != 3 || *purchases[0].Quantity != 1 || *purchases[1].Quantity != 3 || *purchases[2].Quantity != 5 || *purchases[2].Price != 10 {
+		t.Errorf("Unexpected purchases: %+v", purchases)
+	}
+
+	//the quantity is limited, a purchase the balance is not enough for is not made at all
+	for _, path := range []string{"/api/buy/pen?quantity=0", "/api/buy/pen?quantity=101", "/api/buy/pen?quantity=-1", "/api/buy/pen?quantity=many", "/api/buy/pink-hoody?quantity=2"} {
+		if status := buy("GET", path, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 Bad Request, got %v", path, status)
+		}
+	}
+	quantity = 0
+	if status := buy("POST", "/api/buy", BuyRequest{Item: "pen", Quantity: &quantity}); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", status)
+	}
+	if status := buy("POST", "/api/buy", BuyRequest{Item: "no-such-item"}); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 Bad Request, got %v", status)
+	}
+	if got := info(); *got.Coins != 910 || len(*got.Purchases) != 3 {
+		t.Errorf("Expected no purchases to be made, got %v coins and %d purchases", *got.Coins, len(*got.Purchases))
+	}
+
+	//retries of a purchase with an idempotency key are not executed again
+	quantity = 2
+	for i := 0; i < 2; i++ {
+		resp, err := doIdempotentRequest("POST", "/api/buy", *buyer.Token, "bulk-1", BuyRequest{Item: "cup", Quantity: &quantity})
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", resp.Status)
+		}
+	}
+	if got := info(); *got.Coins != 870 {
+		t.Errorf("Expected 870 coins after a retried purchase, got %v", *got.Coins)
+	}
+}
+
+func TestCart(t *testing.T) {
+	buyer, err := authenticate(AuthRequest{Username: "cartbuyer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	cartRequest := func(method, path string, body, out any) int {
+		resp, err := doRequest(method, path, *buyer.Token, body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode != http.StatusInternalServerError {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	//empty cart
+	var cart Cart
+	if status := cartRequest("GET", "/api/cart", nil, &cart); status != http.StatusOK || len(*cart.Items) != 0 {
+		t.Fatalf("Expected an empty cart, got %v %+v", status, cart)
+	}
+	var checkoutErr CheckoutErrorResponse
+	if status := cartRequest("POST", "/api/cart/checkout", nil, &checkoutErr); status != http.StatusBadRequest || *checkoutErr.Errors != emptyCartErrMsg {
+		t.Errorf("Expected status 400 Bad Request for an empty cart, got %v", status)
+	}
+
+	//add, change and remove items
+	for _, line := range []struct {
+		item     string
+		quantity int
+	}{{"hoody", 2}, {"pen", 1}, {"socks", 4}, {"pen", 5}} {
+		if status := cartRequest("PUT", "/api/cart/"+line.item, CartItemRequest{Quantity: line.quantity}, &cart); status != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", status)
+		}
+	}
+	if status := cartRequest("DELETE", "/api/cart/socks", nil, &cart); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if status := cartRequest("DELETE", "/api/cart/socks", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", status)
+	}
+	for path, quantity := range map[string]int{"/api/cart/pen": 0, "/api/cart/cup": 101, "/api/cart/no-such-item": 1} {
+		if status := cartRequest("PUT", path, CartItemRequest{Quantity: quantity}, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 Bad Request, got %v", path, status)
+		}
+	}
+	if status := cartRequest("GET", "/api/cart", nil, &cart); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	items := *cart.Items
+	if len(items) != 2 || *items[0].Item != "hoody" || *items[0].Cost != 600 || *items[1].Item != "pen" || *items[1].Quantity != 5 || *cart.Total != 650 {
+		t.Fatalf("Unexpected cart: %+v", items)
+	}
+
+	//a cart the balance is not enough for is not bought at all
+	if status := cartRequest("PUT", "/api/cart/hoody", CartItemRequest{Quantity: 4}, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	checkoutErr = CheckoutErrorResponse{}
+	if status := cartRequest("POST", "/api/cart/checkout", nil, &checkoutErr); status != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 Bad Request, got %v", status)
+	}
+	if *checkoutErr.Errors != insufficientBalanceErrMsg || *checkoutErr.Total != 1250 || *checkoutErr.Coins != 1000 || len(*checkoutErr.Lines) != 0 {
+		t.Errorf("Unexpected checkout error: %+v", checkoutErr)
+	}
+
+	//items that cannot be bought are listed
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	resp, err := doRequest("POST", "/api/admin/merch", *admin.Token, MerchCreateRequest{Name: "cart-sticker", Price: 5})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if status := cartRequest("PUT", "/api/cart/cart-sticker", CartItemRequest{Quantity: 1}, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	resp, err = doRequest("DELETE", "/api/admin/merch/cart-sticker", *admin.Token, nil)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if status := cartRequest("PUT", "/api/cart/hoody", CartItemRequest{Quantity: 2}, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	checkoutErr = CheckoutErrorResponse{}
+	if status := cartRequest("POST", "/api/cart/checkout", nil, &checkoutErr); status != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 Bad Request, got %v", status)
+	}
+	if lines := *checkoutErr.Lines; len(lines) != 1 || *lines[0].Item != "cart-sticker" || *lines[0].Error != noSuchItemErrMsg {
+		t.Errorf("Unexpected checkout error: %+v", lines)
+	}
+	if status := cartRequest("DELETE", "/api/cart/cart-sticker", nil, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+
+	//checkout
+	if status := cartRequest("POST", "/api/cart/checkout", nil, &cart); status != http.StatusOK || *cart.Total != 650 {
+		t.Fatalf("Expected status 200 OK, got %v %+v", status, cart)
+	}
+	var info InfoResponse
+	if status := cartRequest("GET", "/api/info", nil, &info); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if *info.Coins != 350 || len(*info.Inventory) != 2 || len(*info.Purchases) != 2 {
+		t.Errorf("Unexpected info after checkout: %v coins, %+v", *info.Coins, *info.Inventory)
+	}
+	if status := cartRequest("GET", "/api/cart", nil, &cart); status != http.StatusOK || len(*cart.Items) != 0 {
+		t.Errorf("Expected an empty cart after checkout, got %+v", cart)
+	}
+}
+
+func doRequest(method, path, token string, body any) (*http.Response, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, baseURL+path, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func authenticate(authRequest AuthRequest) (*AuthResponse, error) {
+	body, err := json.Marshal(authRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(baseURL+"/api/auth", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("authentication failed with status: %v", resp.Status)
+	}
+
+	var authResponse AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResponse); err != nil {
+		return nil, err
+	}
+
+	return &authResponse, nil
+}
+
+func refresh(token string) (*AuthResponse, error) {
+	resp, err := doRequest("POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: token})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("refresh failed with status: %v", resp.Status)
+	}
+
+	var authResponse AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResponse); err != nil {
+		return nil, err
+	}
+
+	return &authResponse, nil
+}
+
+func doKeyRequest(method, path, key string, body any) (*http.Response, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, baseURL+path, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", key)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func doIdempotentRequest(method, path, token, key string, body any) (*http.Response, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, baseURL+path, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func TestOrders(t *testing.T) {
+	buyer, err := authenticate(AuthRequest{Username: "orderbuyer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	orderRequest := func(method, path, token string, body, out any) int {
+		resp, err := doRequest(method, path, token, body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	//a purchase places an order
+	var list OrderListResponse
+	if status := orderRequest("GET", "/api/orders", *buyer.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 0 {
+		t.Fatalf("Expected no orders, got %v %+v", status, list)
+	}
+	quantity := 2
+	if status := orderRequest("POST", "/api/buy", *buyer.Token, BuyRequest{Item: "cup", Quantity: &quantity}, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if status := orderRequest("GET", "/api/orders", *buyer.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 1 {
+		t.Fatalf("Expected one order, got %v %+v", status, list)
+	}
+	order := (*list.Orders)[0]
+	items := *order.Items
+	if *order.Status != Placed || *order.Total != 40 || *order.User != "orderbuyer" || len(items) != 1 || *items[0].Item != "cup" || *items[0].Quantity != 2 || order.ShippedAt != nil {
+		t.Fatalf("Unexpected order: %+v", order)
+	}
+	statusPath := fmt.Sprintf("/api/admin/orders/%d/status", *order.Id)
+
+	//only admins see all orders and advance them
+	if status := orderRequest("GET", "/api/admin/orders", *buyer.Token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected status 403 Forbidden, got %v", status)
+	}
+	if status := orderRequest("PUT", statusPath, *buyer.Token, OrderStatusRequest{Status: Shipped}, nil); status != http.StatusForbidden {
+		t.Errorf("Expected status 403 Forbidden, got %v", status)
+	}
+	if status := orderRequest("GET", "/api/admin/orders?status=placed", *admin.Token, nil, &list); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	found := false
+	for _, o := range *list.Orders {
+		found = found || *o.Id == *order.Id
+	}
+	if !found {
+		t.Errorf("Expected the order among placed orders: %+v", list)
+	}
+	for _, path := range []string{"/api/admin/orders?status=lost", "/api/admin/orders?limit=0", "/api/admin/orders?limit=101", "/api/admin/orders?cursor=abc", "/api/orders?limit=101", "/api/orders?cursor=1-0"} {
+		token := *admin.Token
+		if strings.HasPrefix(path, "/api/orders") {
+			token = *buyer.Token
+		}
+		if status := orderRequest("GET", path, token, nil, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 Bad Request, got %v", path, status)
+		}
+	}
+
+	//statuses move forward only
+	if status := orderRequest("PUT", statusPath, *admin.Token, OrderStatusRequest{Status: Shipped}, &order); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if *order.Status != Shipped || order.ShippedAt == nil || order.ProcessingAt != nil {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+	if status := orderRequest("PUT", statusPath, *admin.Token, OrderStatusRequest{Status: Processing}, nil); status != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", status)
+	}
+	for _, s := range []OrderStatus{Placed, Cancelled, "lost"} {
+		if status := orderRequest("PUT", statusPath, *admin.Token, OrderStatusRequest{Status: s}, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 Bad Request, got %v", s, status)
+		}
+	}
+	if status := orderRequest("PUT", "/api/admin/orders/100000/status", *admin.Token, OrderStatusRequest{Status: Delivered}, nil); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", status)
+	}
+	if status := orderRequest("GET", "/api/orders", *buyer.Token, nil, &list); status != http.StatusOK || *(*list.Orders)[0].Status != Shipped {
+		t.Errorf("Expected the order to be shipped, got %v %+v", status, list)
+	}
+
+	//both lists are paged with the cursor of the previous page
+	if status := orderRequest("POST", "/api/buy", *buyer.Token, BuyRequest{Item: "pen"}, nil); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	list = OrderListResponse{}
+	if status := orderRequest("GET", "/api/orders?limit=1", *buyer.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 1 || list.NextCursor == nil || *(*list.Orders)[0].Id == *order.Id {
+		t.Fatalf("Expected the newest order and a cursor, got %v %+v", status, list)
+	}
+	path := "/api/orders?limit=1&cursor=" + *list.NextCursor
+	list = OrderListResponse{}
+	if status := orderRequest("GET", path, *buyer.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 1 || list.NextCursor != nil || *(*list.Orders)[0].Id != *order.Id {
+		t.Fatalf("Expected the first order on the last page, got %v %+v", status, list)
+	}
+	list = OrderListResponse{}
+	if status := orderRequest("GET", "/api/admin/orders?limit=1", *admin.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 1 || list.NextCursor == nil {
+		t.Fatalf("Expected one order and a cursor, got %v %+v", status, list)
+	}
+	first := *(*list.Orders)[0].Id
+	path = "/api/admin/orders?limit=1&cursor=" + *list.NextCursor
+	list = OrderListResponse{}
+	if status := orderRequest("GET", path, *admin.Token, nil, &list); status != http.StatusOK || len(*list.Orders) != 1 || *(*list.Orders)[0].Id == first {
+		t.Fatalf("Expected the next order, got %v %+v", status, list)
+	}
+}
+
+func TestOrderRefund(t *testing.T) {
+	buyer, err := authenticate(AuthRequest{Username: "refundbuyer", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	other, err := authenticate(AuthRequest{Username: "refundother", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	admin, err := authenticate(AuthRequest{Username: "merchadmin", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Authentication failed: %v", err)
+	}
+	orderRequest := func(method, path, token string, body, out any) int {
+		resp, err := doRequest(method, path, token, body)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+	buy := func(item string) Order {
+		if status := orderRequest("POST", "/api/buy", *buyer.Token, BuyRequest{Item: item}, nil); status != http.StatusOK {
+			t.Fatalf("Expected status 200 OK, got %v", status)
+		}
+		var list OrderListResponse
+		if status := orderRequest("GET", "/api/orders", *buyer.Token, nil, &list); status != http.StatusOK || len(*list.Orders) == 0 {
+			t.Fatalf("Expected an order, got %v %+v", status, list)
+		}
+		return (*list.Orders)[0]
+	}
+
+	//users cancel their own orders, the coins come back and the item leaves the inventory
+	order := buy("hoody")
+	cancelPath := fmt.Sprintf("/api/orders/%d/cancel", *order.Id)
+	if status := orderRequest("POST", cancelPath, *other.Token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", status)
+	}
+	if status := orderRequest("POST", cancelPath, *buyer.Token, nil, &order); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	if *order.Status != Cancelled || order.CancelledAt == nil {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+	if status := orderRequest("POST", cancelPath, *buyer.Token, nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", status)
+	}
+	var info InfoResponse
+	if status := orderRequest("GET", "/api/info", *buyer.Token, nil, &info); status != http.StatusOK {
+		t.Fatalf("Expected status 200 OK, got %v", status)
+	}
+	refunds := *info.CoinHistory.Refunds
+	if *info.Coins != 1000 || (info.Inventory != nil && len(*info.Inventory) != 0) || len(refunds) != 1 || *refunds[0].Order != *order.Id || *refunds[0].Amount != 300 {
+		t.Errorf("Unexpected info after the refund: coins %v, inventory %+v, refunds %+v", *info.Coins, info.Inventory, refunds)
+	}
+
+	//admins refund any order
+	order = buy("cup")
+	refundPath := fmt.Sprintf("/api/admin/orders/%d/refund", *order.Id)
+	if status := orderRequest("POST", refundPath, *buyer.Token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("Expected status 403 Forbidden, got %v", status)
+	}
+	if status := orderRequest("POST", refundPath, *admin.Token, nil, &order); status != http.StatusOK || *order.Status != Cancelled {
+		t.Fatalf("Expected the order to be cancelled, got %v %+v", status, order)
+	}
+	if status := orderRequest("POST", refundPath, *admin.Token, nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected status 409 Conflict, got %v", status)
+	}
+	if status := orderRequest("POST", "/api/admin/orders/100000/refund", *admin.Token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 Not Found, got %v", status)
+	}
+	if status := orderRequest("GET", "/api/info", *buyer.Token, nil, &info); status != http.StatusOK || *info.Coins != 1000 {
+		t.Errorf("Expected the coins to be refunded, got %v %+v", status, info)
+	}
+}
+
+func TestOrderRefundWindow(t *testing.T) {
+	api, err := New(&config.Config{StorageType: "memory", RefundWindow: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/api/auth", "application/json", jsonBody(t, AuthRequest{Username: "latebuyer", Password: "pass"}))
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	var auth AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if err := api.storage.Buy("pen", "latebuyer", 1); err != nil {
+		t.Fatalf("Failed to buy: %v", err)
+	}
+	page, err := api.storage.Orders("latebuyer", storage.OrderFilter{})
+	if err != nil || len(page.Orders) != 1 {
+		t.Fatalf("Expected one order, got %v %+v", err, page)
+	}
+	time.Sleep(time.Millisecond)
+
+	//the window has passed, only admins may refund the order now
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/orders/%d/cancel", srv.URL, page.Orders[0].ID), nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+*auth.Token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	var errResp ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusConflict || *errResp.Errors != refundWindowExpiredErrMsg {
+		t.Errorf("Expected status 409 Conflict with an expired window, got %v %+v", resp.StatusCode, errResp)
+	}
 }
@@ -0,0 +1,184 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+	// defaultIdempotencyPendingTimeout is how long a retry waits for a request that never
+	// finished, e.g. because the server stopped while running it, before its outcome is
+	// reported as unknown
+	defaultIdempotencyPendingTimeout = time.Minute
+	maxIdempotencyKeyLen             = 255
+	// idempotentReplayedHeader marks responses sent again for a retried request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotencyMiddleware makes retries of sendCoin and buy requests with the same
+// Idempotency-Key header safe: the request is executed once, its response is recorded
+// per user and sent again on retries. Reusing a key for a different request is rejected
+// with 422, a retry while the first request is still running with 409. A request that
+// never finished is not run again, as it may have moved coins already: after the pending
+// timeout retries get 409 telling the outcome is unknown.
+// It relies on AuthMiddleware, keys of unauthenticated requests are ignored.
+func (s *APIServer) IdempotencyMiddleware(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+	switch operationID {
+	case "PostApiSendCoin", "PostApiBuy", "GetApiBuyItem":
+	default:
+		return f
+	}
+
+	return func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		key, payload := idempotentRequest(request)
+		if key == nil || !ctx.GetBool(authorizedKey) {
+			return f(ctx, request)
+		}
+		if *key == "" || len(*key) > maxIdempotencyKeyLen {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Errors: &invalidIdempotencyKeyErrMsg})
+			return nil, nil
+		}
+		fingerprint, err := requestFingerprint(operationID, payload)
+		if err != nil {
+			s.log.Error(err.Error())
+			return nil, err
+		}
+		user := ctx.GetString(usernameKey)
+		now := time.Now()
+		stored, err := s.storage.ReserveIdempotencyKey(user, *key, fingerprint, now.Add(-s.idempotencyKeyTTL), now.Add(-s.idempotencyPendingTimeout))
+		switch {
+		case errors.Is(err, storage.ErrIdempotencyKeyReused):
+			ctx.JSON(http.StatusUnprocessableEntity, ErrorResponse{Errors: &idempotencyKeyReusedErrMsg})
+			return nil, nil
+		case errors.Is(err, storage.ErrIdempotencyKeyInProgress):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Errors: &idempotencyKeyInProgressErrMsg})
+			return nil, nil
+		case errors.Is(err, storage.ErrIdempotencyKeyOutcomeUnknown):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Errors: &idempotencyKeyUnknownErrMsg})
+			return nil, nil
+		case err != nil:
+			s.log.Error(err.Error())
+			return nil, err
+		}
+		if stored != nil {
+			ctx.Header(idempotentReplayedHeader, "true")
+			writeRecorded(ctx.Writer, stored.StatusCode, stored.Body)
+			return nil, nil
+		}
+
+		response, err := f(ctx, request)
+		//failed requests may be retried with the same key
+		if err != nil || response == nil {
+			s.releaseIdempotencyKey(user, *key)
+			return response, err
+		}
+		rec := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+		if err := visitIdempotentResponse(response, rec); err != nil {
+			s.releaseIdempotencyKey(user, *key)
+			return nil, err
+		}
+		if rec.status >= http.StatusInternalServerError {
+			s.releaseIdempotencyKey(user, *key)
+		} else if err := s.storage.CompleteIdempotencyKey(user, *key, storage.IdempotentResponse{StatusCode: rec.status, Body: rec.body.Bytes()}); err != nil {
+			s.log.Error(err.Error())
+			//rejected requests changed nothing and may be retried, successful ones must
+			//not run twice, so their key stays reserved until its outcome is reported unknown
+			if rec.status >= http.StatusBadRequest {
+				s.releaseIdempotencyKey(user, *key)
+			}
+		}
+		writeRecorded(ctx.Writer, rec.status, rec.body.Bytes())
+		return nil, nil
+	}
+}
+
+func (s *APIServer) releaseIdempotencyKey(user, key string) {
+	if err := s.storage.ReleaseIdempotencyKey(user, key); err != nil {
+		s.log.Error(err.Error())
+	}
+}
+
+// idempotentRequest returns the idempotency key of the request and the part of it
+// that must be the same when the request is retried.
+func idempotentRequest(request interface{}) (*string, any) {
+	switch r := request.(type) {
+	case PostApiSendCoinRequestObject:
+		return r.Params.IdempotencyKey, r.Body
+	case PostApiBuyRequestObject:
+		if r.Body == nil {
+			return r.Params.IdempotencyKey, r.Body
+		}
+		return r.Params.IdempotencyKey, buyPayload(r.Body.Item, r.Body.Quantity)
+	case GetApiBuyItemRequestObject:
+		return r.Params.IdempotencyKey, buyPayload(r.Item, r.Params.Quantity)
+	}
+	return nil, nil
+}
+
+// buyPayload fills in the default quantity, so that a retry spelling it out is the same request.
+func buyPayload(item string, quantity *int) BuyRequest {
+	if quantity == nil {
+		count := 1
+		quantity = &count
+	}
+	return BuyRequest{Item: item, Quantity: quantity}
+}
+
+func visitIdempotentResponse(response interface{}, w http.ResponseWriter) error {
+	switch r := response.(type) {
+	case PostApiSendCoinResponseObject:
+		return r.VisitPostApiSendCoinResponse(w)
+	case PostApiBuyResponseObject:
+		return r.VisitPostApiBuyResponse(w)
+	case GetApiBuyItemResponseObject:
+		return r.VisitGetApiBuyItemResponse(w)
+	}
+	return fmt.Errorf("unexpected response type: %T", response)
+}
+
+func requestFingerprint(operationID string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(operationID+"\n"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeRecorded writes a recorded response, all responses of the operations are JSON or empty.
+func writeRecorded(w gin.ResponseWriter, status int, body []byte) {
+	if len(body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// responseRecorder keeps a response in memory, so that it can be recorded for an idempotency key.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
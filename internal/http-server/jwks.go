@@ -0,0 +1,9 @@
+package httpserver
+
+import "github.com/gin-gonic/gin"
+
+// GetWellKnownJwksJson publishes the public keys so that other services can
+// verify tokens without sharing a secret.
+func (s *APIServer) GetWellKnownJwksJson(ctx *gin.Context, req GetWellKnownJwksJsonRequestObject) (GetWellKnownJwksJsonResponseObject, error) {
+	return GetWellKnownJwksJson200JSONResponse{Keys: s.jwtKeys.jwks()}, nil
+}
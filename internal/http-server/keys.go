@@ -0,0 +1,184 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// minSecretLen is the minimal length of a JWT secret accepted in production, in bytes.
+	minSecretLen = 32
+	// minRSABits is the minimal size of an RSA key accepted in production.
+	minRSABits = 2048
+)
+
+// signingKey is a key tokens are signed or verified with, its id is put into
+// the kid header of the tokens it signs. HMAC keys have a secret, RSA keys have
+// a public key and, unless the key is only used for verification, a private key.
+type signingKey struct {
+	id      string
+	secret  []byte
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+func (k signingKey) method() jwt.SigningMethod {
+	if k.public != nil {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodHS256
+}
+
+func (k signingKey) signKey() any {
+	if k.public != nil {
+		return k.private
+	}
+	return k.secret
+}
+
+func (k signingKey) verifyKey() any {
+	if k.public != nil {
+		return k.public
+	}
+	return k.secret
+}
+
+// keySet holds the key new tokens are signed with and the keys tokens are still
+// verified with, so that tokens signed before a rotation stay valid until they expire.
+type keySet struct {
+	current  signingKey
+	previous []signingKey
+}
+
+// lookup returns the key with the given id. Tokens issued before key ids
+// were introduced have no kid and are verified with the current key.
+func (ks keySet) lookup(kid string) (signingKey, error) {
+	if kid == "" || kid == ks.current.id {
+		return ks.current, nil
+	}
+	for _, key := range ks.previous {
+		if key.id == kid {
+			return key, nil
+		}
+	}
+	return signingKey{}, fmt.Errorf("unknown key id %q", kid)
+}
+
+// jwks returns the public keys of the set in the JSON Web Key format.
+// HMAC keys are secret and never published.
+func (ks keySet) jwks() []JWK {
+	keys := []JWK{}
+	for _, key := range append([]signingKey{ks.current}, ks.previous...) {
+		if key.public == nil {
+			continue
+		}
+		keys = append(keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: key.method().Alg(),
+			Kid: key.id,
+			N:   base64.RawURLEncoding.EncodeToString(key.public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.public.E)).Bytes()),
+		})
+	}
+	return keys
+}
+
+// loadKeys builds the key set from the config. Outside of production a random
+// key is generated when no key is configured, tokens then don't survive a restart.
+func loadKeys(cfg *config.Config) (keySet, bool, error) {
+	production := cfg.Env == config.EnvProduction
+	current, err := readKey(cfg.JWT.KeyID, cfg.JWT.Secret, cfg.JWT.SecretFile, cfg.JWT.PrivateKeyFile, "")
+	if err != nil {
+		return keySet{}, false, err
+	}
+	generated := false
+	if current == nil {
+		if production {
+			return keySet{}, false, fmt.Errorf("one of JWT_SECRET, JWT_SECRET_FILE or JWT_PRIVATE_KEY_FILE must be set in production")
+		}
+		secret := make([]byte, minSecretLen)
+		if _, err := rand.Read(secret); err != nil {
+			return keySet{}, false, fmt.Errorf("failed to generate JWT secret: %w", err)
+		}
+		current = &signingKey{id: cfg.JWT.KeyID, secret: secret}
+		generated = true
+	}
+	if production {
+		if current.public != nil && current.public.N.BitLen() < minRSABits {
+			return keySet{}, false, fmt.Errorf("JWT RSA key must be at least %d bits long", minRSABits)
+		}
+		if current.public == nil && len(current.secret) < minSecretLen {
+			return keySet{}, false, fmt.Errorf("JWT secret must be at least %d bytes long", minSecretLen)
+		}
+	}
+	keys := keySet{current: *current}
+
+	previous, err := readKey(cfg.JWT.PreviousKeyID, cfg.JWT.PreviousSecret, cfg.JWT.PreviousSecretFile, "", cfg.JWT.PreviousPublicKeyFile)
+	if err != nil {
+		return keySet{}, false, err
+	}
+	if previous != nil {
+		if previous.id == "" || previous.id == current.id {
+			return keySet{}, false, fmt.Errorf("JWT_PREVIOUS_KEY_ID must be set and differ from JWT_KEY_ID")
+		}
+		keys.previous = append(keys.previous, *previous)
+	}
+	return keys, generated, nil
+}
+
+// readKey loads a key from one of the sources, nil is returned if none is set.
+func readKey(id, secret, secretFile, privateKeyFile, publicKeyFile string) (*signingKey, error) {
+	set := 0
+	for _, source := range []string{secret, secretFile, privateKeyFile, publicKeyFile} {
+		if source != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("JWT key %q is configured more than once", id)
+	}
+	switch {
+	case secret != "":
+		return &signingKey{id: id, secret: []byte(secret)}, nil
+	case secretFile != "":
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT secret file: %w", err)
+		}
+		secret = strings.TrimSpace(string(data))
+		if secret == "" {
+			return nil, fmt.Errorf("JWT secret file %s is empty", secretFile)
+		}
+		return &signingKey{id: id, secret: []byte(secret)}, nil
+	case privateKeyFile != "":
+		data, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key file: %w", err)
+		}
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+		}
+		return &signingKey{id: id, private: private, public: &private.PublicKey}, nil
+	case publicKeyFile != "":
+		data, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT public key file: %w", err)
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+		}
+		return &signingKey{id: id, public: public}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// lockoutPolicy limits failed logins per user and per client address, see config.LockoutConfig.
+type lockoutPolicy struct {
+	maxFailures     int
+	maxIPFailures   int
+	window          time.Duration
+	lockDuration    time.Duration
+	maxLockDuration time.Duration
+}
+
+func newLockoutPolicy(cfg config.LockoutConfig) lockoutPolicy {
+	p := lockoutPolicy{
+		maxFailures:     cfg.MaxFailures,
+		maxIPFailures:   cfg.MaxIPFailures,
+		window:          cfg.Window,
+		lockDuration:    cfg.LockDuration,
+		maxLockDuration: cfg.MaxLockDuration,
+	}
+	//defaults match the env-default tags for configs built in code
+	if p.maxFailures <= 0 {
+		p.maxFailures = 5
+	}
+	if p.maxIPFailures <= 0 {
+		p.maxIPFailures = 20
+	}
+	if p.window <= 0 {
+		p.window = time.Hour
+	}
+	if p.lockDuration <= 0 {
+		p.lockDuration = time.Minute
+	}
+	if p.maxLockDuration < p.lockDuration {
+		p.maxLockDuration = max(time.Hour, p.lockDuration)
+	}
+	return p
+}
+
+func (p lockoutPolicy) userLockout() storage.LoginLockout {
+	return storage.LoginLockout{MaxFailures: p.maxFailures, LockDuration: p.lockDuration, MaxLockDuration: p.maxLockDuration}
+}
+
+func (p lockoutPolicy) ipLockout() storage.LoginLockout {
+	return storage.LoginLockout{MaxFailures: p.maxIPFailures, LockDuration: p.lockDuration, MaxLockDuration: p.maxLockDuration}
+}
+
+func userLoginKey(name string) string {
+	return "user:" + name
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginLocked returns the time left until the key is unlocked, zero if it is not locked.
+func (s *APIServer) loginLocked(key string) (time.Duration, error) {
+	attempts, err := s.storage.LoginAttempts(key)
+	if err != nil {
+		return 0, err
+	}
+	return max(time.Until(attempts.LockedUntil), 0), nil
+}
+
+// loginAttempt counts a login of the user as failed before the password is checked,
+// so that parallel guesses can't get past the limit; a right password resets the count.
+// Names are counted whether or not the user exists, so that locks don't tell which
+// accounts exist. It returns the time left until the name is unlocked, zero if the
+// password may be checked.
+func (s *APIServer) loginAttempt(name string) (time.Duration, error) {
+	return s.addLoginFailure(userLoginKey(name), s.lockout.userLockout())
+}
+
+// loginFailed counts a failed login from the client address, which is locked once
+// it runs out of attempts.
+func (s *APIServer) loginFailed(ip string) error {
+	//an address locked by a parallel request needn't count this failure
+	_, err := s.addLoginFailure(ipLoginKey(ip), s.lockout.ipLockout())
+	return err
+}
+
+func (s *APIServer) addLoginFailure(key string, lockout storage.LoginLockout) (time.Duration, error) {
+	attempts, err := s.storage.AddLoginFailure(key, time.Now().Add(-s.lockout.window), lockout)
+	if errors.Is(err, storage.ErrLoginLocked) {
+		//the lock may end right after it was checked
+		return max(time.Until(attempts.LockedUntil), time.Second), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d := lockout.LockFor(attempts.Failures); d > 0 {
+		s.log.Warn("login locked", "key", key, "failures", attempts.Failures, "duration", d.String())
+	}
+	return 0, nil
+}
+
+// retryAfter rounds the lock duration up to whole seconds for the Retry-After header.
+func retryAfter(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (s *APIServer) DeleteApiAdminUsersUsernameLockout(ctx *gin.Context, req DeleteApiAdminUsersUsernameLockoutRequestObject) (DeleteApiAdminUsersUsernameLockoutResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return DeleteApiAdminUsersUsernameLockout401JSONResponse(errResp), nil
+	}
+	exists, err := s.storage.UserExist(req.Username)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiAdminUsersUsernameLockout500JSONResponse(errResp), nil
+	}
+	if !exists {
+		errResp := ErrorResponse{Errors: &userNotFoundErrMsg}
+		return DeleteApiAdminUsersUsernameLockout404JSONResponse(errResp), nil
+	}
+	if err := s.storage.ResetLoginAttempts(userLoginKey(req.Username)); err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return DeleteApiAdminUsersUsernameLockout500JSONResponse(errResp), nil
+	}
+	s.log.Info("login unlocked", "admin", ctx.GetString(usernameKey), "user", req.Username)
+	return DeleteApiAdminUsersUsernameLockout204Response{}, nil
+}
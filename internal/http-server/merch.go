@@ -0,0 +1,55 @@
+package httpserver
+
+import (
+	"errors"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) GetApiMerch(ctx *gin.Context, req GetApiMerchRequestObject) (GetApiMerchResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiMerch401JSONResponse(errResp), nil
+	}
+	items, err := s.storage.MerchList()
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiMerch500JSONResponse(errResp), nil
+	}
+	respItems := make([]MerchItem, len(items))
+	for i, item := range items {
+		respItems[i] = convertMerchItem(item)
+	}
+	return GetApiMerch200JSONResponse{Items: &respItems}, nil
+}
+
+func (s *APIServer) GetApiMerchItem(ctx *gin.Context, req GetApiMerchItemRequestObject) (GetApiMerchItemResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiMerchItem401JSONResponse(errResp), nil
+	}
+	item, err := s.storage.MerchItem(req.Item)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemNotFound) {
+			errResp := ErrorResponse{Errors: &noSuchItemErrMsg}
+			return GetApiMerchItem404JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiMerchItem500JSONResponse(errResp), nil
+	}
+	return GetApiMerchItem200JSONResponse(convertMerchItem(*item)), nil
+}
+
+func convertMerchItem(item storage.MerchItem) MerchItem {
+	return MerchItem{
+		Name:        &item.Name,
+		Price:       &item.Price,
+		Description: &item.Description,
+		Available:   &item.Available,
+	}
+}
@@ -0,0 +1,291 @@
+package httpserver
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	defaultJWKSRefresh = time.Hour
+	// minJWKSRefetch limits fetching the JWKS again for tokens signed with an unknown key
+	// and after a failed fetch
+	minJWKSRefetch = time.Minute
+	// maxJWKSSize limits the size of a JWKS response, in bytes.
+	maxJWKSSize = 1 << 20
+	// maxUsernameLen is the length of users.name
+	maxUsernameLen = 255
+)
+
+// errOIDCUserRejected is returned for provider users that must not sign in: users not created
+// by the provider and, when signup is disabled, unknown users.
+var errOIDCUserRejected = errors.New("user is not linked to the identity provider")
+
+// oidcVerifier validates ID tokens of an external identity provider.
+type oidcVerifier struct {
+	issuer        string
+	audience      string
+	usernameClaim string
+	jwksURL       string
+	jwksFile      string
+	refresh       time.Duration
+	client        *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+	// lastFetch is the time of the last attempt to fetch the keys, nextFetch is when they are due to be refreshed
+	lastFetch time.Time
+	nextFetch time.Time
+}
+
+// newOIDCVerifier returns nil if OIDC is not configured. Keys from a file are read
+// right away, keys from a URL are fetched on the first provider token, so that the
+// service starts while the provider is unavailable.
+func newOIDCVerifier(cfg config.OIDCConfig) (*oidcVerifier, error) {
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+	if cfg.Audience == "" {
+		return nil, fmt.Errorf("OIDC_AUDIENCE must be set when OIDC_ISSUER is set")
+	}
+	if (cfg.JWKSURL == "") == (cfg.JWKSFile == "") {
+		return nil, fmt.Errorf("exactly one of OIDC_JWKS_URL or OIDC_JWKS_FILE must be set")
+	}
+	claim := cfg.UsernameClaim
+	switch claim {
+	case "":
+		claim = "email"
+	case "email", "sub":
+	default:
+		return nil, fmt.Errorf("unknown OIDC username claim %q", claim)
+	}
+	refresh := cfg.JWKSRefresh
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	v := &oidcVerifier{
+		issuer:        cfg.Issuer,
+		audience:      cfg.Audience,
+		usernameClaim: claim,
+		jwksURL:       cfg.JWKSURL,
+		jwksFile:      cfg.JWKSFile,
+		refresh:       refresh,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	if v.jwksFile != "" {
+		data, err := os.ReadFile(v.jwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OIDC JWKS file: %w", err)
+		}
+		if v.keys, err = parseJWKS(data); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// issuedBy reports whether the token claims to be issued by the provider.
+// The signature is not checked here, see verify.
+func (v *oidcVerifier) issuedBy(tokenString string) bool {
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, claims); err != nil {
+		return false
+	}
+	iss, _ := claims["iss"].(string)
+	return iss == v.issuer
+}
+
+// verify validates an ID token and returns its claims. The role is left empty,
+// it is not part of the token and comes from the users table.
+func (v *oidcVerifier) verify(tokenString string) (tokenClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.key(kid)
+	})
+	if err != nil {
+		return tokenClaims{}, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return tokenClaims{}, fmt.Errorf("invalid token")
+	}
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return tokenClaims{}, fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !hasAudience(claims["aud"], v.audience) {
+		return tokenClaims{}, fmt.Errorf("token is not issued for %q", v.audience)
+	}
+	//jwt-go accepts tokens without exp
+	if _, ok := claims["exp"]; !ok {
+		return tokenClaims{}, fmt.Errorf("token has no exp claim")
+	}
+	name, _ := claims[v.usernameClaim].(string)
+	if name == "" || len(name) > maxUsernameLen {
+		return tokenClaims{}, fmt.Errorf("token has no valid %s claim", v.usernameClaim)
+	}
+	if v.usernameClaim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return tokenClaims{}, fmt.Errorf("email %q is not verified", name)
+		}
+	}
+	id, _ := claims["jti"].(string)
+	return tokenClaims{
+		name:      name,
+		id:        id,
+		issuedAt:  numericDate(claims["iat"]),
+		expiresAt: numericDate(claims["exp"]),
+	}, nil
+}
+
+// key returns the provider key with the given id. Keys from a URL are refreshed
+// periodically and when a token is signed with an unknown key, as providers rotate keys.
+// The cached keys are kept while the provider is unavailable.
+func (v *oidcVerifier) key(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key, ok := v.lookup(kid)
+	now := time.Now()
+	due := now.After(v.nextFetch) || (!ok && now.Sub(v.lastFetch) >= minJWKSRefetch)
+	if v.jwksURL != "" && due {
+		v.lastFetch = now
+		keys, err := v.fetchKeys()
+		if err != nil {
+			v.nextFetch = now.Add(minJWKSRefetch)
+			if !ok {
+				return nil, err
+			}
+			return key, nil
+		}
+		v.keys, v.nextFetch = keys, now.Add(v.refresh)
+		key, ok = v.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown OIDC key id %q", kid)
+	}
+	return key, nil
+}
+
+// lookup must be called with v.mu held. Tokens without kid are accepted
+// if the provider has a single key.
+func (v *oidcVerifier) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func (v *oidcVerifier) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC JWKS: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS returns the RSA signing keys of a JWKS by key id, other keys are skipped.
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set JWKSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of OIDC key %q: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of OIDC key %q: %w", jwk.Kid, err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent of OIDC key %q", jwk.Kid)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("OIDC JWKS has no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// hasAudience checks the aud claim, which is either a string or an array of strings.
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// oidcUserRole returns the role of a user signed in with the identity provider.
+// Users are created on first sight without a password, linked to the provider, so they
+// can only sign in through it, unless signup is disabled. Provider tokens are rejected
+// for local users of the same name, so the provider can't take over password accounts.
+func (s *APIServer) oidcUserRole(name string) (string, error) {
+	issuer, err := s.storage.UserIssuer(name)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		if s.signupMode == config.SignupDisabled {
+			return "", errOIDCUserRejected
+		}
+		err = s.storage.AddExternalUser(name, s.oidc.issuer)
+		//a concurrent request may have created the user
+		if err != nil && !errors.Is(err, storage.ErrUserExists) {
+			return "", err
+		}
+		if err == nil {
+			s.log.Info("user created from identity provider", "user", name)
+		}
+		issuer, err = s.storage.UserIssuer(name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if issuer != s.oidc.issuer {
+		return "", errOIDCUserRejected
+	}
+	role, err := s.storage.UserRole(name)
+	if err != nil {
+		return "", err
+	}
+	if s.adminUsers[name] && role != storage.RoleAdmin {
+		return s.userRole(name)
+	}
+	return role, nil
+}
@@ -0,0 +1,249 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testAudience = "merch-shop"
+)
+
+// stubIssuer serves the JWKS of a fake identity provider and signs its ID tokens.
+type stubIssuer struct {
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+func newStubIssuer(t *testing.T, kid string) *stubIssuer {
+	i := &stubIssuer{keys: map[string]*rsa.PrivateKey{}}
+	i.addKey(t, kid)
+	return i
+}
+
+func (i *stubIssuer) addKey(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[kid] = key
+}
+
+func (i *stubIssuer) jwks() JWKSet {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	set := JWKSet{Keys: []JWK{}}
+	for kid, key := range i.keys {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+func (i *stubIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i.jwks())
+}
+
+func (i *stubIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	now := time.Now()
+	all := jwt.MapClaims{
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"sub":            "248289761001",
+		"email":          "jane@corp.example",
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(all, k)
+			continue
+		}
+		all[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = kid
+	i.mu.Lock()
+	key := i.keys[kid]
+	i.mu.Unlock()
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestOIDC(t *testing.T) {
+	issuer := newStubIssuer(t, "k1")
+	idp := httptest.NewServer(issuer)
+	defer idp.Close()
+
+	api, err := New(&config.Config{
+		StorageType: "memory",
+		AdminUsers:  []string{"boss@corp.example"},
+		OIDC:        config.OIDCConfig{Issuer: testIssuer, Audience: testAudience, JWKSURL: idp.URL},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+
+	get := func(path, token string) int {
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Сценарий 1: Пользователь создается при первом запросе с токеном провайдера
+	token := issuer.token(t, "k1", nil)
+	assert.Equal(t, http.StatusOK, get("/api/info", token))
+	coins, err := api.storage.UserBalance("jane@corp.example")
+	require.NoError(t, err)
+	assert.Equal(t, storage.StartBalance, coins)
+	assert.Equal(t, http.StatusOK, get("/api/info", token))
+	assert.Equal(t, http.StatusForbidden, get("/api/admin/users/jane@corp.example/balance", token))
+	assert.Equal(t, http.StatusOK, get("/api/admin/users/jane@corp.example/balance", issuer.token(t, "k1", jwt.MapClaims{"email": "boss@corp.example"})))
+
+	// Сценарий 2: Пользователи провайдера не входят по паролю
+	resp, err := http.Post(srv.URL+"/api/auth", "application/json", jsonBody(t, AuthRequest{Username: "jane@corp.example", Password: ""}))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Сценарий 3: Некорректные токены отклоняются
+	for name, claims := range map[string]jwt.MapClaims{
+		"wrong audience":     {"aud": "other-app"},
+		"expired":            {"exp": time.Now().Add(-time.Minute).Unix()},
+		"no expiry":          {"exp": nil},
+		"unverified email":   {"email_verified": false},
+		"no email":           {"email": nil},
+		"unknown issuer":     {"iss": "https://evil.example.com"},
+		"audience in a list": {"aud": []string{"other-app"}},
+	} {
+		assert.Equal(t, http.StatusUnauthorized, get("/api/info", issuer.token(t, "k1", claims)), name)
+	}
+	assert.Equal(t, http.StatusOK, get("/api/info", issuer.token(t, "k1", jwt.MapClaims{"aud": []string{"other-app", testAudience}})))
+	forged := newStubIssuer(t, "k1")
+	assert.Equal(t, http.StatusUnauthorized, get("/api/info", forged.token(t, "k1", nil)))
+
+	// Сценарий 4: Ключи провайдера перечитываются после ротации
+	issuer.addKey(t, "k2")
+	api.oidc.mu.Lock()
+	api.oidc.lastFetch = time.Time{}
+	api.oidc.mu.Unlock()
+	assert.Equal(t, http.StatusOK, get("/api/info", issuer.token(t, "k2", nil)))
+
+	// Сценарий 5: Собственные токены сервиса продолжают работать
+	resp, err = http.Post(srv.URL+"/api/auth", "application/json", jsonBody(t, AuthRequest{Username: "localuser", Password: "pass"}))
+	require.NoError(t, err)
+	var auth AuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&auth))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, get("/api/info", *auth.Token))
+
+	// Сценарий 6: Токены провайдера не подходят к локальным учетным записям с тем же именем
+	require.NoError(t, api.storage.AddUser("root@corp.example", "hash"))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/info", issuer.token(t, "k1", jwt.MapClaims{"email": "root@corp.example"})))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/info", issuer.token(t, "k1", jwt.MapClaims{"email": "localuser"})))
+	userIssuer, err := api.storage.UserIssuer("jane@corp.example")
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer, userIssuer)
+}
+
+func TestOIDCSignupDisabled(t *testing.T) {
+	issuer := newStubIssuer(t, "k1")
+	idp := httptest.NewServer(issuer)
+	defer idp.Close()
+
+	api, err := New(&config.Config{
+		StorageType: "memory",
+		SignupMode:  config.SignupDisabled,
+		OIDC:        config.OIDCConfig{Issuer: testIssuer, Audience: testAudience, JWKSURL: idp.URL},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(api.Router())
+	defer srv.Close()
+	get := func(token string) int {
+		req, err := http.NewRequest("GET", srv.URL+"/api/info", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	//unknown provider users are not created, existing ones sign in
+	assert.Equal(t, http.StatusUnauthorized, get(issuer.token(t, "k1", nil)))
+	_, err = api.storage.UserIssuer("jane@corp.example")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	require.NoError(t, api.storage.AddExternalUser("jane@corp.example", testIssuer))
+	assert.Equal(t, http.StatusOK, get(issuer.token(t, "k1", nil)))
+}
+
+func TestOIDCSubjectClaim(t *testing.T) {
+	issuer := newStubIssuer(t, "k1")
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	data, err := json.Marshal(issuer.jwks())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+
+	v, err := newOIDCVerifier(config.OIDCConfig{Issuer: testIssuer, Audience: testAudience, JWKSFile: file, UsernameClaim: "sub"})
+	require.NoError(t, err)
+	token := issuer.token(t, "k1", jwt.MapClaims{"email": nil})
+	assert.True(t, v.issuedBy(token))
+	claims, err := v.verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "248289761001", claims.name)
+	assert.Empty(t, claims.role)
+}
+
+func TestNewOIDCVerifier(t *testing.T) {
+	v, err := newOIDCVerifier(config.OIDCConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	for name, cfg := range map[string]config.OIDCConfig{
+		"no audience":   {Issuer: testIssuer, JWKSURL: "http://idp"},
+		"no jwks":       {Issuer: testIssuer, Audience: testAudience},
+		"both jwks":     {Issuer: testIssuer, Audience: testAudience, JWKSURL: "http://idp", JWKSFile: "jwks.json"},
+		"unknown claim": {Issuer: testIssuer, Audience: testAudience, JWKSURL: "http://idp", UsernameClaim: "name"},
+		"missing file":  {Issuer: testIssuer, Audience: testAudience, JWKSFile: filepath.Join(t.TempDir(), "jwks.json")},
+	} {
+		_, err := newOIDCVerifier(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func jsonBody(t *testing.T, body any) *bytes.Buffer {
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	return &buf
+}
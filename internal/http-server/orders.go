@@ -0,0 +1,231 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxOrdersLimit      = 100
+	defaultRefundWindow = 14 * 24 * time.Hour
+)
+
+func (s *APIServer) GetApiOrders(ctx *gin.Context, req GetApiOrdersRequestObject) (GetApiOrdersResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiOrders401JSONResponse(errResp), nil
+	}
+	filter := storage.OrderFilter{Limit: maxOrdersLimit}
+	params := req.Params
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxOrdersLimit {
+			errResp := ErrorResponse{Errors: &invalidLimitErrMsg}
+			return GetApiOrders400JSONResponse(errResp), nil
+		}
+		filter.Limit = *params.Limit
+	}
+	if params.Cursor != nil {
+		cursor, err := parseOrderCursor(*params.Cursor)
+		if err != nil {
+			errResp := ErrorResponse{Errors: &invalidCursorErrMsg}
+			return GetApiOrders400JSONResponse(errResp), nil
+		}
+		filter.After = cursor
+	}
+	page, err := s.storage.Orders(ctx.GetString(usernameKey), filter)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+			return GetApiOrders401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiOrders500JSONResponse(errResp), nil
+	}
+	return GetApiOrders200JSONResponse(convertOrders(page)), nil
+}
+
+func (s *APIServer) GetApiAdminOrders(ctx *gin.Context, req GetApiAdminOrdersRequestObject) (GetApiAdminOrdersResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return GetApiAdminOrders401JSONResponse(errResp), nil
+	}
+	filter := storage.OrderFilter{Limit: maxOrdersLimit}
+	params := req.Params
+	if params.Status != nil {
+		switch *params.Status {
+		case Placed, Processing, Shipped, Delivered, Cancelled:
+			filter.Status = string(*params.Status)
+		default:
+			errResp := ErrorResponse{Errors: &invalidOrderStatusErrMsg}
+			return GetApiAdminOrders400JSONResponse(errResp), nil
+		}
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxOrdersLimit {
+			errResp := ErrorResponse{Errors: &invalidLimitErrMsg}
+			return GetApiAdminOrders400JSONResponse(errResp), nil
+		}
+		filter.Limit = *params.Limit
+	}
+	if params.Cursor != nil {
+		cursor, err := parseOrderCursor(*params.Cursor)
+		if err != nil {
+			errResp := ErrorResponse{Errors: &invalidCursorErrMsg}
+			return GetApiAdminOrders400JSONResponse(errResp), nil
+		}
+		filter.After = cursor
+	}
+	page, err := s.storage.AllOrders(filter)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return GetApiAdminOrders500JSONResponse(errResp), nil
+	}
+	return GetApiAdminOrders200JSONResponse(convertOrders(page)), nil
+}
+
+func (s *APIServer) PutApiAdminOrdersIdStatus(ctx *gin.Context, req PutApiAdminOrdersIdStatusRequestObject) (PutApiAdminOrdersIdStatusResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PutApiAdminOrdersIdStatus401JSONResponse(errResp), nil
+	}
+	status := req.Body.Status
+	switch status {
+	case Processing, Shipped, Delivered:
+	default:
+		errResp := ErrorResponse{Errors: &invalidNextOrderStatusErrMsg}
+		return PutApiAdminOrdersIdStatus400JSONResponse(errResp), nil
+	}
+	order, err := s.storage.SetOrderStatus(req.Id, string(status))
+	if err != nil {
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			errResp := ErrorResponse{Errors: &orderNotFoundErrMsg}
+			return PutApiAdminOrdersIdStatus404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrOrderStatus) {
+			errResp := ErrorResponse{Errors: &orderStatusErrMsg}
+			return PutApiAdminOrdersIdStatus409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PutApiAdminOrdersIdStatus500JSONResponse(errResp), nil
+	}
+	s.log.Info("order status changed", "admin", ctx.GetString(usernameKey), "order", req.Id, "status", status)
+	return PutApiAdminOrdersIdStatus200JSONResponse(convertOrder(*order)), nil
+}
+
+func (s *APIServer) PostApiOrdersIdCancel(ctx *gin.Context, req PostApiOrdersIdCancelRequestObject) (PostApiOrdersIdCancelResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiOrdersIdCancel401JSONResponse(errResp), nil
+	}
+	order, err := s.storage.CancelOrder(req.Id, ctx.GetString(usernameKey), time.Now().Add(-s.refundWindow))
+	if err != nil {
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			errResp := ErrorResponse{Errors: &orderNotFoundErrMsg}
+			return PostApiOrdersIdCancel404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrOrderStatus) {
+			errResp := ErrorResponse{Errors: &orderCancelledErrMsg}
+			return PostApiOrdersIdCancel409JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrRefundWindowExpired) {
+			errResp := ErrorResponse{Errors: &refundWindowExpiredErrMsg}
+			return PostApiOrdersIdCancel409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiOrdersIdCancel500JSONResponse(errResp), nil
+	}
+	return PostApiOrdersIdCancel200JSONResponse(convertOrder(*order)), nil
+}
+
+func (s *APIServer) PostApiAdminOrdersIdRefund(ctx *gin.Context, req PostApiAdminOrdersIdRefundRequestObject) (PostApiAdminOrdersIdRefundResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAdminOrdersIdRefund401JSONResponse(errResp), nil
+	}
+	//admins refund orders of any user, however old they are
+	order, err := s.storage.CancelOrder(req.Id, "", time.Time{})
+	if err != nil {
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			errResp := ErrorResponse{Errors: &orderNotFoundErrMsg}
+			return PostApiAdminOrdersIdRefund404JSONResponse(errResp), nil
+		}
+		if errors.Is(err, storage.ErrOrderStatus) {
+			errResp := ErrorResponse{Errors: &orderCancelledErrMsg}
+			return PostApiAdminOrdersIdRefund409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAdminOrdersIdRefund500JSONResponse(errResp), nil
+	}
+	s.log.Info("order refunded", "admin", ctx.GetString(usernameKey), "order", req.Id, "user", order.User, "amount", order.Total)
+	return PostApiAdminOrdersIdRefund200JSONResponse(convertOrder(*order)), nil
+}
+
+func convertOrders(page *storage.OrderPage) OrderListResponse {
+	list := make([]Order, len(page.Orders))
+	for i, order := range page.Orders {
+		list[i] = convertOrder(order)
+	}
+	resp := OrderListResponse{Orders: &list}
+	if page.Next != nil {
+		next := formatOrderCursor(page.Next)
+		resp.NextCursor = &next
+	}
+	return resp
+}
+
+// Order cursors have the form "<placed at, unix nanoseconds>-<order id>".
+func formatOrderCursor(c *storage.OrderCursor) string {
+	return fmt.Sprintf("%d-%d", c.PlacedAt.UnixNano(), c.ID)
+}
+
+func parseOrderCursor(s string) (*storage.OrderCursor, error) {
+	placedPart, idPart, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	placedAt, err := strconv.ParseInt(placedPart, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	return &storage.OrderCursor{PlacedAt: time.Unix(0, placedAt), ID: id}, nil
+}
+
+func convertOrder(order storage.Order) Order {
+	items := make([]OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = OrderItem{Item: &item.Item, Price: &item.Price, Quantity: &item.Quantity}
+	}
+	status := OrderStatus(order.Status)
+	return Order{
+		Id:           &order.ID,
+		User:         &order.User,
+		Status:       &status,
+		Total:        &order.Total,
+		Items:        &items,
+		PlacedAt:     &order.PlacedAt,
+		ProcessingAt: order.ProcessingAt,
+		ShippedAt:    order.ShippedAt,
+		DeliveredAt:  order.DeliveredAt,
+		CancelledAt:  order.CancelledAt,
+	}
+}
@@ -0,0 +1,181 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is the longest password bcrypt can hash.
+const maxPasswordBytes = 72
+
+// passwordPolicy holds the rules for new passwords and the bcrypt cost, see config.PasswordConfig.
+type passwordPolicy struct {
+	minLength  int
+	minClasses int
+	cost       int
+	// dummyHash is checked instead of a missing user's hash, so that the response
+	// time doesn't tell whether the user exists
+	dummyHash []byte
+}
+
+func newPasswordPolicy(cfg config.PasswordConfig) (passwordPolicy, error) {
+	p := passwordPolicy{minLength: cfg.MinLength, minClasses: cfg.MinClasses, cost: cfg.BcryptCost}
+	//a zero config, e.g. built in code, only rejects empty passwords
+	p.minLength = max(p.minLength, 1)
+	if p.minClasses > 4 {
+		return passwordPolicy{}, fmt.Errorf("password can't contain more than 4 character classes, got %d", p.minClasses)
+	}
+	if p.cost == 0 {
+		p.cost = bcrypt.DefaultCost
+	}
+	if p.cost < bcrypt.MinCost || p.cost > bcrypt.MaxCost {
+		return passwordPolicy{}, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, p.cost)
+	}
+	dummy := make([]byte, 16)
+	if _, err := rand.Read(dummy); err != nil {
+		return passwordPolicy{}, fmt.Errorf("failed to generate dummy password: %w", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword(dummy, p.cost)
+	if err != nil {
+		return passwordPolicy{}, fmt.Errorf("failed to hash dummy password: %w", err)
+	}
+	p.dummyHash = hash
+	return p, nil
+}
+
+// violation describes the first rule the password breaks, it is empty for a good password.
+func (p passwordPolicy) violation(pass string) string {
+	if len(pass) > maxPasswordBytes {
+		return fmt.Sprintf("Password must not be longer than %d bytes", maxPasswordBytes)
+	}
+	if utf8.RuneCountInString(pass) < p.minLength {
+		return fmt.Sprintf("Password must be at least %d characters long", p.minLength)
+	}
+	var lower, upper, digit, other bool
+	for _, r := range pass {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			classes++
+		}
+	}
+	if classes < p.minClasses {
+		return fmt.Sprintf("Password must contain at least %d of: lower case letters, upper case letters, digits, other characters", p.minClasses)
+	}
+	return ""
+}
+
+func (p passwordPolicy) hash(pass string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pass), p.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// compareDummy takes as long as checking a password against a stored hash and always fails.
+func (p passwordPolicy) compareDummy(pass string) {
+	bcrypt.CompareHashAndPassword(p.dummyHash, []byte(pass))
+}
+
+// upgradeHash rehashes the password after a successful login if the configured
+// bcrypt cost was raised. Errors are only logged, the login goes on with the old hash.
+func (s *APIServer) upgradeHash(name, passHash, pass string) {
+	cost, err := bcrypt.Cost([]byte(passHash))
+	if err != nil || cost >= s.passwords.cost {
+		return
+	}
+	newHash, err := s.passwords.hash(pass)
+	if err == nil {
+		err = s.storage.SetUserPassHash(name, newHash)
+	}
+	if err != nil {
+		s.log.Error("failed to upgrade password hash", "user", name, "error", err.Error())
+	}
+}
+
+func (s *APIServer) PostApiAuthPassword(ctx *gin.Context, req PostApiAuthPasswordRequestObject) (PostApiAuthPasswordResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAuthPassword401JSONResponse(errResp), nil
+	}
+	name := ctx.GetString(usernameKey)
+	oldPass, newPass := req.Body.OldPassword, req.Body.NewPassword
+	//a stolen access token must not help to guess the password
+	locked, err := s.loginAttempt(name)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	if locked > 0 {
+		errResp := ErrorResponse{Errors: &accountLockedErrMsg}
+		return PostApiAuthPassword423JSONResponse{Body: errResp, Headers: PostApiAuthPassword423ResponseHeaders{RetryAfter: retryAfter(locked)}}, nil
+	}
+	passHash, err := s.storage.UserPassHash(name)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passHash), []byte(oldPass)); err != nil {
+		if err := s.loginFailed(ctx.ClientIP()); err != nil {
+			s.log.Error(err.Error())
+			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+			return PostApiAuthPassword500JSONResponse(errResp), err
+		}
+		errResp := ErrorResponse{Errors: &wrongPasswordErrMsg}
+		return PostApiAuthPassword403JSONResponse(errResp), nil
+	}
+	if err := s.storage.ResetLoginAttempts(userLoginKey(name)); err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	if msg := s.passwords.violation(newPass); msg != "" {
+		errResp := ErrorResponse{Errors: &msg}
+		return PostApiAuthPassword400JSONResponse(errResp), nil
+	}
+	if newPass == oldPass {
+		errResp := ErrorResponse{Errors: &samePasswordErrMsg}
+		return PostApiAuthPassword400JSONResponse(errResp), nil
+	}
+	newHash, err := s.passwords.hash(newPass)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	//sessions started with the old password end here, the caller gets a new one
+	if err := s.storage.ChangeUserPassHash(name, newHash, revocationTime()); err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	authResp, err := s.issueTokens(name)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthPassword500JSONResponse(errResp), err
+	}
+	s.log.Info("password changed", "user", name)
+	return PostApiAuthPassword200JSONResponse(authResp), nil
+}
@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// operationRoles lists the roles allowed to call an operation.
+// Operations not listed here are open to every authenticated user.
+var operationRoles = map[string][]string{
+	"PostApiAdminCoinsGrant":                     {storage.RoleAdmin},
+	"PostApiAdminCoinsClawback":                  {storage.RoleAdmin},
+	"PostApiAdminMerch":                          {storage.RoleAdmin},
+	"PatchApiAdminMerchItem":                     {storage.RoleAdmin},
+	"DeleteApiAdminMerchItem":                    {storage.RoleAdmin},
+	"PutApiAdminUsersUsernameRole":               {storage.RoleAdmin},
+	"PostApiAdminUsersUsernameRevokeSessions":    {storage.RoleAdmin},
+	"DeleteApiAdminUsersUsernameLockout":         {storage.RoleAdmin},
+	"PostApiAdminServiceAccounts":                {storage.RoleAdmin},
+	"GetApiAdminServiceAccountsAccountKeys":      {storage.RoleAdmin},
+	"PostApiAdminServiceAccountsAccountKeys":     {storage.RoleAdmin},
+	"DeleteApiAdminServiceAccountsAccountKeysId": {storage.RoleAdmin},
+	"GetApiAdminUsersUsernameBalance":            {storage.RoleAdmin},
+	"GetApiAdminOrders":                          {storage.RoleAdmin},
+	"PostApiAdminOrdersIdRefund":                 {storage.RoleAdmin},
+	"PutApiAdminOrdersIdStatus":                  {storage.RoleAdmin},
+}
+
+// operationScopes lists the API key scope required to call an operation.
+// Requests made with an API key may only call operations listed here.
+var operationScopes = map[string]ApiKeyScope{
+	"PostApiAdminCoinsGrant":          CoinsGrant,
+	"PostApiAdminCoinsClawback":       CoinsClawback,
+	"GetApiAdminUsersUsernameBalance": BalancesRead,
+}
+
+// PolicyMiddleware rejects requests of authenticated users whose role is not allowed
+// to call the operation, and requests made with an API key that lacks the scope of the operation.
+// It relies on AuthMiddleware, unauthenticated requests are passed through for the handler to answer with 401.
+func (s *APIServer) PolicyMiddleware(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+	roles, restricted := operationRoles[operationID]
+	scope, scoped := operationScopes[operationID]
+
+	return func(ctx *gin.Context, request interface{}) (interface{}, error) {
+		if !ctx.GetBool(authorizedKey) {
+			return f(ctx, request)
+		}
+		if scopes, ok := ctx.Get(scopesKey); ok {
+			if !scoped || !slices.Contains(scopes.([]string), string(scope)) {
+				ctx.JSON(http.StatusForbidden, ErrorResponse{Errors: &forbiddenErrMsg})
+				return nil, nil
+			}
+			return f(ctx, request)
+		}
+		if restricted && !slices.Contains(roles, ctx.GetString(roleKey)) {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Errors: &forbiddenErrMsg})
+			return nil, nil
+		}
+		return f(ctx, request)
+	}
+}
@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+func (s *APIServer) PostApiAuthRefresh(ctx *gin.Context, req PostApiAuthRefreshRequestObject) (PostApiAuthRefreshResponseObject, error) {
+	refresh := strings.TrimSpace(req.Body.RefreshToken)
+	if refresh == "" {
+		errResp := ErrorResponse{Errors: &emptyRefreshTokenErrMsg}
+		return PostApiAuthRefresh400JSONResponse(errResp), nil
+	}
+	next, nextHash, err := newRefreshToken()
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthRefresh500JSONResponse(errResp), err
+	}
+	name, err := s.storage.RotateRefreshToken(hashSecret(refresh), nextHash, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrTokenReused):
+			s.log.Warn("refresh token reused, token family revoked")
+			fallthrough
+		case errors.Is(err, storage.ErrTokenNotFound), errors.Is(err, storage.ErrTokenExpired), errors.Is(err, storage.ErrTokenRevoked):
+			errResp := ErrorResponse{Errors: &invalidRefreshTokenErrMsg}
+			return PostApiAuthRefresh401JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthRefresh500JSONResponse(errResp), err
+	}
+	authResp, err := s.authResponse(name, next)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthRefresh500JSONResponse(errResp), err
+	}
+	return PostApiAuthRefresh200JSONResponse(authResp), nil
+}
+
+func (s *APIServer) PostApiAuthLogout(ctx *gin.Context, req PostApiAuthLogoutRequestObject) (PostApiAuthLogoutResponseObject, error) {
+	authorized := ctx.GetBool(authorizedKey)
+	if !authorized {
+		errResp := ErrorResponse{Errors: &unauthorizedErrMsg}
+		return PostApiAuthLogout401JSONResponse(errResp), nil
+	}
+	//tokens issued before revocation was introduced have no id and expire on their own
+	if id := ctx.GetString(tokenIDKey); id != "" {
+		if err := s.storage.RevokeToken(id, ctx.GetTime(tokenExpiresKey)); err != nil {
+			s.log.Error(err.Error())
+			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+			return PostApiAuthLogout500JSONResponse(errResp), err
+		}
+	}
+	if req.Body.RefreshToken != nil && *req.Body.RefreshToken != "" {
+		if err := s.storage.RevokeRefreshToken(hashSecret(*req.Body.RefreshToken)); err != nil {
+			s.log.Error(err.Error())
+			errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+			return PostApiAuthLogout500JSONResponse(errResp), err
+		}
+	}
+	return PostApiAuthLogout204Response{}, nil
+}
+
+// issueTokens starts a new refresh token family for the user and returns it with an access token.
+func (s *APIServer) issueTokens(name string) (AuthResponse, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	//the hash of the first token is unique, so it doubles as the family id
+	token := storage.RefreshToken{Hash: hash, Family: hash, ExpiresAt: time.Now().Add(s.refreshTTL)}
+	if err := s.storage.AddRefreshToken(name, token); err != nil {
+		return AuthResponse{}, err
+	}
+	return s.authResponse(name, refresh)
+}
+
+// authResponse creates an access token with the current role of the user.
+func (s *APIServer) authResponse(name, refresh string) (AuthResponse, error) {
+	role, err := s.userRole(name)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	token, err := createToken(name, role, s.jwtKeys.current, s.accessTTL)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	expiresIn := int(s.accessTTL.Seconds())
+	return AuthResponse{Token: &token, RefreshToken: &refresh, ExpiresIn: &expiresIn}, nil
+}
+
+// newRefreshToken returns an opaque refresh token and the hash it is stored under.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashSecret(token), nil
+}
+
+// hashSecret returns the hash random secrets such as refresh tokens and API keys are stored under.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+var usernameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,31}$`)
+
+func (s *APIServer) PostApiAuthRegister(ctx *gin.Context, req PostApiAuthRegisterRequestObject) (PostApiAuthRegisterResponseObject, error) {
+	if s.signupMode == config.SignupDisabled {
+		errResp := ErrorResponse{Errors: &registrationDisabledErrMsg}
+		return PostApiAuthRegister403JSONResponse(errResp), nil
+	}
+	name, pass := req.Body.Username, req.Body.Password
+	if !validUsername(name) {
+		errResp := ErrorResponse{Errors: &invalidUsernameErrMsg}
+		return PostApiAuthRegister400JSONResponse(errResp), nil
+	}
+	if msg := s.passwords.violation(pass); msg != "" {
+		errResp := ErrorResponse{Errors: &msg}
+		return PostApiAuthRegister400JSONResponse(errResp), nil
+	}
+	err := s.createUser(name, pass)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			errResp := ErrorResponse{Errors: &userExistsErrMsg}
+			return PostApiAuthRegister409JSONResponse(errResp), nil
+		}
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthRegister500JSONResponse(errResp), err
+	}
+	authResp, err := s.issueTokens(name)
+	if err != nil {
+		s.log.Error(err.Error())
+		errResp := ErrorResponse{Errors: &internalServerErrorMsg}
+		return PostApiAuthRegister500JSONResponse(errResp), err
+	}
+	created := true
+	authResp.Created = &created
+	return PostApiAuthRegister201JSONResponse(authResp), nil
+}
+
+// validUsername checks the rules for new usernames.
+// Users created before the rules were introduced can still log in.
+func validUsername(name string) bool {
+	return usernameRe.MatchString(name)
+}
+
+// createUser stores a user, the password must already be checked against the policy.
+func (s *APIServer) createUser(name, pass string) error {
+	passHash, err := s.passwords.hash(pass)
+	if err != nil {
+		return err
+	}
+	return s.storage.AddUser(name, passHash)
+}
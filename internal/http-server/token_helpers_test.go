@@ -1,43 +1,82 @@
 package httpserver
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateToken(t *testing.T) {
-	secretKey := []byte("mysecret")
+	secretKey := signingKey{id: "test", secret: []byte("mysecret")}
 
 	// Сценарий 1: Корректное создание токена
-	token, err := createToken("testuser", secretKey)
+	token, err := createToken("testuser", storage.RoleUser, secretKey, time.Hour)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
 	// Сценарий 2: Ошибка при создании токена (например, если секретный ключ пуст)
-	emptySecretKey := []byte("")
-	token, err = createToken("testuser", emptySecretKey)
+	emptySecretKey := signingKey{id: "test"}
+	token, err = createToken("testuser", storage.RoleUser, emptySecretKey, time.Hour)
 	assert.Error(t, err)
 	assert.Empty(t, token)
+
+	// Сценарий 3: Истекший токен не проходит проверку
+	token, err = createToken("testuser", storage.RoleUser, secretKey, -time.Minute)
+	assert.NoError(t, err)
+	_, _, err = GetUserFromToken(token, keySet{current: secretKey})
+	assert.Error(t, err)
+
+	// Сценарий 4: Токен содержит уникальный идентификатор и точное время выдачи
+	issuedAt := time.Now()
+	token, _ = createToken("testuser", storage.RoleUser, secretKey, time.Hour)
+	other, _ := createToken("testuser", storage.RoleUser, secretKey, time.Hour)
+	claims, err := parseToken(token, keySet{current: secretKey})
+	assert.NoError(t, err)
+	otherClaims, err := parseToken(other, keySet{current: secretKey})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.id)
+	assert.NotEqual(t, claims.id, otherClaims.id)
+	assert.False(t, claims.issuedAt.Before(issuedAt.Truncate(time.Microsecond)))
+	assert.WithinDuration(t, issuedAt.Add(time.Hour), claims.expiresAt, time.Second)
 }
 
 func TestGetUserFromToken(t *testing.T) {
 	secretKey := []byte("mysecret")
+	keys := keySet{current: signingKey{id: "test", secret: secretKey}}
 
 	// Сценарий 1: Корректный токен
-	token, _ := createToken("testuser", secretKey)
-	name, err := GetUserFromToken(token, secretKey)
+	token, _ := createToken("testuser", storage.RoleAdmin, keys.current, time.Hour)
+	name, role, err := GetUserFromToken(token, keys)
 	assert.NoError(t, err)
 	assert.Equal(t, "testuser", name)
+	assert.Equal(t, storage.RoleAdmin, role)
 
 	// Сценарий 2: Некорректный токен
 	invalidToken := "invalid.token.string"
-	name, err = GetUserFromToken(invalidToken, secretKey)
+	name, _, err = GetUserFromToken(invalidToken, keys)
 	assert.Empty(t, name)
 	assert.Error(t, err)
+
+	// Сценарий 3: Токен без роли, выданный до появления ролей
+	token, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"name": "testuser"}).SignedString(secretKey)
+	_, role, err = GetUserFromToken(token, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.RoleUser, role)
 }
 func TestGetTokenFromContext(t *testing.T) {
 	// Создаем тестовый контекст
@@ -65,17 +104,127 @@ func TestGetTokenFromContext(t *testing.T) {
 	assert.Empty(t, token)
 }
 func TestValidateToken(t *testing.T) {
-	secretKey := []byte("mysecret")
+	keys := keySet{current: signingKey{id: "test", secret: []byte("mysecret")}}
 
 	// Сценарий 1: Корректный токен
-	token, _ := createToken("testuser", secretKey)
-	claims, err := validateToken(token, secretKey)
+	token, _ := createToken("testuser", storage.RoleUser, keys.current, time.Hour)
+	claims, err := validateToken(token, keys)
 	assert.NoError(t, err)
 	assert.Equal(t, "testuser", claims["name"])
 
 	// Сценарий 2: Некорректный токен
 	invalidToken := "invalid.token.string"
-	claims, err = validateToken(invalidToken, secretKey)
+	claims, err = validateToken(invalidToken, keys)
 	assert.Empty(t, claims)
 	assert.Error(t, err)
 }
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := signingKey{id: "2025-01", secret: []byte("oldsecret")}
+	newKey := signingKey{id: "2025-02", secret: []byte("newsecret")}
+	oldToken, _ := createToken("testuser", storage.RoleUser, oldKey, time.Hour)
+
+	// Сценарий 1: Токен, подписанный предыдущим ключом, действует после ротации
+	rotated := keySet{current: newKey, previous: []signingKey{oldKey}}
+	_, err := validateToken(oldToken, rotated)
+	assert.NoError(t, err)
+	newToken, _ := createToken("testuser", storage.RoleUser, rotated.current, time.Hour)
+	_, err = validateToken(newToken, rotated)
+	assert.NoError(t, err)
+
+	// Сценарий 2: После удаления предыдущего ключа токен больше не действует
+	_, err = validateToken(oldToken, keySet{current: newKey})
+	assert.Error(t, err)
+
+	// Сценарий 3: Токен с чужим kid не проверяется текущим ключом
+	forged := signingKey{id: "2025-03", secret: newKey.secret}
+	forgedToken, _ := createToken("testuser", storage.RoleUser, forged, time.Hour)
+	_, err = validateToken(forgedToken, rotated)
+	assert.Error(t, err)
+}
+
+func TestLoadKeys(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+
+	// Сценарий 1: В production без секрета сервис не запускается
+	_, _, err := loadKeys(&config.Config{Env: config.EnvProduction, JWT: config.JWTConfig{KeyID: "k1"}})
+	assert.Error(t, err)
+
+	// Сценарий 2: Локально без секрета генерируется случайный ключ
+	keys, generated, err := loadKeys(&config.Config{Env: "local", JWT: config.JWTConfig{KeyID: "k1"}})
+	assert.NoError(t, err)
+	assert.True(t, generated)
+	assert.Len(t, keys.current.secret, minSecretLen)
+
+	// Сценарий 3: Секрет из файла и предыдущий ключ
+	file := filepath.Join(t.TempDir(), "jwt-secret")
+	assert.NoError(t, os.WriteFile(file, []byte(secret+"\n"), 0o600))
+	keys, generated, err = loadKeys(&config.Config{Env: config.EnvProduction, JWT: config.JWTConfig{
+		SecretFile:     file,
+		KeyID:          "k2",
+		PreviousSecret: "previous",
+		PreviousKeyID:  "k1",
+	}})
+	assert.NoError(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, signingKey{id: "k2", secret: []byte(secret)}, keys.current)
+	assert.Equal(t, []signingKey{{id: "k1", secret: []byte("previous")}}, keys.previous)
+
+	// Сценарий 4: Короткий секрет в production
+	_, _, err = loadKeys(&config.Config{Env: config.EnvProduction, JWT: config.JWTConfig{Secret: "short", KeyID: "k1"}})
+	assert.Error(t, err)
+
+	// Сценарий 5: Предыдущий ключ без собственного kid
+	_, _, err = loadKeys(&config.Config{JWT: config.JWTConfig{Secret: secret, KeyID: "k1", PreviousSecret: "previous"}})
+	assert.Error(t, err)
+}
+
+func TestRS256(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: mustMarshalPKIX(t, &private.PublicKey)})
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	dir := t.TempDir()
+	privateFile, publicFile := filepath.Join(dir, "jwt.key"), filepath.Join(dir, "jwt.pub")
+	assert.NoError(t, os.WriteFile(privateFile, privatePEM, 0o600))
+	assert.NoError(t, os.WriteFile(publicFile, publicPEM, 0o644))
+
+	// Сценарий 1: Ключи из PEM-файлов, предыдущий ключ только для проверки
+	keys, _, err := loadKeys(&config.Config{Env: config.EnvProduction, JWT: config.JWTConfig{
+		PrivateKeyFile:        privateFile,
+		KeyID:                 "rsa-2",
+		PreviousPublicKeyFile: publicFile,
+		PreviousKeyID:         "rsa-1",
+	}})
+	assert.NoError(t, err)
+	token, err := createToken("testuser", storage.RoleUser, keys.current, time.Hour)
+	assert.NoError(t, err)
+	claims, err := validateToken(token, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", claims["name"])
+
+	// Сценарий 2: Токен подписан HMAC с публичным ключом в качестве секрета
+	forged, _ := createToken("testuser", storage.RoleAdmin, signingKey{id: "rsa-1", secret: publicPEM}, time.Hour)
+	_, err = validateToken(forged, keys)
+	assert.Error(t, err)
+
+	// Сценарий 3: Публикуются оба публичных ключа
+	jwks := keys.jwks()
+	assert.Len(t, jwks, 2)
+	assert.Equal(t, "rsa-2", jwks[0].Kid)
+	assert.Equal(t, "RS256", jwks[0].Alg)
+	n, err := base64.RawURLEncoding.DecodeString(jwks[0].N)
+	assert.NoError(t, err)
+	assert.Equal(t, private.N, new(big.Int).SetBytes(n))
+	assert.Equal(t, "AQAB", jwks[0].E)
+
+	// Сценарий 4: HMAC-ключи не публикуются
+	assert.Empty(t, keySet{current: signingKey{id: "test", secret: []byte("mysecret")}}.jwks())
+}
+
+func mustMarshalPKIX(t *testing.T, key *rsa.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	return der
+}
@@ -1,10 +1,14 @@
 package httpserver
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
@@ -22,37 +26,106 @@ func GetTokenFromContext(c *gin.Context) (string, error) {
 
 	return jwtToken[1], nil
 }
-func GetUserFromToken(token string, secretKey []byte) (string, error) {
-	claims, err := validateToken(token, secretKey)
+
+// tokenClaims are the claims of a validated access token.
+type tokenClaims struct {
+	name string
+	role string
+	// id is empty for tokens issued before revocation was introduced
+	id        string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// GetUserFromToken returns the user name and role from the token.
+// Tokens issued before roles were introduced carry no role claim and get the user role.
+func GetUserFromToken(token string, keys keySet) (string, string, error) {
+	claims, err := parseToken(token, keys)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	return claims.name, claims.role, nil
+}
+
+func parseToken(token string, keys keySet) (tokenClaims, error) {
+	claims, err := validateToken(token, keys)
+	if err != nil {
+		return tokenClaims{}, err
+	}
+	name, ok := claims["name"].(string)
+	if !ok {
+		return tokenClaims{}, fmt.Errorf("token has no name claim")
+	}
+	role, ok := claims["role"].(string)
+	if !ok {
+		role = storage.RoleUser
 	}
-	name := claims["name"].(string)
-	return name, nil
+	id, _ := claims["jti"].(string)
+	return tokenClaims{
+		name:      name,
+		role:      role,
+		id:        id,
+		issuedAt:  numericDate(claims["iat"]),
+		expiresAt: numericDate(claims["exp"]),
+	}, nil
 }
-func createToken(name string, secretKey []byte) (string, error) {
-	if len(secretKey) == 0 {
+
+// numericDate converts a JWT NumericDate claim, a missing claim gives the zero time.
+func numericDate(claim interface{}) time.Time {
+	seconds, ok := claim.(float64)
+	if !ok {
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(math.Round(seconds * 1e6)))
+}
+
+// revocationTime is the cutoff for revoking all sessions of a user. It is taken
+// from the clock setting iat, truncated like iat, so that tokens issued right
+// after the revocation stay valid.
+func revocationTime() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+func createToken(name, role string, key signingKey, ttl time.Duration) (string, error) {
+	if len(key.secret) == 0 && key.private == nil {
 		return "", fmt.Errorf("no secret key provided")
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(key.method(), jwt.MapClaims{
 		"name": name,
-		"iat":  time.Now().Unix(),
-		"exp":  time.Now().Add(time.Hour * 72).Unix(),
+		"role": role,
+		"jti":  hex.EncodeToString(id),
+		//iat keeps microseconds, so that tokens issued right after all sessions
+		//of the user are revoked are not rejected
+		"iat": float64(now.UnixMicro()) / 1e6,
+		"exp": now.Add(ttl).Unix(),
 	})
 
-	tokenString, err := token.SignedString(secretKey)
+	token.Header["kid"] = key.id
+
+	tokenString, err := token.SignedString(key.signKey())
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-func validateToken(tokenString string, secretKey []byte) (jwt.MapClaims, error) {
+func validateToken(tokenString string, keys keySet) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		//the algorithm must match the key, otherwise a public RSA key could be used as an HMAC secret
+		if token.Method.Alg() != key.method().Alg() {
 			return nil, fmt.Errorf("wrong signing method")
 		}
-		return secretKey, nil
+		return key.verifyKey(), nil
 	})
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
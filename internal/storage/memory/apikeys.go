@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+func (s *Storage) AddServiceAccount(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[name]; ok {
+		return storage.ErrUserExists
+	}
+	s.users[name] = &user{
+		role:      storage.RoleService,
+		inventory: make(map[string]int),
+	}
+	return nil
+}
+
+// isServiceAccount must be called with s.mu held.
+func (s *Storage) isServiceAccount(name string) bool {
+	u, ok := s.users[name]
+	return ok && u.role == storage.RoleService
+}
+
+func (s *Storage) AddAPIKey(key storage.APIKey) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isServiceAccount(key.Account) {
+		return 0, storage.ErrUserNotFound
+	}
+	key.ID = len(s.apiKeys) + 1
+	key.Scopes = slices.Clone(key.Scopes)
+	key.CreatedAt = time.Now()
+	key.LastUsedAt, key.RevokedAt = nil, nil
+	s.apiKeys = append(s.apiKeys, &key)
+	return key.ID, nil
+}
+
+func (s *Storage) APIKeys(account string) ([]storage.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isServiceAccount(account) {
+		return nil, storage.ErrUserNotFound
+	}
+	keys := []storage.APIKey{}
+	for _, key := range s.apiKeys {
+		if key.Account == account {
+			k := *key
+			k.Hash = ""
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(account string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.apiKeys {
+		if key.ID == id && key.Account == account && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return storage.ErrAPIKeyNotFound
+}
+
+func (s *Storage) UseAPIKey(hash string) (*storage.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.apiKeys {
+		if u, ok := s.users[key.Account]; ok && u.role == storage.RoleService && key.Hash == hash && key.RevokedAt == nil {
+			now := time.Now()
+			key.LastUsedAt = &now
+			k := *key
+			return &k, nil
+		}
+	}
+	return nil, storage.ErrAPIKeyNotFound
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// cartLine refers to the catalog item itself, so that carts follow renames
+// and retirement like the cart_items table does with merch_id.
+type cartLine struct {
+	item     *merchItem
+	quantity int
+}
+
+func (s *Storage) SetCartItem(name, item string, quantity int) error {
+	if quantity < 1 {
+		return storage.ErrInvalidQuantity
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	merchItem := s.findItem(item)
+	if merchItem == nil {
+		return storage.ErrItemNotFound
+	}
+	if !merchItem.Available {
+		return storage.ErrItemUnavailable
+	}
+	for _, line := range u.cart {
+		if line.item == merchItem {
+			line.quantity = quantity
+			return nil
+		}
+	}
+	u.cart = append(u.cart, &cartLine{item: merchItem, quantity: quantity})
+	return nil
+}
+
+func (s *Storage) RemoveCartItem(name, item string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	i := slices.IndexFunc(u.cart, func(line *cartLine) bool { return line.item.Name == item })
+	if i < 0 {
+		return storage.ErrCartItemNotFound
+	}
+	u.cart = slices.Delete(u.cart, i, i+1)
+	return nil
+}
+
+func (s *Storage) Cart(name string) (*storage.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	return newCart(u.cart), nil
+}
+
+func (s *Storage) Checkout(name string) (*storage.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	if len(u.cart) == 0 {
+		return nil, storage.ErrCartEmpty
+	}
+	cart := newCart(u.cart)
+	var lineErrs []storage.CheckoutLineError
+	for _, line := range u.cart {
+		switch {
+		case line.item.retired:
+			lineErrs = append(lineErrs, storage.CheckoutLineError{Item: line.item.Name, Err: storage.ErrItemNotFound})
+		case !line.item.Available:
+			lineErrs = append(lineErrs, storage.CheckoutLineError{Item: line.item.Name, Err: storage.ErrItemUnavailable})
+		}
+	}
+	if len(lineErrs) > 0 || u.coins < cart.Total {
+		return nil, &storage.CheckoutError{Lines: lineErrs, Total: cart.Total, Balance: u.coins}
+	}
+	s.record(storage.KindPurchase, name, "", cart.Total,
+		posting{account: accountUser, user: name, amount: -cart.Total},
+		posting{account: accountShop, amount: cart.Total},
+	)
+	now := time.Now()
+	items := make([]storage.OrderItem, len(cart.Items))
+	for i, item := range cart.Items {
+		u.inventory[item.Item] += item.Quantity
+		u.purchases = append(u.purchases, storage.Purchase{Item: item.Item, Price: item.Price, Quantity: item.Quantity, CreatedAt: now})
+		items[i] = storage.OrderItem{Item: item.Item, Price: item.Price, Quantity: item.Quantity}
+	}
+	s.placeOrder(name, items, now)
+	u.cart = nil
+	return cart, nil
+}
+
+func newCart(lines []*cartLine) *storage.Cart {
+	cart := &storage.Cart{Items: make([]storage.CartItem, len(lines))}
+	for i, line := range lines {
+		cart.Items[i] = storage.CartItem{
+			Item:      line.item.Name,
+			Price:     line.item.Price,
+			Quantity:  line.quantity,
+			Available: line.item.Available && !line.item.retired,
+		}
+		cart.Total += line.item.Price * line.quantity
+	}
+	return cart
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+type idempotencyKey struct {
+	fingerprint string
+	// response is nil while the request is in progress
+	response  *storage.IdempotentResponse
+	createdAt time.Time
+}
+
+func (s *Storage) ReserveIdempotencyKey(user, key, fingerprint string, since, pendingSince time.Time) (*storage.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[user]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	if u.idempotencyKeys == nil {
+		u.idempotencyKeys = make(map[string]*idempotencyKey)
+	}
+	for k, ik := range u.idempotencyKeys {
+		if ik.createdAt.Before(since) {
+			delete(u.idempotencyKeys, k)
+		}
+	}
+	ik, ok := u.idempotencyKeys[key]
+	if !ok {
+		u.idempotencyKeys[key] = &idempotencyKey{fingerprint: fingerprint, createdAt: time.Now()}
+		return nil, nil
+	}
+	if ik.fingerprint != fingerprint {
+		return nil, storage.ErrIdempotencyKeyReused
+	}
+	if ik.response == nil && ik.createdAt.Before(pendingSince) {
+		return nil, storage.ErrIdempotencyKeyOutcomeUnknown
+	}
+	if ik.response == nil {
+		return nil, storage.ErrIdempotencyKeyInProgress
+	}
+	resp := *ik.response
+	resp.Body = slices.Clone(resp.Body)
+	return &resp, nil
+}
+
+func (s *Storage) CompleteIdempotencyKey(user, key string, resp storage.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[user]; ok {
+		if ik, ok := u.idempotencyKeys[key]; ok {
+			resp.Body = slices.Clone(resp.Body)
+			ik.response = &resp
+		}
+	}
+	return nil
+}
+
+func (s *Storage) ReleaseIdempotencyKey(user, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[user]; ok {
+		if ik, ok := u.idempotencyKeys[key]; ok && ik.response == nil {
+			delete(u.idempotencyKeys, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+func (s *Storage) LoginAttempts(key string) (*storage.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.loginAttempts[key]
+	if !ok {
+		return &storage.LoginAttempts{}, nil
+	}
+	a := *attempts
+	return &a, nil
+}
+
+func (s *Storage) AddLoginFailure(key string, since time.Time, lockout storage.LoginLockout) (*storage.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.loginAttempts[key]
+	if !ok {
+		attempts = &storage.LoginAttempts{}
+		s.loginAttempts[key] = attempts
+	}
+	now := time.Now()
+	if attempts.LockedUntil.After(now) {
+		a := *attempts
+		return &a, storage.ErrLoginLocked
+	}
+	if attempts.LastFailureAt.Before(since) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	if d := lockout.LockFor(attempts.Failures); d > 0 {
+		attempts.LockedUntil = now.Add(d)
+	}
+	a := *attempts
+	return &a, nil
+}
+
+func (s *Storage) ResetLoginAttempts(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loginAttempts, key)
+	return nil
+}
@@ -0,0 +1,587 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// defaultMerch mirrors the seed data from migrations/1_init.up.sql and 5_merch_catalog.up.sql.
+var defaultMerch = []storage.MerchItem{
+	{Name: "t-shirt", Price: 80, Description: "Футболка с логотипом", Available: true},
+	{Name: "cup", Price: 20, Description: "Кружка с логотипом", Available: true},
+	{Name: "book", Price: 50, Description: "Блокнот", Available: true},
+	{Name: "pen", Price: 10, Description: "Ручка", Available: true},
+	{Name: "powerbank", Price: 200, Description: "Внешний аккумулятор", Available: true},
+	{Name: "hoody", Price: 300, Description: "Худи с логотипом", Available: true},
+	{Name: "umbrella", Price: 200, Description: "Зонт", Available: true},
+	{Name: "socks", Price: 10, Description: "Носки", Available: true},
+	{Name: "wallet", Price: 50, Description: "Кошелек", Available: true},
+	{Name: "pink-hoody", Price: 500, Description: "Розовое худи", Available: true},
+}
+
+// Ledger accounts, see migrations/2_ledger.up.sql.
+const (
+	accountUser     = "user"
+	accountIssuance = "issuance"
+	accountShop     = "shop"
+)
+
+// Storage is an in-memory implementation of the shop storage.
+// It is safe for concurrent use; all operations are serialized by a single mutex.
+type Storage struct {
+	mu           sync.Mutex
+	users        map[string]*user
+	merch        []*merchItem
+	transactions []transaction
+	ledger       []posting
+	// refreshTokens are keyed by token hash
+	refreshTokens map[string]*refreshToken
+	// revokedTokens maps access token ids to their expiration time
+	revokedTokens map[string]time.Time
+	loginAttempts map[string]*storage.LoginAttempts
+	apiKeys       []*storage.APIKey
+	orders        []*storage.Order
+}
+
+type user struct {
+	passHash string
+	role     string
+	// issuer is the identity provider the user is linked to, empty for local users
+	issuer string
+	// coins caches the sum of the user's postings in the ledger
+	coins     int
+	inventory map[string]int
+	purchases []storage.Purchase
+	// access tokens issued before tokensValidAfter are rejected
+	tokensValidAfter time.Time
+	idempotencyKeys  map[string]*idempotencyKey
+	cart             []*cartLine
+}
+
+type merchItem struct {
+	storage.MerchItem
+	retired bool
+}
+
+type transaction struct {
+	id        int
+	kind      string
+	fromUser  string
+	toUser    string
+	amount    int
+	createdAt time.Time
+	// reason and createdBy are set for grants and clawbacks
+	reason    string
+	createdBy string
+	// orderID is set for refunds
+	orderID int
+}
+
+type posting struct {
+	transactionID int
+	account       string
+	user          string
+	amount        int
+}
+
+func New() *Storage {
+	merch := make([]*merchItem, len(defaultMerch))
+	for i, item := range defaultMerch {
+		merch[i] = &merchItem{MerchItem: item}
+	}
+	return &Storage{
+		users:         make(map[string]*user),
+		merch:         merch,
+		refreshTokens: make(map[string]*refreshToken),
+		revokedTokens: make(map[string]time.Time),
+		loginAttempts: make(map[string]*storage.LoginAttempts),
+	}
+}
+
+func (s *Storage) AddUser(name, passHash string) error {
+	return s.addUser(name, &user{passHash: passHash})
+}
+
+func (s *Storage) AddExternalUser(name, issuer string) error {
+	return s.addUser(name, &user{issuer: issuer})
+}
+
+func (s *Storage) UserIssuer(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return "", storage.ErrUserNotFound
+	}
+	return u.issuer, nil
+}
+
+func (s *Storage) addUser(name string, u *user) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[name]; ok {
+		return storage.ErrUserExists
+	}
+	u.role = storage.RoleUser
+	u.inventory = make(map[string]int)
+	s.users[name] = u
+	s.record(storage.KindSignup, "", name, storage.StartBalance,
+		posting{account: accountIssuance, amount: -storage.StartBalance},
+		posting{account: accountUser, user: name, amount: storage.StartBalance},
+	)
+	return nil
+}
+
+func (s *Storage) UserPassHash(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return "", storage.ErrUserNotFound
+	}
+	return u.passHash, nil
+}
+
+func (s *Storage) UserBalance(name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return 0, storage.ErrUserNotFound
+	}
+	return u.coins, nil
+}
+
+func (s *Storage) SetUserPassHash(name, passHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	u.passHash = passHash
+	return nil
+}
+
+func (s *Storage) UserExist(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.users[name]
+	return ok, nil
+}
+
+func (s *Storage) UserRole(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return "", storage.ErrUserNotFound
+	}
+	return u.role, nil
+}
+
+func (s *Storage) SetUserRole(name, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	if u.role == storage.RoleService || role == storage.RoleService {
+		return storage.ErrServiceAccountRole
+	}
+	u.role = role
+	return nil
+}
+
+func (s *Storage) SendCoins(fromUser string, toUser string, amount int) error {
+	if amount <= 0 {
+		return storage.ErrInvalidAmount
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from, ok := s.users[fromUser]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	if from.coins < amount {
+		return storage.ErrUnsufficientBalance
+	}
+	if _, ok := s.users[toUser]; !ok {
+		return storage.ErrUserNotFound
+	}
+	s.record(storage.KindTransfer, fromUser, toUser, amount,
+		posting{account: accountUser, user: fromUser, amount: -amount},
+		posting{account: accountUser, user: toUser, amount: amount},
+	)
+	return nil
+}
+
+func (s *Storage) UserInfo(name string) (*storage.UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	userInfo := storage.UserInfo{Coins: u.coins}
+	for item, quantity := range u.inventory {
+		userInfo.Inventory = append(userInfo.Inventory, storage.InventoryEntry{Type: item, Quantity: quantity})
+	}
+	sort.Slice(userInfo.Inventory, func(i, j int) bool {
+		return userInfo.Inventory[i].Type < userInfo.Inventory[j].Type
+	})
+	//newest first
+	for i := len(s.transactions) - 1; i >= 0; i-- {
+		t := s.transactions[i]
+		isGrant := t.kind == storage.KindGrant && t.toUser == name
+		isClawback := t.kind == storage.KindClawback && t.fromUser == name
+		if (isGrant || isClawback) && len(userInfo.CoinHistory.Adjustments) < storage.InfoHistoryLimit {
+			userInfo.CoinHistory.Adjustments = append(userInfo.CoinHistory.Adjustments, storage.Adjustment{
+				ID:        t.id,
+				Kind:      t.kind,
+				Amount:    t.amount,
+				Reason:    t.reason,
+				CreatedAt: t.createdAt,
+			})
+		}
+		if t.kind == storage.KindRefund && t.toUser == name && len(userInfo.CoinHistory.Refunds) < storage.InfoHistoryLimit {
+			userInfo.CoinHistory.Refunds = append(userInfo.CoinHistory.Refunds, storage.Refund{
+				ID:        t.id,
+				OrderID:   t.orderID,
+				Amount:    t.amount,
+				CreatedAt: t.createdAt,
+			})
+		}
+		if t.kind != storage.KindTransfer {
+			continue
+		}
+		if t.fromUser == name && len(userInfo.CoinHistory.Sent) < storage.InfoHistoryLimit {
+			userInfo.CoinHistory.Sent = append(userInfo.CoinHistory.Sent, storage.TransactionSent{
+				ID:        t.id,
+				Amount:    t.amount,
+				ToUser:    t.toUser,
+				CreatedAt: t.createdAt,
+			})
+		}
+		if t.toUser == name && len(userInfo.CoinHistory.Received) < storage.InfoHistoryLimit {
+			userInfo.CoinHistory.Received = append(userInfo.CoinHistory.Received, storage.TransactionReceived{
+				ID:        t.id,
+				Amount:    t.amount,
+				FromUser:  t.fromUser,
+				CreatedAt: t.createdAt,
+			})
+		}
+	}
+	//newest first
+	for i := len(u.purchases) - 1; i >= 0 && len(userInfo.Purchases) < storage.InfoHistoryLimit; i-- {
+		userInfo.Purchases = append(userInfo.Purchases, u.purchases[i])
+	}
+	return &userInfo, nil
+}
+
+// GrantCoins gives amount coins to every user in users. Either all users get
+// their coins or none does.
+func (s *Storage) GrantCoins(admin string, users []string, amount int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range append([]string{admin}, users...) {
+		if _, ok := s.users[name]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrUserNotFound, name)
+		}
+	}
+	for _, name := range users {
+		id := s.record(storage.KindGrant, "", name, amount,
+			posting{account: accountIssuance, amount: -amount},
+			posting{account: accountUser, user: name, amount: amount},
+		)
+		s.explain(id, reason, admin)
+	}
+	return nil
+}
+
+// ClawbackCoins takes amount coins away from user, the balance can't go below zero.
+func (s *Storage) ClawbackCoins(admin string, user string, amount int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[admin]; !ok {
+		return fmt.Errorf("%w: %s", storage.ErrUserNotFound, admin)
+	}
+	u, ok := s.users[user]
+	if !ok {
+		return fmt.Errorf("%w: %s", storage.ErrUserNotFound, user)
+	}
+	if u.coins < amount {
+		return storage.ErrUnsufficientBalance
+	}
+	id := s.record(storage.KindClawback, user, "", amount,
+		posting{account: accountUser, user: user, amount: -amount},
+		posting{account: accountIssuance, amount: amount},
+	)
+	s.explain(id, reason, admin)
+	return nil
+}
+
+func (s *Storage) Buy(item string, name string, quantity int) error {
+	if quantity < 1 {
+		return storage.ErrInvalidQuantity
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	merchItem := s.findItem(item)
+	if merchItem == nil {
+		return storage.ErrItemNotFound
+	}
+	if !merchItem.Available {
+		return storage.ErrItemUnavailable
+	}
+	price := merchItem.Price
+	total := price * quantity
+	if u.coins < total {
+		return storage.ErrUnsufficientBalance
+	}
+	s.record(storage.KindPurchase, name, "", total,
+		posting{account: accountUser, user: name, amount: -total},
+		posting{account: accountShop, amount: total},
+	)
+	now := time.Now()
+	u.inventory[item] += quantity
+	u.purchases = append(u.purchases, storage.Purchase{Item: item, Price: price, Quantity: quantity, CreatedAt: now})
+	s.placeOrder(name, []storage.OrderItem{{Item: item, Price: price, Quantity: quantity}}, now)
+	return nil
+}
+
+func (s *Storage) ItemExist(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findItem(name) != nil, nil
+}
+
+func (s *Storage) MerchList() ([]storage.MerchItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []storage.MerchItem
+	for _, item := range s.merch {
+		if !item.retired {
+			items = append(items, item.MerchItem)
+		}
+	}
+	return items, nil
+}
+
+func (s *Storage) MerchItem(name string) (*storage.MerchItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.findItem(name)
+	if item == nil {
+		return nil, storage.ErrItemNotFound
+	}
+	found := item.MerchItem
+	return &found, nil
+}
+
+func (s *Storage) AddMerchItem(item storage.MerchItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(item.Name) {
+		return storage.ErrItemExists
+	}
+	s.merch = append(s.merch, &merchItem{MerchItem: item})
+	return nil
+}
+
+func (s *Storage) UpdateMerchItem(name string, update storage.MerchUpdate) (*storage.MerchItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.findItem(name)
+	if item == nil {
+		return nil, storage.ErrItemNotFound
+	}
+	if update.Name != nil && *update.Name != name {
+		if s.nameTaken(*update.Name) {
+			return nil, storage.ErrItemExists
+		}
+		s.renameItem(name, *update.Name)
+		item.Name = *update.Name
+	}
+	if update.Price != nil {
+		item.Price = *update.Price
+	}
+	if update.Description != nil {
+		item.Description = *update.Description
+	}
+	if update.Available != nil {
+		item.Available = *update.Available
+	}
+	updated := item.MerchItem
+	return &updated, nil
+}
+
+// RetireMerchItem removes the item from the catalog, users keep it in their inventory.
+func (s *Storage) RetireMerchItem(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.findItem(name)
+	if item == nil {
+		return storage.ErrItemNotFound
+	}
+	item.retired = true
+	return nil
+}
+
+// findItem looks up an item of the catalog by name, retired items are skipped.
+// The caller must hold s.mu.
+func (s *Storage) findItem(name string) *merchItem {
+	for _, item := range s.merch {
+		if item.Name == name && !item.retired {
+			return item
+		}
+	}
+	return nil
+}
+
+// nameTaken reports whether any item, including retired ones, has the name.
+// Names stay unique like the merch.name column. The caller must hold s.mu.
+func (s *Storage) nameTaken(name string) bool {
+	for _, item := range s.merch {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renameItem updates inventories and purchases which refer to items by name,
+// the postgres storage gets the same effect by joining on merch_id.
+// The caller must hold s.mu.
+func (s *Storage) renameItem(from, to string) {
+	for _, u := range s.users {
+		if quantity, ok := u.inventory[from]; ok {
+			delete(u.inventory, from)
+			u.inventory[to] = quantity
+		}
+		for i := range u.purchases {
+			if u.purchases[i].Item == from {
+				u.purchases[i].Item = to
+			}
+		}
+	}
+	for _, order := range s.orders {
+		for i := range order.Items {
+			if order.Items[i].Item == from {
+				order.Items[i].Item = to
+			}
+		}
+	}
+}
+
+func (s *Storage) CoinHistory(name string, filter storage.HistoryFilter) (*storage.HistoryPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[name]; !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	var page storage.HistoryPage
+	//newest first, "sent" before "received" for transfers to oneself
+	for i := len(s.transactions) - 1; i >= 0; i-- {
+		t := s.transactions[i]
+		if t.kind != storage.KindTransfer {
+			continue
+		}
+		var entries []storage.HistoryEntry
+		if t.fromUser == name {
+			entries = append(entries, storage.HistoryEntry{ID: t.id, Direction: storage.DirectionSent, Counterparty: t.toUser, Amount: t.amount, CreatedAt: t.createdAt})
+		}
+		if t.toUser == name {
+			entries = append(entries, storage.HistoryEntry{ID: t.id, Direction: storage.DirectionReceived, Counterparty: t.fromUser, Amount: t.amount, CreatedAt: t.createdAt})
+		}
+		for _, e := range entries {
+			if !matchHistoryFilter(e, filter) {
+				continue
+			}
+			if filter.Limit > 0 && len(page.Entries) == filter.Limit {
+				last := page.Entries[len(page.Entries)-1]
+				page.Next = &storage.HistoryCursor{ID: last.ID, Direction: last.Direction}
+				return &page, nil
+			}
+			page.Entries = append(page.Entries, e)
+		}
+	}
+	return &page, nil
+}
+
+func matchHistoryFilter(e storage.HistoryEntry, filter storage.HistoryFilter) bool {
+	if filter.Direction != "" && e.Direction != filter.Direction {
+		return false
+	}
+	if filter.Counterparty != "" && e.Counterparty != filter.Counterparty {
+		return false
+	}
+	if !filter.From.IsZero() && e.CreatedAt.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !e.CreatedAt.Before(filter.To) {
+		return false
+	}
+	if filter.After != nil {
+		if e.ID > filter.After.ID || e.ID == filter.After.ID && e.Direction >= filter.After.Direction {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Storage) UserLedger(name string) ([]storage.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []storage.LedgerEntry
+	for _, p := range s.ledger {
+		if p.account != accountUser || p.user != name {
+			continue
+		}
+		entries = append(entries, storage.LedgerEntry{
+			TransactionID: p.transactionID,
+			Kind:          s.transactions[p.transactionID-1].kind,
+			Amount:        p.amount,
+		})
+	}
+	return entries, nil
+}
+
+// record appends a journal entry with its postings and updates cached balances.
+// The caller must hold s.mu and pass balanced postings.
+func (s *Storage) record(kind, fromUser, toUser string, amount int, postings ...posting) int {
+	id := len(s.transactions) + 1
+	s.transactions = append(s.transactions, transaction{
+		id:        id,
+		kind:      kind,
+		fromUser:  fromUser,
+		toUser:    toUser,
+		amount:    amount,
+		createdAt: time.Now(),
+	})
+	for _, p := range postings {
+		p.transactionID = id
+		s.ledger = append(s.ledger, p)
+		if p.account == accountUser {
+			s.users[p.user].coins += p.amount
+		}
+	}
+	return id
+}
+
+// explain attaches the reason and the admin to a grant or a clawback.
+// The caller must hold s.mu.
+func (s *Storage) explain(transactionID int, reason, admin string) {
+	t := &s.transactions[transactionID-1]
+	t.reason = reason
+	t.createdBy = admin
+}
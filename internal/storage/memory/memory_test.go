@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		return New()
+	})
+}
+
+func TestAddUser(t *testing.T) {
+	s := New()
+
+	err := s.AddUser("testuser", "hashedpassword")
+	assert.NoError(t, err)
+
+	exists, err := s.UserExist("testuser")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	passHash, err := s.UserPassHash("testuser")
+	assert.NoError(t, err)
+	assert.Equal(t, "hashedpassword", passHash)
+
+	//adding the same user twice
+	err = s.AddUser("testuser", "otherpassword")
+	assert.ErrorIs(t, err, storage.ErrUserExists)
+
+	//non-existing user
+	exists, err = s.UserExist("nonexistinguser")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	_, err = s.UserPassHash("nonexistinguser")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+}
+
+func TestSendCoins(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.AddUser("fromUser", "hash"))
+	assert.NoError(t, s.AddUser("toUser", "hash"))
+
+	err := s.SendCoins("fromUser", "toUser", 10)
+	assert.NoError(t, err)
+
+	err = s.SendCoins("fromUser", "toUser", 1000)
+	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
+
+	err = s.SendCoins("fromUser", "nonexistinguser", 10)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	from, err := s.UserInfo("fromUser")
+	assert.NoError(t, err)
+	assert.Equal(t, 990, from.Coins)
+	assert.Len(t, from.CoinHistory.Sent, 1)
+	assert.Equal(t, "toUser", from.CoinHistory.Sent[0].ToUser)
+	assert.Equal(t, 10, from.CoinHistory.Sent[0].Amount)
+
+	to, err := s.UserInfo("toUser")
+	assert.NoError(t, err)
+	assert.Equal(t, 1010, to.Coins)
+	assert.Len(t, to.CoinHistory.Received, 1)
+	assert.Equal(t, "fromUser", to.CoinHistory.Received[0].FromUser)
+}
+
+func TestBuy(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.AddUser("buyer", "hash"))
+
+	exists, err := s.ItemExist("pink-hoody")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = s.ItemExist("nonexistingitem")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, s.Buy("pink-hoody", "buyer", 1))
+	assert.NoError(t, s.Buy("pink-hoody", "buyer", 1))
+	assert.ErrorIs(t, s.Buy("pink-hoody", "buyer", 1), storage.ErrUnsufficientBalance)
+	assert.ErrorIs(t, s.Buy("nonexistingitem", "buyer", 1), storage.ErrItemNotFound)
+
+	info, err := s.UserInfo("buyer")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, info.Coins)
+	assert.Equal(t, []storage.InventoryEntry{{Type: "pink-hoody", Quantity: 2}}, info.Inventory)
+}
+
+func TestConcurrentSendCoins(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.AddUser("a", "hash"))
+	assert.NoError(t, s.AddUser("b", "hash"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.SendCoins("a", "b", 7)
+		}()
+		go func() {
+			defer wg.Done()
+			s.SendCoins("b", "a", 5)
+		}()
+	}
+	wg.Wait()
+
+	a, err := s.UserInfo("a")
+	assert.NoError(t, err)
+	b, err := s.UserInfo("b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, a.Coins+b.Coins)
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// placeOrder creates the order delivering the items bought in one purchase.
+// The caller must hold s.mu.
+func (s *Storage) placeOrder(user string, items []storage.OrderItem, createdAt time.Time) {
+	order := &storage.Order{
+		ID:       len(s.orders) + 1,
+		User:     user,
+		Status:   storage.OrderPlaced,
+		Items:    items,
+		PlacedAt: createdAt,
+	}
+	for _, item := range items {
+		order.Total += item.Price * item.Quantity
+	}
+	s.orders = append(s.orders, order)
+}
+
+// Orders returns a page of the user's orders matching the filter, newest first.
+func (s *Storage) Orders(name string, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[name]; !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	return s.orderPage(name, filter, true), nil
+}
+
+// AllOrders returns a page of the orders of all users matching the filter, oldest first.
+func (s *Storage) AllOrders(filter storage.OrderFilter) (*storage.OrderPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orderPage("", filter, false), nil
+}
+
+// SetOrderStatus advances the order to the status and returns the updated order.
+func (s *Storage) SetOrderStatus(id int, status string) (*storage.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.orders) {
+		return nil, storage.ErrOrderNotFound
+	}
+	order := s.orders[id-1]
+	if !storage.OrderStatusAdvances(order.Status, status) {
+		return nil, storage.ErrOrderStatus
+	}
+	now := time.Now()
+	order.Status = status
+	switch status {
+	case storage.OrderProcessing:
+		order.ProcessingAt = &now
+	case storage.OrderShipped:
+		order.ShippedAt = &now
+	case storage.OrderDelivered:
+		order.DeliveredAt = &now
+	}
+	updated := copyOrder(order)
+	return &updated, nil
+}
+
+// CancelOrder cancels the order, returns its cost to the user and takes its items back
+// from the user's inventory. Orders of other users than name are not found, an empty name
+// matches any user. Orders placed before since cannot be cancelled, unless since is zero.
+func (s *Storage) CancelOrder(id int, name string, since time.Time) (*storage.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.orders) || (name != "" && s.orders[id-1].User != name) {
+		return nil, storage.ErrOrderNotFound
+	}
+	order := s.orders[id-1]
+	if order.Status == storage.OrderCancelled {
+		return nil, storage.ErrOrderStatus
+	}
+	if !since.IsZero() && order.PlacedAt.Before(since) {
+		return nil, storage.ErrRefundWindowExpired
+	}
+	u := s.users[order.User]
+	quantities := map[string]int{}
+	for _, item := range order.Items {
+		quantities[item.Item] += item.Quantity
+	}
+	for item, quantity := range quantities {
+		if u.inventory[item] < quantity {
+			return nil, fmt.Errorf("user %s has fewer items than order %d", order.User, id)
+		}
+	}
+	for item, quantity := range quantities {
+		u.inventory[item] -= quantity
+		if u.inventory[item] == 0 {
+			delete(u.inventory, item)
+		}
+	}
+	refundID := s.record(storage.KindRefund, "", order.User, order.Total,
+		posting{account: accountShop, amount: -order.Total},
+		posting{account: accountUser, user: order.User, amount: order.Total},
+	)
+	s.transactions[refundID-1].orderID = id
+	now := time.Now()
+	order.Status = storage.OrderCancelled
+	order.CancelledAt = &now
+	cancelled := copyOrder(order)
+	return &cancelled, nil
+}
+
+// orderPage cuts the page of the orders of user matching the filter, an empty user
+// matches any user. The caller must hold s.mu.
+func (s *Storage) orderPage(user string, filter storage.OrderFilter, newestFirst bool) *storage.OrderPage {
+	sorted := slices.Clone(s.orders)
+	slices.SortStableFunc(sorted, func(a, b *storage.Order) int {
+		return compareOrder(a, b.PlacedAt, b.ID)
+	})
+	if newestFirst {
+		slices.Reverse(sorted)
+	}
+	page := storage.OrderPage{Orders: []storage.Order{}}
+	for _, order := range sorted {
+		if user != "" && order.User != user || filter.Status != "" && order.Status != filter.Status {
+			continue
+		}
+		if filter.After != nil {
+			c := compareOrder(order, filter.After.PlacedAt, filter.After.ID)
+			if newestFirst && c >= 0 || !newestFirst && c <= 0 {
+				continue
+			}
+		}
+		if filter.Limit > 0 && len(page.Orders) == filter.Limit {
+			last := page.Orders[filter.Limit-1]
+			page.Next = &storage.OrderCursor{PlacedAt: last.PlacedAt, ID: last.ID}
+			break
+		}
+		page.Orders = append(page.Orders, copyOrder(order))
+	}
+	return &page
+}
+
+// compareOrder orders orders by the time they were placed, then by id, like the cursors do.
+func compareOrder(order *storage.Order, placedAt time.Time, id int) int {
+	if c := order.PlacedAt.Compare(placedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(order.ID, id)
+}
+
+// copyOrder must be called with s.mu held, the stored orders are modified in place.
+func copyOrder(order *storage.Order) storage.Order {
+	o := *order
+	o.Items = slices.Clone(order.Items)
+	return o
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+type refreshToken struct {
+	storage.RefreshToken
+	user    string
+	used    bool
+	revoked bool
+}
+
+// AddRefreshToken mirrors the postgres implementation:
+// families of the user whose tokens have all expired are deleted.
+func (s *Storage) AddRefreshToken(user string, token storage.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user]; !ok {
+		return storage.ErrUserNotFound
+	}
+	now := time.Now()
+	live := map[string]bool{}
+	for _, t := range s.refreshTokens {
+		if !t.ExpiresAt.Before(now) {
+			live[t.Family] = true
+		}
+	}
+	for hash, t := range s.refreshTokens {
+		if t.user == user && !live[t.Family] {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	s.refreshTokens[token.Hash] = &refreshToken{RefreshToken: token, user: user}
+	return nil
+}
+
+// RotateRefreshToken mirrors the postgres implementation:
+// reuse of a rotated token revokes its whole family.
+func (s *Storage) RotateRefreshToken(hash, nextHash string, nextExpiresAt time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[hash]
+	if !ok {
+		return "", storage.ErrTokenNotFound
+	}
+	if token.revoked {
+		return "", storage.ErrTokenRevoked
+	}
+	if token.used {
+		s.revokeFamily(token.Family)
+		return "", storage.ErrTokenReused
+	}
+	if !time.Now().Before(token.ExpiresAt) {
+		return "", storage.ErrTokenExpired
+	}
+	token.used = true
+	s.refreshTokens[nextHash] = &refreshToken{
+		RefreshToken: storage.RefreshToken{Hash: nextHash, Family: token.Family, ExpiresAt: nextExpiresAt},
+		user:         token.user,
+	}
+	return token.user, nil
+}
+
+func (s *Storage) RevokeRefreshToken(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[hash]
+	if !ok {
+		return nil
+	}
+	s.revokeFamily(token.Family)
+	return nil
+}
+
+func (s *Storage) RevokeToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, exp := range s.revokedTokens {
+		if exp.Before(now) {
+			delete(s.revokedTokens, id)
+		}
+	}
+	s.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (s *Storage) RevokeUserTokens(user string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revokeUserTokens(user, at)
+}
+
+func (s *Storage) ChangeUserPassHash(name, passHash string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.revokeUserTokens(name, at); err != nil {
+		return err
+	}
+	s.users[name].passHash = passHash
+	return nil
+}
+
+// revokeUserTokens must be called with s.mu held.
+func (s *Storage) revokeUserTokens(user string, at time.Time) error {
+	u, ok := s.users[user]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	u.tokensValidAfter = at
+	for _, t := range s.refreshTokens {
+		if t.user == user {
+			t.revoked = true
+		}
+	}
+	return nil
+}
+
+func (s *Storage) TokenRevoked(user, jti string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revokedTokens[jti]; ok && jti != "" {
+		return true, nil
+	}
+	u, ok := s.users[user]
+	if !ok {
+		return false, storage.ErrUserNotFound
+	}
+	return issuedAt.Before(u.tokensValidAfter), nil
+}
+
+// revokeFamily revokes all refresh tokens of the family.
+// The caller must hold s.mu.
+func (s *Storage) revokeFamily(family string) {
+	for _, t := range s.refreshTokens {
+		if t.Family == family {
+			t.revoked = true
+		}
+	}
+}
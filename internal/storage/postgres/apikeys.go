@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/lib/pq"
+)
+
+// AddServiceAccount creates a user with the service role. Unlike AddUser
+// it grants no start balance, service accounts don't spend coins.
+func (s *Storage) AddServiceAccount(name string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Insert("users").
+		Columns("name", "pass_hash", "role").
+		Values(name, "", storage.RoleService).
+		RunWith(s.db).
+		Exec()
+	if isUniqueViolation(err) {
+		return storage.ErrUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add service account: %w", err)
+	}
+	return nil
+}
+
+// serviceAccountID returns the id of a service account, other users are not found.
+func serviceAccountID(runner squirrel.BaseRunner, name string) (int, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var id int
+	err := psql.Select("id").
+		From("users").
+		Where("name = ? AND role = ?", name, storage.RoleService).
+		RunWith(runner).
+		QueryRow().
+		Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", storage.ErrUserNotFound, name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get service account: %w", err)
+	}
+	return id, nil
+}
+
+// AddAPIKey stores a key of the service account key.Account and returns its id.
+func (s *Storage) AddAPIKey(key storage.APIKey) (int, error) {
+	id, err := serviceAccountID(s.db, key.Account)
+	if err != nil {
+		return 0, err
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var keyID int
+	err = psql.Insert("api_keys").
+		Columns("user_id", "prefix", "key_hash", "description", "scopes").
+		Values(id, key.Prefix, key.Hash, key.Description, pq.Array(key.Scopes)).
+		Suffix("RETURNING id").
+		RunWith(s.db).
+		QueryRow().
+		Scan(&keyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add api key: %w", err)
+	}
+	return keyID, nil
+}
+
+// APIKeys lists the keys of the service account including revoked ones, without hashes.
+func (s *Storage) APIKeys(account string) ([]storage.APIKey, error) {
+	id, err := serviceAccountID(s.db, account)
+	if err != nil {
+		return nil, err
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err := psql.Select("id", "prefix", "description", "scopes", "created_at", "last_used_at", "revoked_at").
+		From("api_keys").
+		Where("user_id = ?", id).
+		OrderBy("id").
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+	keys := []storage.APIKey{}
+	for rows.Next() {
+		key := storage.APIKey{Account: account}
+		var lastUsedAt, revokedAt sql.NullTime
+		err := rows.Scan(&key.ID, &key.Prefix, &key.Description, pq.Array(&key.Scopes), &key.CreatedAt, &lastUsedAt, &revokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(account string, id int) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	res, err := psql.Update("api_keys").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where("id = ? AND revoked_at IS NULL AND user_id = (SELECT id FROM users WHERE name = ? AND role = ?)", id, account, storage.RoleService).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// UseAPIKey returns the active key with the hash and records its use.
+// Only keys of service accounts are accepted, like in APIKeys and RevokeAPIKey.
+func (s *Storage) UseAPIKey(hash string) (*storage.APIKey, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	key := storage.APIKey{Hash: hash}
+	err := psql.Update("api_keys k").
+		Set("last_used_at", squirrel.Expr("NOW()")).
+		Suffix("FROM users u WHERE k.key_hash = ? AND k.revoked_at IS NULL AND u.id = k.user_id AND u.role = ? RETURNING k.id, u.name, k.prefix, k.scopes", hash, storage.RoleService).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&key.ID, &key.Account, &key.Prefix, pq.Array(&key.Scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use api key: %w", err)
+	}
+	return &key, nil
+}
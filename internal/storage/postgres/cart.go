@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// cartLine is a cart item with the merch fields needed for the checkout.
+type cartLine struct {
+	storage.CartItem
+	merchID int
+	retired bool
+}
+
+// SetCartItem puts quantity items into the cart of the user, replacing the quantity
+// if the item is already there.
+func (s *Storage) SetCartItem(user, item string, quantity int) error {
+	if quantity < 1 {
+		return storage.ErrInvalidQuantity
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	id, err := userID(s.db, user)
+	if err != nil {
+		return err
+	}
+	var (
+		merchID   int
+		available bool
+	)
+	err = psql.Select("id", "available").
+		From("merch").
+		Where("name=? AND retired_at IS NULL", item).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&merchID, &available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get items info: %w", err)
+	}
+	if !available {
+		return storage.ErrItemUnavailable
+	}
+	_, err = psql.Insert("cart_items").
+		Columns("user_id", "merch_id", "quantity").
+		Values(id, merchID, quantity).
+		Suffix("ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = EXCLUDED.quantity").
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update cart: %w", err)
+	}
+	return nil
+}
+
+// RemoveCartItem removes the item from the cart of the user, retired items included.
+func (s *Storage) RemoveCartItem(user, item string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	id, err := userID(s.db, user)
+	if err != nil {
+		return err
+	}
+	res, err := psql.Delete("cart_items").
+		Where("user_id = ? AND merch_id IN (SELECT id FROM merch WHERE name = ?)", id, item).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update cart: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update cart: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (s *Storage) Cart(user string) (*storage.Cart, error) {
+	id, err := userID(s.db, user)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := cartLines(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return newCart(lines), nil
+}
+
+// Checkout buys everything in the cart of the user as a single ledger transaction
+// placing a single order, and empties the cart. If any item cannot be bought or the coins are not enough,
+// nothing is bought and a *storage.CheckoutError explains why.
+func (s *Storage) Checkout(user string) (*storage.Cart, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var id int
+	err = psql.Select("id").
+		From("users").
+		Where("name=?", user).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRow().
+		Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	coins, err := balance(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coins for user: %w", err)
+	}
+	if err := lockCartMerch(tx, id); err != nil {
+		return nil, err
+	}
+	lines, err := cartLines(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, storage.ErrCartEmpty
+	}
+	cart := newCart(lines)
+	if err := checkoutError(lines, cart.Total, coins); err != nil {
+		return nil, err
+	}
+
+	transactionID, err := record(tx, storage.KindPurchase, id, nil, cart.Total,
+		userPosting(id, -cart.Total),
+		systemPosting(accountShop, cart.Total),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := placeOrder(tx, id, transactionID, cart.Total); err != nil {
+		return nil, err
+	}
+	purchases := psql.Insert("purchases").Columns("user_id", "merch_id", "transaction_id", "price", "quantity")
+	inventory := psql.Insert("user_inventory").Columns("user_id", "merch_id", "quantity")
+	for _, line := range lines {
+		purchases = purchases.Values(id, line.merchID, transactionID, line.Price, line.Quantity)
+		inventory = inventory.Values(id, line.merchID, line.Quantity)
+	}
+	if _, err := purchases.RunWith(tx).Exec(); err != nil {
+		return nil, fmt.Errorf("failed to create purchase record: %w", err)
+	}
+	_, err = inventory.
+		Suffix("ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity").
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update inventory: %w", err)
+	}
+	_, err = psql.Delete("cart_items").Where("user_id = ?", id).RunWith(tx).Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to empty cart: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return cart, nil
+}
+
+// lockCartMerch locks the merch rows of the cart until the checkout commits, so that
+// a concurrent price change or retirement waits for the order instead of being missed
+// by it. Rows are locked in id order, so concurrent checkouts can not deadlock.
+func lockCartMerch(tx *sql.Tx, userID int) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err := psql.Select("m.id").
+		From("merch m").
+		Join("cart_items c ON c.merch_id = m.id").
+		Where("c.user_id = ?", userID).
+		OrderBy("m.id").
+		Suffix("FOR SHARE OF m").
+		RunWith(tx).
+		Query()
+	if err != nil {
+		return fmt.Errorf("failed to lock merch: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock merch: %w", err)
+	}
+	return nil
+}
+
+func cartLines(runner squirrel.BaseRunner, userID int) ([]cartLine, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err := psql.Select("m.id", "m.name", "m.price", "m.available", "m.retired_at IS NOT NULL", "c.quantity").
+		From("cart_items c").
+		Join("merch m ON m.id = c.merch_id").
+		Where("c.user_id = ?", userID).
+		OrderBy("c.added_at", "m.id").
+		RunWith(runner).
+		Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	defer rows.Close()
+	var lines []cartLine
+	for rows.Next() {
+		var line cartLine
+		if err := rows.Scan(&line.merchID, &line.Item, &line.Price, &line.Available, &line.retired, &line.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to get cart: %w", err)
+		}
+		line.Available = line.Available && !line.retired
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	return lines, nil
+}
+
+func newCart(lines []cartLine) *storage.Cart {
+	cart := &storage.Cart{Items: make([]storage.CartItem, len(lines))}
+	for i, line := range lines {
+		cart.Items[i] = line.CartItem
+		cart.Total += line.Price * line.Quantity
+	}
+	return cart
+}
+
+// checkoutError returns nil if the cart can be bought.
+func checkoutError(lines []cartLine, total, coins int) error {
+	var lineErrs []storage.CheckoutLineError
+	for _, line := range lines {
+		switch {
+		case line.retired:
+			lineErrs = append(lineErrs, storage.CheckoutLineError{Item: line.Item, Err: storage.ErrItemNotFound})
+		case !line.Available:
+			lineErrs = append(lineErrs, storage.CheckoutLineError{Item: line.Item, Err: storage.ErrItemUnavailable})
+		}
+	}
+	if len(lineErrs) == 0 && coins >= total {
+		return nil
+	}
+	return &storage.CheckoutError{Lines: lineErrs, Total: total, Balance: coins}
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// ReserveIdempotencyKey marks the key of the user as in progress. If the key is already
+// taken, the recorded response is returned, or ErrIdempotencyKeyInProgress if the request
+// has not finished, or ErrIdempotencyKeyOutcomeUnknown if it was reserved before pendingSince
+// and is still not finished. Keys created before since have expired and may be used again.
+func (s *Storage) ReserveIdempotencyKey(user, key, fingerprint string, since, pendingSince time.Time) (*storage.IdempotentResponse, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	id, err := userID(tx, user)
+	if err != nil {
+		return nil, err
+	}
+	_, err = psql.Delete("idempotency_keys").
+		Where("user_id = ? AND created_at < ?", id, since).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	//a concurrent request with the same key waits here until the first one commits
+	res, err := psql.Insert("idempotency_keys").
+		Columns("user_id", "key", "fingerprint").
+		Values(id, key, fingerprint).
+		Suffix("ON CONFLICT (user_id, key) DO NOTHING").
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	} else if n == 1 {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, nil
+	}
+	var (
+		stored     string
+		statusCode sql.NullInt64
+		body       []byte
+		createdAt  time.Time
+	)
+	err = psql.Select("fingerprint", "status_code", "response", "created_at").
+		From("idempotency_keys").
+		Where("user_id = ? AND key = ?", id, key).
+		RunWith(tx).
+		QueryRow().
+		Scan(&stored, &statusCode, &body, &createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if stored != fingerprint {
+		return nil, storage.ErrIdempotencyKeyReused
+	}
+	if !statusCode.Valid && createdAt.Before(pendingSince) {
+		return nil, storage.ErrIdempotencyKeyOutcomeUnknown
+	}
+	if !statusCode.Valid {
+		return nil, storage.ErrIdempotencyKeyInProgress
+	}
+	return &storage.IdempotentResponse{StatusCode: int(statusCode.Int64), Body: body}, nil
+}
+
+// CompleteIdempotencyKey records the response of the request with the key.
+func (s *Storage) CompleteIdempotencyKey(user, key string, resp storage.IdempotentResponse) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Update("idempotency_keys").
+		Set("status_code", resp.StatusCode).
+		Set("response", resp.Body).
+		Where("key = ? AND user_id = (SELECT id FROM users WHERE name = ?)", key, user).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed, so that it can be retried.
+// Completed keys are kept.
+func (s *Storage) ReleaseIdempotencyKey(user, key string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Delete("idempotency_keys").
+		Where("key = ? AND status_code IS NULL AND user_id = (SELECT id FROM users WHERE name = ?)", key, user).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
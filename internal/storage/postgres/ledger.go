@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Ledger accounts. Only postings to accountUser reference a user.
+const (
+	accountUser     = "user"
+	accountIssuance = "issuance"
+	accountShop     = "shop"
+)
+
+type posting struct {
+	account string
+	userID  any
+	amount  int
+}
+
+func userPosting(userID, amount int) posting {
+	return posting{account: accountUser, userID: userID, amount: amount}
+}
+
+func systemPosting(account string, amount int) posting {
+	return posting{account: account, amount: amount}
+}
+
+// record writes a journal entry into transactions together with its postings.
+// fromUserID and toUserID may be nil for movements from or to system accounts.
+func record(tx *sql.Tx, kind string, fromUserID, toUserID any, amount int, postings ...posting) (int, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	insert := psql.Insert("transactions").
+		Columns("kind", "from_user_id", "to_user_id", "amount").
+		Values(kind, fromUserID, toUserID, amount)
+	return insertEntry(tx, kind, insert, postings)
+}
+
+// recordAdjustment is record for grants and clawbacks, which also keep the reason
+// and the admin who made them.
+func recordAdjustment(tx *sql.Tx, kind string, fromUserID, toUserID any, amount int, reason string, adminID int, postings ...posting) (int, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	insert := psql.Insert("transactions").
+		Columns("kind", "from_user_id", "to_user_id", "amount", "reason", "created_by").
+		Values(kind, fromUserID, toUserID, amount, reason, adminID)
+	return insertEntry(tx, kind, insert, postings)
+}
+
+func insertEntry(tx *sql.Tx, kind string, insert squirrel.InsertBuilder, postings []posting) (int, error) {
+	sum := 0
+	for _, p := range postings {
+		sum += p.amount
+	}
+	if sum != 0 {
+		return 0, fmt.Errorf("unbalanced %s transaction: postings sum to %d", kind, sum)
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var transactionID int
+	err := insert.
+		Suffix("RETURNING id").
+		RunWith(tx).
+		QueryRow().
+		Scan(&transactionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create transaction record: %w", err)
+	}
+	ledger := psql.Insert("ledger").Columns("transaction_id", "account", "user_id", "amount")
+	for _, p := range postings {
+		ledger = ledger.Values(transactionID, p.account, p.userID, p.amount)
+	}
+	if _, err := ledger.RunWith(tx).Exec(); err != nil {
+		return 0, fmt.Errorf("failed to create ledger postings: %w", err)
+	}
+	return transactionID, nil
+}
+
+// balance derives the balance of a user account from the ledger.
+func balance(runner squirrel.BaseRunner, userID int) (int, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var coins int
+	err := psql.Select("COALESCE(SUM(amount), 0)").
+		From("ledger").
+		Where("account = ? AND user_id = ?", accountUser, userID).
+		RunWith(runner).
+		QueryRow().
+		Scan(&coins)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return coins, nil
+}
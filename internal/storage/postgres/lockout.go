@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+func (s *Storage) LoginAttempts(key string) (*storage.LoginAttempts, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var (
+		attempts    storage.LoginAttempts
+		lockedUntil sql.NullTime
+	)
+	err := psql.Select("failures", "last_failure_at", "locked_until").
+		From("login_attempts").
+		Where("key = ?", key).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &storage.LoginAttempts{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	attempts.LockedUntil = lockedUntil.Time
+	return &attempts, nil
+}
+
+// AddLoginFailure counts a failed login and locks the key once it runs out of attempts.
+// Failures older than since are forgotten. The row is locked while the failure is counted,
+// so concurrent failures can't get past the limit. A locked key is not counted, its
+// attempts are returned with ErrLoginLocked.
+func (s *Storage) AddLoginFailure(key string, since time.Time, lockout storage.LoginLockout) (*storage.LoginAttempts, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err = psql.Insert("login_attempts").
+		Columns("key", "failures", "last_failure_at").
+		Values(key, 0, squirrel.Expr("NOW()")).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to add login failure: %w", err)
+	}
+	var (
+		attempts    storage.LoginAttempts
+		lockedUntil sql.NullTime
+	)
+	err = psql.Select("failures", "last_failure_at", "locked_until").
+		From("login_attempts").
+		Where("key = ?", key).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRow().
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	attempts.LockedUntil = lockedUntil.Time
+	now := time.Now()
+	if attempts.LockedUntil.After(now) {
+		return &attempts, storage.ErrLoginLocked
+	}
+	if attempts.LastFailureAt.Before(since) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	update := psql.Update("login_attempts").
+		Set("failures", attempts.Failures).
+		Set("last_failure_at", now)
+	if d := lockout.LockFor(attempts.Failures); d > 0 {
+		attempts.LockedUntil = now.Add(d)
+		update = update.Set("locked_until", attempts.LockedUntil)
+	}
+	_, err = update.Where("key = ?", key).RunWith(tx).Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to add login failure: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &attempts, nil
+}
+
+func (s *Storage) ResetLoginAttempts(key string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Delete("login_attempts").
+		Where("key = ?", key).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
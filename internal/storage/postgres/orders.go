@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// orderStatusColumns are the columns keeping the time an order reached a status.
+// Orders are cancelled with CancelOrder.
+var orderStatusColumns = map[string]string{
+	storage.OrderProcessing: "processing_at",
+	storage.OrderShipped:    "shipped_at",
+	storage.OrderDelivered:  "delivered_at",
+}
+
+// placeOrder creates the order delivering the items bought in a purchase transaction.
+func placeOrder(tx *sql.Tx, userID, transactionID, total int) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Insert("orders").
+		Columns("user_id", "transaction_id", "total").
+		Values(userID, transactionID, total).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+	return nil
+}
+
+// Orders returns a page of the user's orders matching the filter, newest first.
+func (s *Storage) Orders(user string, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	id, err := userID(s.db, user)
+	if err != nil {
+		return nil, err
+	}
+	q := ordersQuery().
+		Where("o.user_id = ?", id).
+		OrderBy("o.placed_at DESC", "o.id DESC")
+	if filter.After != nil {
+		q = q.Where("(o.placed_at, o.id) < (?, ?)", filter.After.PlacedAt, filter.After.ID)
+	}
+	return queryOrderPage(s.db, q, filter)
+}
+
+// AllOrders returns a page of the orders of all users matching the filter, oldest first.
+func (s *Storage) AllOrders(filter storage.OrderFilter) (*storage.OrderPage, error) {
+	q := ordersQuery().OrderBy("o.placed_at", "o.id")
+	if filter.After != nil {
+		q = q.Where("(o.placed_at, o.id) > (?, ?)", filter.After.PlacedAt, filter.After.ID)
+	}
+	return queryOrderPage(s.db, q, filter)
+}
+
+// SetOrderStatus advances the order to the status and returns the updated order.
+func (s *Storage) SetOrderStatus(id int, status string) (*storage.Order, error) {
+	column, ok := orderStatusColumns[status]
+	if !ok {
+		return nil, storage.ErrOrderStatus
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var current string
+	err = psql.Select("status").
+		From("orders").
+		Where("id = ?", id).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRow().
+		Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if !storage.OrderStatusAdvances(current, status) {
+		return nil, storage.ErrOrderStatus
+	}
+	_, err = psql.Update("orders").
+		Set("status", status).
+		Set(column, squirrel.Expr("NOW()")).
+		Where("id = ?", id).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+	orders, err := queryOrders(tx, ordersQuery().Where("o.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &orders[0], nil
+}
+
+// CancelOrder cancels the order, returns its cost to the user and takes its items back
+// from the user's inventory. Orders of other users than user are not found, an empty user
+// matches any user. Orders placed before since cannot be cancelled, unless since is zero.
+func (s *Storage) CancelOrder(id int, user string, since time.Time) (*storage.Order, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var (
+		userID, transactionID, total int
+		name, status                 string
+		placedAt                     time.Time
+	)
+	//the user is locked as well, like on purchases
+	err = psql.Select("o.user_id", "u.name", "o.transaction_id", "o.status", "o.total", "o.placed_at").
+		From("orders o").
+		Join("users u ON u.id = o.user_id").
+		Where("o.id = ?", id).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRow().
+		Scan(&userID, &name, &transactionID, &status, &total, &placedAt)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user != "" && name != user) {
+		return nil, storage.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if status == storage.OrderCancelled {
+		return nil, storage.ErrOrderStatus
+	}
+	if !since.IsZero() && placedAt.Before(since) {
+		return nil, storage.ErrRefundWindowExpired
+	}
+
+	rows, err := psql.Select("merch_id", "quantity").
+		From("purchases").
+		Where("transaction_id = ?", transactionID).
+		OrderBy("id").
+		RunWith(tx).
+		Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+	quantities := map[int]int{}
+	var merchIDs []int
+	for rows.Next() {
+		var merchID, quantity int
+		if err := rows.Scan(&merchID, &quantity); err != nil {
+			return nil, fmt.Errorf("failed to get order items: %w", err)
+		}
+		if _, ok := quantities[merchID]; !ok {
+			merchIDs = append(merchIDs, merchID)
+		}
+		quantities[merchID] += quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	rows.Close()
+	for _, merchID := range merchIDs {
+		res, err := psql.Update("user_inventory").
+			Set("quantity", squirrel.Expr("quantity - ?", quantities[merchID])).
+			Where("user_id = ? AND merch_id = ? AND quantity >= ?", userID, merchID, quantities[merchID]).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update inventory: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update inventory: %w", err)
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("failed to update inventory: user %s has fewer items than order %d", name, id)
+		}
+	}
+	_, err = psql.Delete("user_inventory").Where("user_id = ? AND quantity = 0", userID).RunWith(tx).Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update inventory: %w", err)
+	}
+
+	refundID, err := record(tx, storage.KindRefund, nil, userID, total,
+		systemPosting(accountShop, -total),
+		userPosting(userID, total),
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = psql.Update("orders").
+		Set("status", storage.OrderCancelled).
+		Set("cancelled_at", squirrel.Expr("NOW()")).
+		Set("refund_transaction_id", refundID).
+		Where("id = ?", id).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+	orders, err := queryOrders(tx, ordersQuery().Where("o.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &orders[0], nil
+}
+
+func ordersQuery() squirrel.SelectBuilder {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return psql.Select("o.id", "o.transaction_id", "u.name", "o.status", "o.total",
+		"o.placed_at", "o.processing_at", "o.shipped_at", "o.delivered_at", "o.cancelled_at").
+		From("orders o").
+		Join("users u ON u.id = o.user_id")
+}
+
+// queryOrders runs a query built on ordersQuery and loads the items of the orders.
+func queryOrders(runner squirrel.BaseRunner, q squirrel.SelectBuilder) ([]storage.Order, error) {
+	rows, err := q.RunWith(runner).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	defer rows.Close()
+	orders := []storage.Order{}
+	//orders are found by the transaction of their purchases
+	byTransaction := map[int]int{}
+	var transactionIDs []int
+	for rows.Next() {
+		var (
+			order         storage.Order
+			transactionID int
+			processingAt  sql.NullTime
+			shippedAt     sql.NullTime
+			deliveredAt   sql.NullTime
+			cancelledAt   sql.NullTime
+		)
+		err := rows.Scan(&order.ID, &transactionID, &order.User, &order.Status, &order.Total,
+			&order.PlacedAt, &processingAt, &shippedAt, &deliveredAt, &cancelledAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders: %w", err)
+		}
+		order.ProcessingAt = nullTime(processingAt)
+		order.ShippedAt = nullTime(shippedAt)
+		order.DeliveredAt = nullTime(deliveredAt)
+		order.CancelledAt = nullTime(cancelledAt)
+		byTransaction[transactionID] = len(orders)
+		transactionIDs = append(transactionIDs, transactionID)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	rows.Close()
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err = psql.Select("p.transaction_id", "m.name", "p.price", "p.quantity").
+		From("purchases p").
+		Join("merch m ON m.id = p.merch_id").
+		Where(squirrel.Eq{"p.transaction_id": transactionIDs}).
+		OrderBy("p.id").
+		RunWith(runner).
+		Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			transactionID int
+			item          storage.OrderItem
+		)
+		if err := rows.Scan(&transactionID, &item.Item, &item.Price, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to get order items: %w", err)
+		}
+		order := &orders[byTransaction[transactionID]]
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	return orders, nil
+}
+
+// queryOrderPage restricts q to the status and the limit of the filter and runs it
+// with queryOrders. The cursor of the filter is applied by the caller.
+func queryOrderPage(runner squirrel.BaseRunner, q squirrel.SelectBuilder, filter storage.OrderFilter) (*storage.OrderPage, error) {
+	if filter.Status != "" {
+		q = q.Where("o.status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		//one extra row tells whether there is a next page
+		q = q.Limit(uint64(filter.Limit) + 1)
+	}
+	orders, err := queryOrders(runner, q)
+	if err != nil {
+		return nil, err
+	}
+	page := storage.OrderPage{Orders: orders}
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		page.Orders = orders[:filter.Limit]
+		last := page.Orders[filter.Limit-1]
+		page.Next = &storage.OrderCursor{PlacedAt: last.PlacedAt, ID: last.ID}
+	}
+	return &page, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -2,37 +2,17 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Storage struct {
 	db *sql.DB
 }
-type UserInfo struct {
-	CoinHistory CoinHistory
-	Coins       int
-	Inventory   []InventoryEntry
-}
-type CoinHistory struct {
-	Received []TransactionReceived
-	Sent     []TransactionSent
-}
-type TransactionReceived struct {
-	Amount   int
-	FromUser string
-}
-type TransactionSent struct {
-	Amount int
-	ToUser string
-}
-type InventoryEntry struct {
-	Quantity int
-	Type     string
-}
 
 func New(port, user, password, name, host string) (*Storage, error) {
 	const op = "storage.postgres.New"
@@ -50,15 +30,59 @@ func New(port, user, password, name, host string) (*Storage, error) {
 }
 
 func (s *Storage) AddUser(name, passHash string) error {
+	return s.addUser(map[string]interface{}{"name": name, "pass_hash": passHash})
+}
+
+// AddExternalUser creates a user without a password, linked to the identity provider issuer.
+func (s *Storage) AddExternalUser(name, issuer string) error {
+	return s.addUser(map[string]interface{}{"name": name, "pass_hash": "", "auth_issuer": issuer})
+}
+
+// UserIssuer returns the identity provider issuer the user is linked to, it is empty for local users.
+func (s *Storage) UserIssuer(name string) (string, error) {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	_, err := psql.Insert("users").
-		Columns("name", "pass_hash").
-		Values(name, passHash).
-		RunWith(s.db).
-		Exec()
+	var issuer string
+	err := psql.Select("COALESCE(auth_issuer, '')").From("users").Where("name=?", name).RunWith(s.db).QueryRow().Scan(&issuer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user issuer: %w", err)
+	}
+	return issuer, nil
+}
+
+// addUser creates a user with the column values and records its start balance.
+func (s *Storage) addUser(values map[string]interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var userID int
+	err = psql.Insert("users").
+		SetMap(values).
+		Suffix("RETURNING id").
+		RunWith(tx).
+		QueryRow().
+		Scan(&userID)
+	if isUniqueViolation(err) {
+		return storage.ErrUserExists
+	}
 	if err != nil {
 		return err
 	}
+	_, err = record(tx, storage.KindSignup, nil, userID, storage.StartBalance,
+		systemPosting(accountIssuance, -storage.StartBalance),
+		userPosting(userID, storage.StartBalance),
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 func (s *Storage) UserPassHash(name string) (string, error) {
@@ -72,6 +96,30 @@ func (s *Storage) UserPassHash(name string) (string, error) {
 	return passHash, nil
 }
 
+func (s *Storage) SetUserPassHash(name, passHash string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	res, err := psql.Update("users").Set("pass_hash", passHash).Where("name=?", name).RunWith(s.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Storage) UserBalance(name string) (int, error) {
+	id, err := userID(s.db, name)
+	if err != nil {
+		return 0, err
+	}
+	return balance(s.db, id)
+}
+
 func (s *Storage) UserExist(name string) (bool, error) {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	var count int
@@ -85,7 +133,52 @@ func (s *Storage) UserExist(name string) (bool, error) {
 	return false, nil
 }
 
+func (s *Storage) UserRole(name string) (string, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var role string
+	err := psql.Select("role").From("users").Where("name=?", name).RunWith(s.db).QueryRow().Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+// SetUserRole changes the role of a user, service accounts are created and kept
+// as such, see ErrServiceAccountRole.
+func (s *Storage) SetUserRole(name, role string) error {
+	if role == storage.RoleService {
+		return storage.ErrServiceAccountRole
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	res, err := psql.Update("users").
+		Set("role", role).
+		Where("name=? AND role <> ?", name, storage.RoleService).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	if affected == 0 {
+		//either there is no such user or it is a service account
+		if _, err := s.UserRole(name); err != nil {
+			return err
+		}
+		return storage.ErrServiceAccountRole
+	}
+	return nil
+}
+
 func (s *Storage) SendCoins(fromUser string, toUser string, amount int) error {
+	if amount <= 0 {
+		return storage.ErrInvalidAmount
+	}
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -93,58 +186,58 @@ func (s *Storage) SendCoins(fromUser string, toUser string, amount int) error {
 	defer tx.Rollback()
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	var fromCoins, toCoins int
-	var fromUserId, toUserId int
-	err = psql.Select("id", "coins").
+	//both rows are locked in id order, so concurrent transfers
+	//between the same pair of users can not deadlock
+	rows, err := psql.Select("id", "name").
 		From("users").
-		Where("name=?", fromUser).
+		Where(squirrel.Eq{"name": []string{fromUser, toUser}}).
+		OrderBy("id").
+		Suffix("FOR UPDATE").
 		RunWith(tx).
-		QueryRow().
-		Scan(&fromUserId, &fromCoins)
+		Query()
 	if err != nil {
-		return fmt.Errorf("failed to get coins for fromUser: %w", err)
+		return fmt.Errorf("failed to lock users: %w", err)
 	}
-	if fromCoins < amount {
-		return storage.ErrUnsufficientBalance
+	var fromUserId, toUserId int
+	for rows.Next() {
+		var (
+			id   int
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to lock users: %w", err)
+		}
+		if name == fromUser {
+			fromUserId = id
+		}
+		if name == toUser {
+			toUserId = id
+		}
 	}
-	err = psql.Select("id", "coins").
-		From("users").
-		Where("name=?", toUser).
-		RunWith(tx).
-		QueryRow().
-		Scan(&toUserId, &toCoins)
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	if fromUserId == 0 {
+		return fmt.Errorf("failed to get coins for fromUser: %w", sql.ErrNoRows)
+	}
+	fromCoins, err := balance(tx, fromUserId)
 	if err != nil {
-		return fmt.Errorf("failed to get coins for toUser: %w", err)
+		return fmt.Errorf("failed to get coins for fromUser: %w", err)
 	}
-
 	if fromCoins < amount {
 		return storage.ErrUnsufficientBalance
 	}
-
-	_, err = psql.Update("users").
-		Set("coins", fromCoins-amount).
-		Where("name=?", fromUser).
-		RunWith(tx).
-		Exec()
-	if err != nil {
-		return fmt.Errorf("failed to update coins for fromUser: %w", err)
+	if toUserId == 0 {
+		return fmt.Errorf("failed to get coins for toUser: %w", sql.ErrNoRows)
 	}
 
-	_, err = psql.Update("users").
-		Set("coins", toCoins+amount).
-		Where("name=?", toUser).
-		RunWith(tx).
-		Exec()
-	if err != nil {
-		return fmt.Errorf("failed to update coins for toUser: %w", err)
-	}
-	_, err = psql.Insert("transactions").
-		Columns("from_user_id", "to_user_id", "amount").
-		Values(fromUserId, toUserId, amount).
-		RunWith(tx).
-		Exec()
+	_, err = record(tx, storage.KindTransfer, fromUserId, toUserId, amount,
+		userPosting(fromUserId, -amount),
+		userPosting(toUserId, amount),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to create transaction record: %w", err)
+		return err
 	}
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -152,17 +245,21 @@ func (s *Storage) SendCoins(fromUser string, toUser string, amount int) error {
 
 	return nil
 }
-func (s *Storage) UserInfo(user string) (*UserInfo, error) {
+func (s *Storage) UserInfo(user string) (*storage.UserInfo, error) {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	var userInfo UserInfo
+	var userInfo storage.UserInfo
 	var userID int
 	//balance
-	err := psql.Select("id", "coins").
+	err := psql.Select("id").
 		From("users").
 		Where("name=?", user).
 		RunWith(s.db).
 		QueryRow().
-		Scan(&userID, &userInfo.Coins)
+		Scan(&userID)
+	if err != nil {
+		return nil, err
+	}
+	userInfo.Coins, err = balance(s.db, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +274,7 @@ func (s *Storage) UserInfo(user string) (*UserInfo, error) {
 		return nil, err
 	}
 	for rows.Next() {
-		var ie InventoryEntry
+		var ie storage.InventoryEntry
 		if err := rows.Scan(&ie.Type, &ie.Quantity); err != nil {
 			return nil, err
 		}
@@ -185,10 +282,12 @@ func (s *Storage) UserInfo(user string) (*UserInfo, error) {
 	}
 	//coin history
 	//transactions SENT
-	tsRows, err := psql.Select("u.name", "t.amount").
+	tsRows, err := psql.Select("t.id", "u.name", "t.amount", "t.created_at").
 		From("transactions t").
 		Join("users u ON u.id = t.to_user_id").
-		Where("t.from_user_id = ?", userID).
+		Where("t.from_user_id = ? AND t.kind = ?", userID, storage.KindTransfer).
+		OrderBy("t.created_at DESC", "t.id DESC").
+		Limit(storage.InfoHistoryLimit).
 		RunWith(s.db).
 		Query()
 	if err != nil {
@@ -196,18 +295,20 @@ func (s *Storage) UserInfo(user string) (*UserInfo, error) {
 	}
 	for tsRows.Next() {
 		var (
-			trSent TransactionSent
+			trSent storage.TransactionSent
 		)
-		if err := tsRows.Scan(&trSent.ToUser, &trSent.Amount); err != nil {
+		if err := tsRows.Scan(&trSent.ID, &trSent.ToUser, &trSent.Amount, &trSent.CreatedAt); err != nil {
 			return nil, err
 		}
 		userInfo.CoinHistory.Sent = append(userInfo.CoinHistory.Sent, trSent)
 	}
 	//transactions RECEIVED
-	trRows, err := psql.Select("u.name", "t.amount").
+	trRows, err := psql.Select("t.id", "u.name", "t.amount", "t.created_at").
 		From("transactions t").
 		Join("users u ON u.id = t.from_user_id").
-		Where("t.to_user_id = ?", userID).
+		Where("t.to_user_id = ? AND t.kind = ?", userID, storage.KindTransfer).
+		OrderBy("t.created_at DESC", "t.id DESC").
+		Limit(storage.InfoHistoryLimit).
 		RunWith(s.db).
 		Query()
 	if err != nil {
@@ -215,17 +316,207 @@ func (s *Storage) UserInfo(user string) (*UserInfo, error) {
 	}
 	for trRows.Next() {
 		var (
-			trRcv TransactionReceived
+			trRcv storage.TransactionReceived
 		)
-		if err := trRows.Scan(&trRcv.FromUser, &trRcv.Amount); err != nil {
+		if err := trRows.Scan(&trRcv.ID, &trRcv.FromUser, &trRcv.Amount, &trRcv.CreatedAt); err != nil {
 			return nil, err
 		}
 		userInfo.CoinHistory.Received = append(userInfo.CoinHistory.Received, trRcv)
 	}
+	//grants and clawbacks
+	aRows, err := psql.Select("t.id", "t.kind", "t.amount", "t.reason", "t.created_at").
+		From("transactions t").
+		Where("(t.kind = ? AND t.to_user_id = ?) OR (t.kind = ? AND t.from_user_id = ?)",
+			storage.KindGrant, userID, storage.KindClawback, userID).
+		OrderBy("t.created_at DESC", "t.id DESC").
+		Limit(storage.InfoHistoryLimit).
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer aRows.Close()
+	for aRows.Next() {
+		var a storage.Adjustment
+		if err := aRows.Scan(&a.ID, &a.Kind, &a.Amount, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		userInfo.CoinHistory.Adjustments = append(userInfo.CoinHistory.Adjustments, a)
+	}
+	if err := aRows.Err(); err != nil {
+		return nil, err
+	}
+	//refunds of cancelled orders
+	rfRows, err := psql.Select("t.id", "o.id", "t.amount", "t.created_at").
+		From("transactions t").
+		Join("orders o ON o.refund_transaction_id = t.id").
+		Where("t.to_user_id = ? AND t.kind = ?", userID, storage.KindRefund).
+		OrderBy("t.created_at DESC", "t.id DESC").
+		Limit(storage.InfoHistoryLimit).
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rfRows.Close()
+	for rfRows.Next() {
+		var r storage.Refund
+		if err := rfRows.Scan(&r.ID, &r.OrderID, &r.Amount, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		userInfo.CoinHistory.Refunds = append(userInfo.CoinHistory.Refunds, r)
+	}
+	if err := rfRows.Err(); err != nil {
+		return nil, err
+	}
+	//purchases
+	pRows, err := psql.Select("m.name", "p.price", "p.quantity", "p.created_at").
+		From("purchases p").
+		Join("merch m ON m.id = p.merch_id").
+		Where("p.user_id = ?", userID).
+		OrderBy("p.created_at DESC", "p.id DESC").
+		Limit(storage.InfoHistoryLimit).
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer pRows.Close()
+	for pRows.Next() {
+		var p storage.Purchase
+		if err := pRows.Scan(&p.Item, &p.Price, &p.Quantity, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		userInfo.Purchases = append(userInfo.Purchases, p)
+	}
+	if err := pRows.Err(); err != nil {
+		return nil, err
+	}
 	return &userInfo, nil
 }
 
-func (s *Storage) Buy(item string, user string) error {
+// GrantCoins gives amount coins to every user in users. Either all users get
+// their coins or none does.
+func (s *Storage) GrantCoins(admin string, users []string, amount int, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	adminID, err := userID(tx, admin)
+	if err != nil {
+		return err
+	}
+	rows, err := psql.Select("id", "name").
+		From("users").
+		Where(squirrel.Eq{"name": users}).
+		RunWith(tx).
+		Query()
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	ids := make(map[string]int, len(users))
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to get users: %w", err)
+		}
+		ids[name] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, name := range users {
+		if _, ok := ids[name]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrUserNotFound, name)
+		}
+	}
+	for _, name := range users {
+		id := ids[name]
+		_, err := recordAdjustment(tx, storage.KindGrant, nil, id, amount, reason, adminID,
+			systemPosting(accountIssuance, -amount),
+			userPosting(id, amount),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClawbackCoins takes amount coins away from user, the balance can't go below zero.
+func (s *Storage) ClawbackCoins(admin string, user string, amount int, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	adminID, err := userID(tx, admin)
+	if err != nil {
+		return err
+	}
+	var id int
+	err = psql.Select("id").
+		From("users").
+		Where("name=?", user).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		QueryRow().
+		Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", storage.ErrUserNotFound, user)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	coins, err := balance(tx, id)
+	if err != nil {
+		return err
+	}
+	if coins < amount {
+		return storage.ErrUnsufficientBalance
+	}
+	_, err = recordAdjustment(tx, storage.KindClawback, id, nil, amount, reason, adminID,
+		userPosting(id, -amount),
+		systemPosting(accountIssuance, amount),
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// userID looks up the id of the user with the given name.
+func userID(runner squirrel.BaseRunner, name string) (int, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var id int
+	err := psql.Select("id").From("users").Where("name=?", name).RunWith(runner).QueryRow().Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", storage.ErrUserNotFound, name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+	return id, nil
+}
+
+// Buy buys quantity items at the current price. The purchase is a single
+// ledger transaction and a single purchases row.
+func (s *Storage) Buy(item string, user string, quantity int) error {
+	if quantity < 1 {
+		return storage.ErrInvalidQuantity
+	}
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -233,42 +524,66 @@ func (s *Storage) Buy(item string, user string) error {
 	defer tx.Rollback()
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	var userBalance, userID, itemPrice, itemID int
-	err = psql.Select("id", "coins").
+	err = psql.Select("id").
 		From("users").
 		Where("name=?", user).
+		Suffix("FOR UPDATE").
 		RunWith(tx).
 		QueryRow().
-		Scan(&userID, &userBalance)
+		Scan(&userID)
+	if err != nil {
+		return fmt.Errorf("failed to get coins for user: %w", err)
+	}
+	userBalance, err = balance(tx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get coins for user: %w", err)
 	}
 
-	err = psql.Select("price", "id").
+	var itemAvailable bool
+	err = psql.Select("price", "id", "available").
 		From("merch").
-		Where("name=?", item).
+		Where("name=? AND retired_at IS NULL", item).
 		RunWith(tx).
 		QueryRow().
-		Scan(&itemPrice, &itemID)
+		Scan(&itemPrice, &itemID, &itemAvailable)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrItemNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get items info: %w", err)
 	}
+	if !itemAvailable {
+		return storage.ErrItemUnavailable
+	}
 
-	if userBalance < itemPrice {
+	total := itemPrice * quantity
+	if userBalance < total {
 		return storage.ErrUnsufficientBalance
 	}
 
-	_, err = psql.Update("users").
-		Set("coins", userBalance-itemPrice).
-		Where("name=?", user).
+	transactionID, err := record(tx, storage.KindPurchase, userID, nil, total,
+		userPosting(userID, -total),
+		systemPosting(accountShop, total),
+	)
+	if err != nil {
+		return err
+	}
+	if err := placeOrder(tx, userID, transactionID, total); err != nil {
+		return err
+	}
+
+	_, err = psql.Insert("purchases").
+		Columns("user_id", "merch_id", "transaction_id", "price", "quantity").
+		Values(userID, itemID, transactionID, itemPrice, quantity).
 		RunWith(tx).
 		Exec()
 	if err != nil {
-		return fmt.Errorf("failed to update coins for fromUser: %w", err)
+		return fmt.Errorf("failed to create purchase record: %w", err)
 	}
 
 	_, err = psql.Insert("user_inventory").
 		Columns("user_id", "merch_id", "quantity").
-		Values(userID, itemID, 1).
+		Values(userID, itemID, quantity).
 		RunWith(tx).
 		Suffix("ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity").
 		Exec()
@@ -285,7 +600,7 @@ func (s *Storage) Buy(item string, user string) error {
 func (s *Storage) ItemExist(name string) (bool, error) {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	var count int
-	err := psql.Select("COUNT(*)").From("merch").Where("name=?", name).RunWith(s.db).Scan(&count)
+	err := psql.Select("COUNT(*)").From("merch").Where("name=? AND retired_at IS NULL", name).RunWith(s.db).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -294,3 +609,228 @@ func (s *Storage) ItemExist(name string) (bool, error) {
 	}
 	return false, nil
 }
+
+func (s *Storage) MerchList() ([]storage.MerchItem, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err := psql.Select("name", "price", "description", "available").
+		From("merch").
+		Where("retired_at IS NULL").
+		OrderBy("id").
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []storage.MerchItem
+	for rows.Next() {
+		var item storage.MerchItem
+		if err := rows.Scan(&item.Name, &item.Price, &item.Description, &item.Available); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Storage) MerchItem(name string) (*storage.MerchItem, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var item storage.MerchItem
+	err := psql.Select("name", "price", "description", "available").
+		From("merch").
+		Where("name=? AND retired_at IS NULL", name).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&item.Name, &item.Price, &item.Description, &item.Available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Storage) AddMerchItem(item storage.MerchItem) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Insert("merch").
+		Columns("name", "price", "description", "available").
+		Values(item.Name, item.Price, item.Description, item.Available).
+		RunWith(s.db).
+		Exec()
+	if isUniqueViolation(err) {
+		return storage.ErrItemExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add item: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) UpdateMerchItem(name string, update storage.MerchUpdate) (*storage.MerchItem, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.Update("merch")
+	changed := false
+	if update.Name != nil {
+		query = query.Set("name", *update.Name)
+		changed = true
+	}
+	if update.Price != nil {
+		query = query.Set("price", *update.Price)
+		changed = true
+	}
+	if update.Description != nil {
+		query = query.Set("description", *update.Description)
+		changed = true
+	}
+	if update.Available != nil {
+		query = query.Set("available", *update.Available)
+		changed = true
+	}
+	if !changed {
+		return s.MerchItem(name)
+	}
+	var item storage.MerchItem
+	err := query.Where("name=? AND retired_at IS NULL", name).
+		Suffix("RETURNING name, price, description, available").
+		RunWith(s.db).
+		QueryRow().
+		Scan(&item.Name, &item.Price, &item.Description, &item.Available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrItemNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, storage.ErrItemExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+	return &item, nil
+}
+
+// RetireMerchItem removes the item from the catalog. The row is kept so that
+// inventory and purchase records referencing it stay valid.
+func (s *Storage) RetireMerchItem(name string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	res, err := psql.Update("merch").
+		Set("retired_at", squirrel.Expr("NOW()")).
+		Where("name=? AND retired_at IS NULL", name).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to retire item: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retire item: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrItemNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (s *Storage) UserLedger(user string) ([]storage.LedgerEntry, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	rows, err := psql.Select("l.transaction_id", "t.kind", "l.amount").
+		From("ledger l").
+		Join("transactions t ON t.id = l.transaction_id").
+		Join("users u ON u.id = l.user_id").
+		Where("u.name = ? AND l.account = ?", user, accountUser).
+		OrderBy("l.id").
+		RunWith(s.db).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []storage.LedgerEntry
+	for rows.Next() {
+		var e storage.LedgerEntry
+		if err := rows.Scan(&e.TransactionID, &e.Kind, &e.Amount); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *Storage) CoinHistory(user string, filter storage.HistoryFilter) (*storage.HistoryPage, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var userID int
+	err := psql.Select("id").
+		From("users").
+		Where("name=?", user).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := squirrel.Select("t.id", "'sent' AS direction", "u.name AS counterparty", "t.amount", "t.created_at").
+		From("transactions t").
+		Join("users u ON u.id = t.to_user_id").
+		Where("t.from_user_id = ? AND t.kind = ?", userID, storage.KindTransfer)
+	received := squirrel.Select("t.id", "'received' AS direction", "u.name AS counterparty", "t.amount", "t.created_at").
+		From("transactions t").
+		Join("users u ON u.id = t.from_user_id").
+		Where("t.to_user_id = ? AND t.kind = ?", userID, storage.KindTransfer)
+	var history squirrel.SelectBuilder
+	switch filter.Direction {
+	case storage.DirectionSent:
+		history = sent
+	case storage.DirectionReceived:
+		history = received
+	default:
+		history = sent.SuffixExpr(squirrel.ConcatExpr("UNION ALL ", received))
+	}
+
+	query := psql.Select("id", "direction", "counterparty", "amount", "created_at").
+		FromSelect(history, "h").
+		OrderBy("id DESC", "direction DESC")
+	if filter.Counterparty != "" {
+		query = query.Where("counterparty = ?", filter.Counterparty)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.After != nil {
+		query = query.Where("(id, direction) < (?, ?)", filter.After.ID, filter.After.Direction)
+	}
+	if filter.Limit > 0 {
+		//one extra row tells whether there is a next page
+		query = query.Limit(uint64(filter.Limit) + 1)
+	}
+
+	rows, err := query.RunWith(s.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var page storage.HistoryPage
+	for rows.Next() {
+		var e storage.HistoryEntry
+		if err := rows.Scan(&e.ID, &e.Direction, &e.Counterparty, &e.Amount, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if filter.Limit > 0 && len(page.Entries) > filter.Limit {
+		page.Entries = page.Entries[:filter.Limit]
+		last := page.Entries[filter.Limit-1]
+		page.Next = &storage.HistoryCursor{ID: last.ID, Direction: last.Direction}
+	}
+	return &page, nil
+}
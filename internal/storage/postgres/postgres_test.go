@@ -1,14 +1,38 @@
 package postgres
 
 import (
-	"fmt"
+	"database/sql"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/config"
 	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage/storagetest"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// TestConformance runs the shared storage suite against a live database.
+// It is skipped unless TEST_DATABASE_HOST points to a migrated Postgres instance;
+// the remaining connection settings are read like in config.Config.
+func TestConformance(t *testing.T) {
+	host := os.Getenv("TEST_DATABASE_HOST")
+	if host == "" {
+		t.Skip("TEST_DATABASE_HOST is not set")
+	}
+	cfg := config.MustLoad()
+	s, err := New(cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName, host)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.db.Close() })
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		return s
+	})
+}
+
 func TestUserExist(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	assert.NoError(t, err)
@@ -42,15 +66,61 @@ func TestAddUser(t *testing.T) {
 
 	storage := &Storage{db: db}
 
-	mock.ExpectExec("INSERT INTO users (name,pass_hash) VALUES ($1,$2)").
+	//user is created together with the signup grant in the ledger
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users (name,pass_hash) VALUES ($1,$2) RETURNING id").
 		WithArgs("testuser", "hashedpassword").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("signup", nil, 1, 1000).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(7, "issuance", nil, -1000, 7, "user", 1, 1000).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
 
 	err = storage.AddUser("testuser", "hashedpassword")
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAddExistingUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users (name,pass_hash) VALUES ($1,$2) RETURNING id").
+		WithArgs("testuser", "hashedpassword").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err = s.AddUser("testuser", "hashedpassword")
+	assert.ErrorIs(t, err, storage.ErrUserExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetUserPassHash(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectExec("UPDATE users SET pass_hash = $1 WHERE name=$2").
+		WithArgs("newhash", "testuser").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET pass_hash = $1 WHERE name=$2").
+		WithArgs("newhash", "nonexistinguser").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, s.SetUserPassHash("testuser", "newhash"))
+	assert.ErrorIs(t, s.SetUserPassHash("nonexistinguser", "newhash"), storage.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserPassHash(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	assert.NoError(t, err)
@@ -81,21 +151,20 @@ func TestSendCoinsSuccess(t *testing.T) {
 	initBalance := 1000
 	//Expecting that amount will be substracted from fromUser balance and added to toUser balance
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
-		WithArgs(fromUser).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, initBalance)) // init balance fromUser
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
-		WithArgs(toUser).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(2, initBalance)) // init balance toUser
-	mock.ExpectExec("UPDATE users SET coins = $1 WHERE name=$2").
-		WithArgs(initBalance-amount, fromUser).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("UPDATE users SET coins = $1 WHERE name=$2").
-		WithArgs(initBalance+amount, toUser).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("INSERT INTO transactions (from_user_id,to_user_id,amount) VALUES ($1,$2,$3)").
-		WithArgs(1, 2, amount).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, name FROM users WHERE name IN ($1,$2) ORDER BY id FOR UPDATE").
+		WithArgs(fromUser, toUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, fromUser).
+			AddRow(2, toUser))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(initBalance)) // init balance fromUser
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("transfer", 1, 2, amount).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(3, "user", 1, -amount, 3, "user", 2, amount).
+		WillReturnResult(sqlmock.NewResult(1, 2))
 	mock.ExpectCommit()
 
 	err = s.SendCoins(fromUser, toUser, amount)
@@ -118,9 +187,14 @@ func TestSendCoinsInsufficientBalance(t *testing.T) {
 	initBalance := 1000
 	//Expecting that insufficient balance error will return
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
-		WithArgs(fromUser).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, initBalance))
+	mock.ExpectQuery("SELECT id, name FROM users WHERE name IN ($1,$2) ORDER BY id FOR UPDATE").
+		WithArgs(fromUser, toUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, fromUser).
+			AddRow(2, toUser))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(initBalance))
 	mock.ExpectRollback()
 
 	err = s.SendCoins(fromUser, toUser, amount)
@@ -142,9 +216,9 @@ func TestSendCoinsUserNotFound(t *testing.T) {
 	amount := 100
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
-		WithArgs(fromUser).
-		WillReturnError(fmt.Errorf("user not found"))
+	mock.ExpectQuery("SELECT id, name FROM users WHERE name IN ($1,$2) ORDER BY id FOR UPDATE").
+		WithArgs(fromUser, toUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, toUser)) // fromUser not found
 	mock.ExpectRollback()
 
 	err = s.SendCoins(fromUser, toUser, amount)
@@ -161,12 +235,15 @@ func TestUserInfo(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	storage := &Storage{db: db}
+	s := &Storage{db: db}
 	coins := 100
 	//Coins
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
 		WithArgs("testuser").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, coins))
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(coins))
 
 	//Inventory
 	mock.ExpectQuery("SELECT m.name, ui.quantity FROM user_inventory ui JOIN merch m ON ui.merch_id = m.id WHERE ui.user_id = $1").
@@ -176,15 +253,34 @@ func TestUserInfo(t *testing.T) {
 			AddRow("item2", 3))
 
 	//Transactions sent
-	mock.ExpectQuery("SELECT u.name, t.amount FROM transactions t JOIN users u ON u.id = t.to_user_id WHERE t.from_user_id = $1").
-		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"name", "amount"}).AddRow("to1", 5).AddRow("to2", 10))
+	sentAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT t.id, u.name, t.amount, t.created_at FROM transactions t JOIN users u ON u.id = t.to_user_id WHERE t.from_user_id = $1 AND t.kind = $2 ORDER BY t.created_at DESC, t.id DESC LIMIT 100").
+		WithArgs(1, "transfer").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "amount", "created_at"}).AddRow(4, "to1", 5, sentAt).AddRow(3, "to2", 10, sentAt))
 
 	//Transactions received
-	mock.ExpectQuery("SELECT u.name, t.amount FROM transactions t JOIN users u ON u.id = t.from_user_id WHERE t.to_user_id = $1").
+	mock.ExpectQuery("SELECT t.id, u.name, t.amount, t.created_at FROM transactions t JOIN users u ON u.id = t.from_user_id WHERE t.to_user_id = $1 AND t.kind = $2 ORDER BY t.created_at DESC, t.id DESC LIMIT 100").
+		WithArgs(1, "transfer").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "amount", "created_at"}).AddRow(2, "from1", 5, sentAt).AddRow(1, "from2", 10, sentAt))
+
+	//Grants and clawbacks
+	grantedAt := time.Date(2025, 2, 1, 11, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT t.id, t.kind, t.amount, t.reason, t.created_at FROM transactions t WHERE (t.kind = $1 AND t.to_user_id = $2) OR (t.kind = $3 AND t.from_user_id = $4) ORDER BY t.created_at DESC, t.id DESC LIMIT 100").
+		WithArgs("grant", 1, "clawback", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "amount", "reason", "created_at"}).AddRow(5, "grant", 50, "quarterly bonus", grantedAt))
+
+	//Refunds
+	refundedAt := time.Date(2025, 2, 1, 13, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT t.id, o.id, t.amount, t.created_at FROM transactions t JOIN orders o ON o.refund_transaction_id = t.id WHERE t.to_user_id = $1 AND t.kind = $2 ORDER BY t.created_at DESC, t.id DESC LIMIT 100").
+		WithArgs(1, "refund").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "amount", "created_at"}).AddRow(8, 3, 20, refundedAt))
+
+	//Purchases
+	boughtAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT m.name, p.price, p.quantity, p.created_at FROM purchases p JOIN merch m ON m.id = p.merch_id WHERE p.user_id = $1 ORDER BY p.created_at DESC, p.id DESC LIMIT 100").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"name", "amount"}).AddRow("from1", 5).AddRow("from2", 10))
-	userInfo, err := storage.UserInfo("testuser")
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "quantity", "created_at"}).AddRow("hoody", 300, 2, boughtAt))
+	userInfo, err := s.UserInfo("testuser")
 	if err != nil {
 		t.Errorf("error was not expected while getting user info: %s", err)
 	}
@@ -198,9 +294,15 @@ func TestUserInfo(t *testing.T) {
 	assert.Len(t, userInfo.CoinHistory.Sent, 2)
 	assert.Equal(t, "to1", userInfo.CoinHistory.Sent[0].ToUser)
 	assert.Equal(t, 5, userInfo.CoinHistory.Sent[0].Amount)
+	assert.Equal(t, 4, userInfo.CoinHistory.Sent[0].ID)
+	assert.Equal(t, sentAt, userInfo.CoinHistory.Sent[0].CreatedAt)
 	assert.Len(t, userInfo.CoinHistory.Received, 2)
 	assert.Equal(t, "from1", userInfo.CoinHistory.Received[0].FromUser)
 	assert.Equal(t, 5, userInfo.CoinHistory.Received[0].Amount)
+	assert.Equal(t, 2, userInfo.CoinHistory.Received[0].ID)
+	assert.Equal(t, []storage.Purchase{{Item: "hoody", Price: 300, Quantity: 2, CreatedAt: boughtAt}}, userInfo.Purchases)
+	assert.Equal(t, []storage.Adjustment{{ID: 5, Kind: "grant", Amount: 50, Reason: "quarterly bonus", CreatedAt: grantedAt}}, userInfo.CoinHistory.Adjustments)
+	assert.Equal(t, []storage.Refund{{ID: 8, OrderID: 3, Amount: 20, CreatedAt: refundedAt}}, userInfo.CoinHistory.Refunds)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -219,27 +321,84 @@ func TestBuySuccess(t *testing.T) {
 	price := 100
 	//Expecting that amount will be substracted from buyer balance and added to toUser balance
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
 		WithArgs(buyer).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, initBalance)) // init balance for buyer
-	mock.ExpectQuery("SELECT price, id FROM merch WHERE name=$1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(initBalance)) // init balance for buyer
+	mock.ExpectQuery("SELECT price, id, available FROM merch WHERE name=$1 AND retired_at IS NULL").
 		WithArgs(item).
-		WillReturnRows(sqlmock.NewRows([]string{"price", "id"}).AddRow(price, 1)) // merch price
-	mock.ExpectExec("UPDATE users SET coins = $1 WHERE name=$2").
-		WithArgs(initBalance-price, buyer).
+		WillReturnRows(sqlmock.NewRows([]string{"price", "id", "available"}).AddRow(price, 1, true)) // merch price
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("purchase", 1, nil, price).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(5, "user", 1, -price, 5, "shop", nil, price).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO orders (user_id,transaction_id,total) VALUES ($1,$2,$3)").
+		WithArgs(1, 5, price).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO purchases (user_id,merch_id,transaction_id,price,quantity) VALUES ($1,$2,$3,$4,$5)").
+		WithArgs(1, 1, 5, price, 1).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("INSERT INTO user_inventory (user_id,merch_id,quantity) VALUES ($1,$2,$3) ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity").
 		WithArgs(1, 1, 1).
 		WillReturnResult(sqlmock.NewResult(1, 3))
 	mock.ExpectCommit()
 
-	err = s.Buy(item, buyer)
+	err = s.Buy(item, buyer, 1)
 
 	assert.NoError(t, err)
 
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+func TestBuyQuantity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	buyer := "buyer"
+	item := "pen"
+	price := 10
+	//Expecting a single transaction for the whole purchase and a single purchase row
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
+		WithArgs(buyer).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(50))
+	mock.ExpectQuery("SELECT price, id, available FROM merch WHERE name=$1 AND retired_at IS NULL").
+		WithArgs(item).
+		WillReturnRows(sqlmock.NewRows([]string{"price", "id", "available"}).AddRow(price, 2, true))
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("purchase", 1, nil, 5*price).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(7, "user", 1, -5*price, 7, "shop", nil, 5*price).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO orders (user_id,transaction_id,total) VALUES ($1,$2,$3)").
+		WithArgs(1, 7, 5*price).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO purchases (user_id,merch_id,transaction_id,price,quantity) VALUES ($1,$2,$3,$4,$5)").
+		WithArgs(1, 2, 7, price, 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO user_inventory (user_id,merch_id,quantity) VALUES ($1,$2,$3) ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity").
+		WithArgs(1, 2, 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, s.Buy(item, buyer, 5))
+	assert.ErrorIs(t, s.Buy(item, buyer, 0), storage.ErrInvalidQuantity)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
 func TestBuyInsufficientBalance(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	assert.NoError(t, err)
@@ -253,18 +412,762 @@ func TestBuyInsufficientBalance(t *testing.T) {
 	price := 1000
 	//Expecting that insufficient balance error will return
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, coins FROM users WHERE name=$1").
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
 		WithArgs(buyer).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, initBalance)) // init balance for buyer
-	mock.ExpectQuery("SELECT price, id FROM merch WHERE name=$1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(initBalance)) // init balance for buyer
+	mock.ExpectQuery("SELECT price, id, available FROM merch WHERE name=$1 AND retired_at IS NULL").
 		WithArgs(item).
-		WillReturnRows(sqlmock.NewRows([]string{"price", "id"}).AddRow(price, 1)) // merch price
+		WillReturnRows(sqlmock.NewRows([]string{"price", "id", "available"}).AddRow(price, 1, true)) // merch price
 	mock.ExpectRollback()
 
-	err = s.Buy(item, buyer)
+	err = s.Buy(item, buyer, 1)
 
 	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
 
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestUserLedger(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectQuery("SELECT l.transaction_id, t.kind, l.amount FROM ledger l JOIN transactions t ON t.id = l.transaction_id JOIN users u ON u.id = l.user_id WHERE u.name = $1 AND l.account = $2 ORDER BY l.id").
+		WithArgs("testuser", "user").
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "kind", "amount"}).
+			AddRow(1, "signup", 1000).
+			AddRow(4, "purchase", -80))
+
+	entries, err := s.UserLedger("testuser")
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.LedgerEntry{
+		{TransactionID: 1, Kind: "signup", Amount: 1000},
+		{TransactionID: 4, Kind: "purchase", Amount: -80},
+	}, entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCoinHistory(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	sentAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	//one extra row is requested to detect the next page
+	mock.ExpectQuery("SELECT id, direction, counterparty, amount, created_at FROM "+
+		"(SELECT t.id, 'sent' AS direction, u.name AS counterparty, t.amount, t.created_at FROM transactions t JOIN users u ON u.id = t.to_user_id WHERE t.from_user_id = $1 AND t.kind = $2 "+
+		"UNION ALL SELECT t.id, 'received' AS direction, u.name AS counterparty, t.amount, t.created_at FROM transactions t JOIN users u ON u.id = t.from_user_id WHERE t.to_user_id = $3 AND t.kind = $4) AS h "+
+		"WHERE counterparty = $5 AND (id, direction) < ($6, $7) ORDER BY id DESC, direction DESC LIMIT 3").
+		WithArgs(1, "transfer", 1, "transfer", "other", 10, "sent").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "direction", "counterparty", "amount", "created_at"}).
+			AddRow(9, "sent", "other", 5, sentAt).
+			AddRow(8, "received", "other", 7, sentAt).
+			AddRow(6, "sent", "other", 1, sentAt))
+
+	page, err := s.CoinHistory("testuser", storage.HistoryFilter{
+		Counterparty: "other",
+		After:        &storage.HistoryCursor{ID: 10, Direction: "sent"},
+		Limit:        2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.HistoryEntry{
+		{ID: 9, Direction: "sent", Counterparty: "other", Amount: 5, CreatedAt: sentAt},
+		{ID: 8, Direction: "received", Counterparty: "other", Amount: 7, CreatedAt: sentAt},
+	}, page.Entries)
+	assert.Equal(t, &storage.HistoryCursor{ID: 8, Direction: "received"}, page.Next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuyUnavailableItem(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
+		WithArgs("buyer").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1000))
+	mock.ExpectQuery("SELECT price, id, available FROM merch WHERE name=$1 AND retired_at IS NULL").
+		WithArgs("t-shirt").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "id", "available"}).AddRow(80, 1, false))
+	mock.ExpectRollback()
+
+	err = s.Buy("t-shirt", "buyer", 1)
+	assert.ErrorIs(t, err, storage.ErrItemUnavailable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchItem(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectQuery("SELECT name, price, description, available FROM merch WHERE name=$1 AND retired_at IS NULL").
+		WithArgs("cup").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "description", "available"}).AddRow("cup", 20, "Кружка", true))
+	item, err := s.MerchItem("cup")
+	assert.NoError(t, err)
+	assert.Equal(t, &storage.MerchItem{Name: "cup", Price: 20, Description: "Кружка", Available: true}, item)
+
+	//not found
+	mock.ExpectQuery("SELECT name, price, description, available FROM merch WHERE name=$1 AND retired_at IS NULL").
+		WithArgs("nonexistingitem").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "description", "available"}))
+	_, err = s.MerchItem("nonexistingitem")
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMerchItem(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	price := 25
+	mock.ExpectQuery("UPDATE merch SET price = $1 WHERE name=$2 AND retired_at IS NULL RETURNING name, price, description, available").
+		WithArgs(price, "cup").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "description", "available"}).AddRow("cup", price, "Кружка", true))
+	item, err := s.UpdateMerchItem("cup", storage.MerchUpdate{Price: &price})
+	assert.NoError(t, err)
+	assert.Equal(t, &storage.MerchItem{Name: "cup", Price: price, Description: "Кружка", Available: true}, item)
+
+	//retired or missing item
+	mock.ExpectQuery("UPDATE merch SET price = $1 WHERE name=$2 AND retired_at IS NULL RETURNING name, price, description, available").
+		WithArgs(price, "nonexistingitem").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "description", "available"}))
+	_, err = s.UpdateMerchItem("nonexistingitem", storage.MerchUpdate{Price: &price})
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetireMerchItem(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	mock.ExpectExec("UPDATE merch SET retired_at = NOW() WHERE name=$1 AND retired_at IS NULL").
+		WithArgs("cup").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.RetireMerchItem("cup"))
+
+	//already retired
+	mock.ExpectExec("UPDATE merch SET retired_at = NOW() WHERE name=$1 AND retired_at IS NULL").
+		WithArgs("cup").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, s.RetireMerchItem("cup"), storage.ErrItemNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	mock.ExpectExec("UPDATE users SET role = $1 WHERE name=$2 AND role <> $3").
+		WithArgs(storage.RoleAdmin, "testuser", storage.RoleService).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.SetUserRole("testuser", storage.RoleAdmin))
+
+	mock.ExpectQuery("SELECT role FROM users WHERE name=$1").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(storage.RoleAdmin))
+	role, err := s.UserRole("testuser")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.RoleAdmin, role)
+
+	//unknown user
+	mock.ExpectExec("UPDATE users SET role = $1 WHERE name=$2 AND role <> $3").
+		WithArgs(storage.RoleAdmin, "nonexistinguser", storage.RoleService).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT role FROM users WHERE name=$1").
+		WithArgs("nonexistinguser").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}))
+	assert.ErrorIs(t, s.SetUserRole("nonexistinguser", storage.RoleAdmin), storage.ErrUserNotFound)
+
+	//service accounts keep their role
+	mock.ExpectExec("UPDATE users SET role = $1 WHERE name=$2 AND role <> $3").
+		WithArgs(storage.RoleUser, "hr-sync", storage.RoleService).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT role FROM users WHERE name=$1").
+		WithArgs("hr-sync").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(storage.RoleService))
+	assert.ErrorIs(t, s.SetUserRole("hr-sync", storage.RoleUser), storage.ErrServiceAccountRole)
+	assert.ErrorIs(t, s.SetUserRole("testuser", storage.RoleService), storage.ErrServiceAccountRole)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClawbackCoinsInsufficientBalance(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(10))
+	mock.ExpectRollback()
+
+	err = s.ClawbackCoins("admin", "testuser", 20, "mistaken grant")
+	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGrantCoins(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id, name FROM users WHERE name IN ($1,$2)").
+		WithArgs("user1", "user2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "user1").AddRow(3, "user2"))
+	for i, id := range []int{2, 3} {
+		mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount,reason,created_by) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id").
+			WithArgs("grant", nil, id, 50, "quarterly bonus", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10 + i))
+		mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+			WithArgs(10+i, "issuance", nil, -50, 10+i, "user", id, 50).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, s.GrantCoins("admin", []string{"user1", "user2"}, 50, "quarterly bonus"))
+
+	//unknown user, nobody gets coins
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id, name FROM users WHERE name IN ($1,$2)").
+		WithArgs("user1", "nonexistinguser").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "user1"))
+	mock.ExpectRollback()
+	err = s.GrantCoins("admin", []string{"user1", "nonexistinguser"}, 50, "quarterly bonus")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	//expired families of the user are pruned before the new one is stored
+	mock.ExpectExec("DELETE FROM refresh_tokens rt WHERE rt.user_id = $1 AND rt.expires_at < NOW() AND NOT EXISTS (SELECT 1 FROM refresh_tokens live WHERE live.family = rt.family AND live.expires_at >= NOW())").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO refresh_tokens (user_id,token_hash,family,expires_at) VALUES ($1,$2,$3,$4)").
+		WithArgs(2, "hash", "fam", expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = s.AddRefreshToken("testuser", storage.RefreshToken{Hash: "hash", Family: "fam", ExpiresAt: expiresAt})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	selectToken := "SELECT rt.id, rt.user_id, u.name, rt.family, rt.expires_at, rt.used_at, rt.revoked_at FROM refresh_tokens rt JOIN users u ON u.id = rt.user_id WHERE rt.token_hash = $1 FOR UPDATE OF rt"
+	columns := []string{"id", "user_id", "name", "family", "expires_at", "used_at", "revoked_at"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectToken).
+		WithArgs("old").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 2, "testuser", "fam", expiresAt, nil, nil))
+	mock.ExpectExec("UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens (user_id,token_hash,family,expires_at) VALUES ($1,$2,$3,$4)").
+		WithArgs(2, "new", "fam", expiresAt).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	user, err := s.RotateRefreshToken("old", "new", expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", user)
+
+	//reuse revokes the family and still commits
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectToken).
+		WithArgs("old").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 2, "testuser", "fam", expiresAt, time.Now(), nil))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE family = $1 AND revoked_at IS NULL").
+		WithArgs("fam").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	_, err = s.RotateRefreshToken("old", "newer", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrTokenReused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTokenRevoked(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	revokedAt := time.Now()
+
+	mock.ExpectQuery("SELECT 1 FROM revoked_tokens WHERE jti = $1").
+		WithArgs("jti").
+		WillReturnRows(sqlmock.NewRows([]string{"?column?"}))
+	mock.ExpectQuery("SELECT tokens_valid_after FROM users WHERE name = $1").
+		WithArgs("testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"tokens_valid_after"}).AddRow(revokedAt))
+
+	revoked, err := s.TokenRevoked("testuser", "jti", revokedAt.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	mock.ExpectQuery("SELECT 1 FROM revoked_tokens WHERE jti = $1").
+		WithArgs("jti").
+		WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+
+	revoked, err = s.TokenRevoked("testuser", "jti", revokedAt.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	since := time.Now().Add(-time.Hour)
+
+	lockout := storage.LoginLockout{MaxFailures: 5, LockDuration: time.Minute, MaxLockDuration: time.Hour}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO login_attempts (key,failures,last_failure_at) VALUES ($1,$2,NOW()) ON CONFLICT (key) DO NOTHING").
+		WithArgs("user:testuser", 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1 FOR UPDATE").
+		WithArgs("user:testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).AddRow(4, time.Now().Add(-time.Minute), nil))
+	//the fifth failure locks the key
+	mock.ExpectExec("UPDATE login_attempts SET failures = $1, last_failure_at = $2, locked_until = $3 WHERE key = $4").
+		WithArgs(5, sqlmock.AnyArg(), sqlmock.AnyArg(), "user:testuser").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts, err := s.AddLoginFailure("user:testuser", since, lockout)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, attempts.Failures)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), attempts.LockedUntil, 5*time.Second)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddLoginFailureLocked(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	lockedUntil := time.Now().Add(time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO login_attempts (key,failures,last_failure_at) VALUES ($1,$2,NOW()) ON CONFLICT (key) DO NOTHING").
+		WithArgs("user:testuser", 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1 FOR UPDATE").
+		WithArgs("user:testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).AddRow(5, time.Now(), lockedUntil))
+	mock.ExpectRollback()
+
+	attempts, err := s.AddLoginFailure("user:testuser", time.Now().Add(-time.Hour), storage.LoginLockout{MaxFailures: 5})
+	assert.ErrorIs(t, err, storage.ErrLoginLocked)
+	assert.Equal(t, 5, attempts.Failures)
+	assert.True(t, lockedUntil.Equal(attempts.LockedUntil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	query := "UPDATE api_keys k SET last_used_at = NOW() FROM users u WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND u.id = k.user_id AND u.role = $2 RETURNING k.id, u.name, k.prefix, k.scopes"
+
+	mock.ExpectQuery(query).
+		WithArgs("hash", storage.RoleService).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "prefix", "scopes"}).AddRow(3, "hr-sync", "abcd1234", "{coins:grant,balances:read}"))
+	mock.ExpectQuery(query).
+		WithArgs("revoked", storage.RoleService).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "prefix", "scopes"}))
+
+	key, err := s.UseAPIKey("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, &storage.APIKey{ID: 3, Account: "hr-sync", Prefix: "abcd1234", Hash: "hash", Scopes: []string{"coins:grant", "balances:read"}}, key)
+
+	_, err = s.UseAPIKey("revoked")
+	assert.ErrorIs(t, err, storage.ErrAPIKeyNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	since, pendingSince := time.Now().Add(-24*time.Hour), time.Now().Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("user").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE user_id = $1 AND created_at < $2").
+		WithArgs(1, since).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO idempotency_keys (user_id,key,fingerprint) VALUES ($1,$2,$3) ON CONFLICT (user_id, key) DO NOTHING").
+		WithArgs(1, "key", "fp").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT fingerprint, status_code, response, created_at FROM idempotency_keys WHERE user_id = $1 AND key = $2").
+		WithArgs(1, "key").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status_code", "response", "created_at"}).AddRow("fp", 200, []byte("{}"), time.Now()))
+	mock.ExpectRollback()
+
+	resp, err := s.ReserveIdempotencyKey("user", "key", "fp", since, pendingSince)
+	assert.NoError(t, err)
+	assert.Equal(t, &storage.IdempotentResponse{StatusCode: 200, Body: []byte("{}")}, resp)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	//the whole cart is bought in one transaction and the cart is emptied
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
+		WithArgs("buyer").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1000))
+	//prices and availability can't change until the order is placed
+	mock.ExpectQuery("SELECT m.id FROM merch m JOIN cart_items c ON c.merch_id = m.id WHERE c.user_id = $1 ORDER BY m.id FOR SHARE OF m").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(4))
+	mock.ExpectQuery("SELECT m.id, m.name, m.price, m.available, m.retired_at IS NOT NULL, c.quantity FROM cart_items c JOIN merch m ON m.id = c.merch_id WHERE c.user_id = $1 ORDER BY c.added_at, m.id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "available", "retired", "quantity"}).
+			AddRow(4, "pen", 10, true, false, 3).
+			AddRow(2, "cup", 20, true, false, 1))
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("purchase", 1, nil, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(9, "user", 1, -50, 9, "shop", nil, 50).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO orders (user_id,transaction_id,total) VALUES ($1,$2,$3)").
+		WithArgs(1, 9, 50).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO purchases (user_id,merch_id,transaction_id,price,quantity) VALUES ($1,$2,$3,$4,$5),($6,$7,$8,$9,$10)").
+		WithArgs(1, 4, 9, 10, 3, 1, 2, 9, 20, 1).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO user_inventory (user_id,merch_id,quantity) VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT (user_id, merch_id) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity").
+		WithArgs(1, 4, 3, 1, 2, 1).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("DELETE FROM cart_items WHERE user_id = $1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	cart, err := s.Checkout("buyer")
+	require.NoError(t, err)
+	assert.Equal(t, 50, cart.Total)
+	assert.Equal(t, []storage.CartItem{
+		{Item: "pen", Price: 10, Quantity: 3, Available: true},
+		{Item: "cup", Price: 20, Quantity: 1, Available: true},
+	}, cart.Items)
+
+	//an unavailable item fails the whole cart
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1 FOR UPDATE").
+		WithArgs("buyer").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE account = $1 AND user_id = $2").
+		WithArgs("user", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(950))
+	mock.ExpectQuery("SELECT m.id FROM merch m JOIN cart_items c ON c.merch_id = m.id WHERE c.user_id = $1 ORDER BY m.id FOR SHARE OF m").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(7))
+	mock.ExpectQuery("SELECT m.id, m.name, m.price, m.available, m.retired_at IS NOT NULL, c.quantity FROM cart_items c JOIN merch m ON m.id = c.merch_id WHERE c.user_id = $1 ORDER BY c.added_at, m.id").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "available", "retired", "quantity"}).
+			AddRow(4, "pen", 10, true, false, 1).
+			AddRow(7, "umbrella", 200, false, false, 1))
+	mock.ExpectRollback()
+
+	_, err = s.Checkout("buyer")
+	assert.ErrorIs(t, err, storage.ErrItemUnavailable)
+	assert.NotErrorIs(t, err, storage.ErrUnsufficientBalance)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetOrderStatus(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	placedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	shippedAt := placedAt.Add(time.Hour)
+
+	//the order is shipped and returned with its items
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM orders WHERE id = $1 FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("placed"))
+	mock.ExpectExec("UPDATE orders SET status = $1, shipped_at = NOW() WHERE id = $2").
+		WithArgs("shipped", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT o.id, o.transaction_id, u.name, o.status, o.total, o.placed_at, o.processing_at, o.shipped_at, o.delivered_at, o.cancelled_at FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = $1").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "name", "status", "total", "placed_at", "processing_at", "shipped_at", "delivered_at", "cancelled_at"}).
+			AddRow(3, 9, "buyer", "shipped", 50, placedAt, nil, shippedAt, nil, nil))
+	mock.ExpectQuery("SELECT p.transaction_id, m.name, p.price, p.quantity FROM purchases p JOIN merch m ON m.id = p.merch_id WHERE p.transaction_id IN ($1) ORDER BY p.id").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "name", "price", "quantity"}).
+			AddRow(9, "pen", 10, 3).
+			AddRow(9, "cup", 20, 1))
+	mock.ExpectCommit()
+
+	order, err := s.SetOrderStatus(3, storage.OrderShipped)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.Order{
+		ID:        3,
+		User:      "buyer",
+		Status:    storage.OrderShipped,
+		Total:     50,
+		Items:     []storage.OrderItem{{Item: "pen", Price: 10, Quantity: 3}, {Item: "cup", Price: 20, Quantity: 1}},
+		PlacedAt:  placedAt,
+		ShippedAt: &shippedAt,
+	}, order)
+
+	//orders do not move back
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM orders WHERE id = $1 FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("shipped"))
+	mock.ExpectRollback()
+
+	_, err = s.SetOrderStatus(3, storage.OrderProcessing)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM orders WHERE id = $1 FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	mock.ExpectRollback()
+
+	_, err = s.SetOrderStatus(4, storage.OrderDelivered)
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllOrders(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+
+	placedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	//one extra row is requested to detect the next page
+	mock.ExpectQuery("SELECT o.id, o.transaction_id, u.name, o.status, o.total, o.placed_at, o.processing_at, o.shipped_at, o.delivered_at, o.cancelled_at FROM orders o JOIN users u ON u.id = o.user_id WHERE (o.placed_at, o.id) > ($1, $2) AND o.status = $3 ORDER BY o.placed_at, o.id LIMIT 11").
+		WithArgs(placedAt, 4, "processing").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "name", "status", "total", "placed_at", "processing_at", "shipped_at", "delivered_at", "cancelled_at"}))
+
+	page, err := s.AllOrders(storage.OrderFilter{
+		Status: storage.OrderProcessing,
+		After:  &storage.OrderCursor{PlacedAt: placedAt, ID: 4},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, page.Orders)
+	assert.NotNil(t, page.Orders)
+	assert.Nil(t, page.Next)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrders(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	placedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id FROM users WHERE name=$1").
+		WithArgs("buyer").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT o.id, o.transaction_id, u.name, o.status, o.total, o.placed_at, o.processing_at, o.shipped_at, o.delivered_at, o.cancelled_at FROM orders o JOIN users u ON u.id = o.user_id WHERE o.user_id = $1 AND (o.placed_at, o.id) < ($2, $3) ORDER BY o.placed_at DESC, o.id DESC LIMIT 2").
+		WithArgs(1, placedAt, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "name", "status", "total", "placed_at", "processing_at", "shipped_at", "delivered_at", "cancelled_at"}).
+			AddRow(4, 9, "buyer", "placed", 30, placedAt, nil, nil, nil, nil).
+			AddRow(3, 8, "buyer", "placed", 10, placedAt.Add(-time.Hour), nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT p.transaction_id, m.name, p.price, p.quantity FROM purchases p JOIN merch m ON m.id = p.merch_id WHERE p.transaction_id IN ($1,$2) ORDER BY p.id").
+		WithArgs(9, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "name", "price", "quantity"}).
+			AddRow(8, "pen", 10, 1).
+			AddRow(9, "cup", 30, 1))
+
+	page, err := s.Orders("buyer", storage.OrderFilter{
+		After: &storage.OrderCursor{PlacedAt: placedAt, ID: 5},
+		Limit: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 1)
+	assert.Equal(t, 4, page.Orders[0].ID)
+	assert.Equal(t, []storage.OrderItem{{Item: "cup", Price: 30, Quantity: 1}}, page.Orders[0].Items)
+	assert.Equal(t, &storage.OrderCursor{PlacedAt: placedAt, ID: 4}, page.Next)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelOrder(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	placedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	cancelledAt := placedAt.Add(time.Hour)
+	lockOrder := "SELECT o.user_id, u.name, o.transaction_id, o.status, o.total, o.placed_at FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = $1 FOR UPDATE"
+
+	//the items are taken back and the coins returned in one transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockOrder).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "transaction_id", "status", "total", "placed_at"}).
+			AddRow(1, "buyer", 9, "delivered", 50, placedAt))
+	mock.ExpectQuery("SELECT merch_id, quantity FROM purchases WHERE transaction_id = $1 ORDER BY id").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"merch_id", "quantity"}).AddRow(4, 3).AddRow(2, 1))
+	mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - $1 WHERE user_id = $2 AND merch_id = $3 AND quantity >= $4").
+		WithArgs(3, 1, 4, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - $1 WHERE user_id = $2 AND merch_id = $3 AND quantity >= $4").
+		WithArgs(1, 1, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user_inventory WHERE user_id = $1 AND quantity = 0").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO transactions (kind,from_user_id,to_user_id,amount) VALUES ($1,$2,$3,$4) RETURNING id").
+		WithArgs("refund", nil, 1, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectExec("INSERT INTO ledger (transaction_id,account,user_id,amount) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)").
+		WithArgs(12, "shop", nil, -50, 12, "user", 1, 50).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("UPDATE orders SET status = $1, cancelled_at = NOW(), refund_transaction_id = $2 WHERE id = $3").
+		WithArgs("cancelled", 12, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT o.id, o.transaction_id, u.name, o.status, o.total, o.placed_at, o.processing_at, o.shipped_at, o.delivered_at, o.cancelled_at FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = $1").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "name", "status", "total", "placed_at", "processing_at", "shipped_at", "delivered_at", "cancelled_at"}).
+			AddRow(3, 9, "buyer", "cancelled", 50, placedAt, nil, nil, placedAt, cancelledAt))
+	mock.ExpectQuery("SELECT p.transaction_id, m.name, p.price, p.quantity FROM purchases p JOIN merch m ON m.id = p.merch_id WHERE p.transaction_id IN ($1) ORDER BY p.id").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "name", "price", "quantity"}).
+			AddRow(9, "pen", 10, 3).
+			AddRow(9, "cup", 20, 1))
+	mock.ExpectCommit()
+
+	order, err := s.CancelOrder(3, "buyer", placedAt.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, storage.OrderCancelled, order.Status)
+	assert.Equal(t, &cancelledAt, order.CancelledAt)
+
+	//orders of other users are not found, old orders are not cancelled by users
+	for user, want := range map[string]error{"other": storage.ErrOrderNotFound, "buyer": storage.ErrRefundWindowExpired} {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockOrder).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "transaction_id", "status", "total", "placed_at"}).
+				AddRow(1, "buyer", 9, "placed", 50, placedAt))
+		mock.ExpectRollback()
+
+		_, err = s.CancelOrder(3, user, placedAt.Add(time.Minute))
+		assert.ErrorIs(t, err, want, user)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeUserPassHash(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := &Storage{db: db}
+	at := time.Now()
+
+	//the password and the cutoff from the app clock change together
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE users SET pass_hash = $1, tokens_valid_after = $2 WHERE name = $3 RETURNING id").
+		WithArgs("newhash", at, "testuser").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL").
+		WithArgs(2).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err = s.ChangeUserPassHash("testuser", "newhash", at)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
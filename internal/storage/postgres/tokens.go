@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+)
+
+// AddRefreshToken stores the first token of a new family. Families of the user whose
+// tokens have all expired are deleted on the way, used and revoked tokens of live
+// families are kept to detect reuse.
+func (s *Storage) AddRefreshToken(user string, token storage.RefreshToken) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	id, err := userID(s.db, user)
+	if err != nil {
+		return err
+	}
+	_, err = psql.Delete("refresh_tokens rt").
+		Where("rt.user_id = ? AND rt.expires_at < NOW() AND NOT EXISTS (SELECT 1 FROM refresh_tokens live WHERE live.family = rt.family AND live.expires_at >= NOW())", id).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to prune refresh tokens: %w", err)
+	}
+	_, err = psql.Insert("refresh_tokens").
+		Columns("user_id", "token_hash", "family", "expires_at").
+		Values(id, token.Hash, token.Family, token.ExpiresAt).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the token as used and stores its successor in the same
+// family. It returns the name of the token owner. Presenting a used token again
+// revokes the whole family, as the token has most likely been stolen.
+func (s *Storage) RotateRefreshToken(hash, nextHash string, nextExpiresAt time.Time) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var (
+		tokenID, userID   int
+		name, family      string
+		expiresAt         time.Time
+		usedAt, revokedAt sql.NullTime
+	)
+	err = psql.Select("rt.id", "rt.user_id", "u.name", "rt.family", "rt.expires_at", "rt.used_at", "rt.revoked_at").
+		From("refresh_tokens rt").
+		Join("users u ON u.id = rt.user_id").
+		Where("rt.token_hash = ?", hash).
+		Suffix("FOR UPDATE OF rt").
+		RunWith(tx).
+		QueryRow().
+		Scan(&tokenID, &userID, &name, &family, &expiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrTokenNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		return "", storage.ErrTokenRevoked
+	}
+	if usedAt.Valid {
+		_, err = psql.Update("refresh_tokens").
+			Set("revoked_at", squirrel.Expr("NOW()")).
+			Where("family = ? AND revoked_at IS NULL", family).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return "", fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return "", storage.ErrTokenReused
+	}
+	if !time.Now().Before(expiresAt) {
+		return "", storage.ErrTokenExpired
+	}
+	_, err = psql.Update("refresh_tokens").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where("id = ?", tokenID).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return "", fmt.Errorf("failed to update refresh token: %w", err)
+	}
+	_, err = psql.Insert("refresh_tokens").
+		Columns("user_id", "token_hash", "family", "expires_at").
+		Values(userID, nextHash, family, nextExpiresAt).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return "", fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return name, nil
+}
+
+// RevokeRefreshToken revokes the family of the token. Unknown tokens are ignored.
+func (s *Storage) RevokeRefreshToken(hash string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err := psql.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where("family = (SELECT family FROM refresh_tokens WHERE token_hash = ?) AND revoked_at IS NULL", hash).
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeToken adds an access token to the revocation list until it expires.
+func (s *Storage) RevokeToken(jti string, expiresAt time.Time) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	//expired tokens are rejected anyway, so there is no need to keep them
+	_, err := psql.Delete("revoked_tokens").
+		Where("expires_at < NOW()").
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+	_, err = psql.Insert("revoked_tokens").
+		Columns("jti", "expires_at").
+		Values(jti, expiresAt).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		RunWith(s.db).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserTokens invalidates all sessions of the user: access tokens issued
+// before at and all refresh tokens. at comes from the clock that sets iat of
+// access tokens, not from the database.
+func (s *Storage) RevokeUserTokens(user string, at time.Time) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return s.revokeUserTokens(psql.Update("users").Where("name = ?", user), at)
+}
+
+// ChangeUserPassHash sets the password hash of the user and revokes its sessions
+// like RevokeUserTokens, in one transaction.
+func (s *Storage) ChangeUserPassHash(name, passHash string, at time.Time) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return s.revokeUserTokens(psql.Update("users").Set("pass_hash", passHash).Where("name = ?", name), at)
+}
+
+// revokeUserTokens runs the update of the user row, which must match a single user,
+// together with the revocation of its tokens.
+func (s *Storage) revokeUserTokens(update squirrel.UpdateBuilder, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var id int
+	err = update.
+		Set("tokens_valid_after", at).
+		Suffix("RETURNING id").
+		RunWith(tx).
+		QueryRow().
+		Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	_, err = psql.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where("user_id = ? AND revoked_at IS NULL", id).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TokenRevoked reports whether an access token of the user issued at issuedAt
+// was revoked, either by its id or together with all sessions of the user.
+func (s *Storage) TokenRevoked(user, jti string, issuedAt time.Time) (bool, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	if jti != "" {
+		var one int
+		err := psql.Select("1").
+			From("revoked_tokens").
+			Where("jti = ?", jti).
+			RunWith(s.db).
+			QueryRow().
+			Scan(&one)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("failed to check revoked tokens: %w", err)
+		}
+	}
+	var validAfter sql.NullTime
+	err := psql.Select("tokens_valid_after").
+		From("users").
+		Where("name = ?", user).
+		RunWith(s.db).
+		QueryRow().
+		Scan(&validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get user tokens_valid_after: %w", err)
+	}
+	return validAfter.Valid && issuedAt.Before(validAfter.Time), nil
+}
@@ -1,7 +1,362 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
 
 var (
 	ErrUnsufficientBalance = errors.New("unsufficient balance")
+	// ErrInvalidAmount means a transfer of zero or negative coins.
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrUserExists       = errors.New("user already exists")
+	ErrItemNotFound     = errors.New("item not found")
+	ErrItemUnavailable  = errors.New("item is not available")
+	ErrItemExists       = errors.New("item already exists")
+	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCartItemNotFound = errors.New("item is not in the cart")
+	ErrTokenNotFound    = errors.New("refresh token not found")
+	ErrTokenExpired     = errors.New("refresh token expired")
+	ErrTokenReused      = errors.New("refresh token reused")
+	ErrTokenRevoked     = errors.New("refresh token revoked")
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	// ErrLoginLocked means the login key is locked, so the failed login was not counted.
+	ErrLoginLocked = errors.New("login locked")
+	// ErrServiceAccountRole means the user is a service account or would become one;
+	// service accounts keep their role, so that their API keys stay manageable.
+	ErrServiceAccountRole = errors.New("role of a service account cannot be changed")
+	ErrOrderNotFound      = errors.New("order not found")
+	// ErrOrderStatus means the order cannot move to the requested status from its current one.
+	ErrOrderStatus = errors.New("order status cannot be changed")
+	// ErrRefundWindowExpired means the order was placed too long ago to be cancelled by the user.
+	ErrRefundWindowExpired = errors.New("refund window expired")
+	// ErrIdempotencyKeyReused means the key was used for a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused")
+	// ErrIdempotencyKeyInProgress means the request with the key has not finished yet.
+	ErrIdempotencyKeyInProgress = errors.New("idempotency key in progress")
+	// ErrIdempotencyKeyOutcomeUnknown means the request with the key never finished, it may
+	// have been executed or not, so it must not be run again with the same key.
+	ErrIdempotencyKeyOutcomeUnknown = errors.New("idempotency key outcome unknown")
+)
+
+// Kinds of coin movements recorded in the ledger.
+const (
+	KindOpening  = "opening"
+	KindSignup   = "signup"
+	KindTransfer = "transfer"
+	KindPurchase = "purchase"
+	KindGrant    = "grant"
+	KindClawback = "clawback"
+	KindRefund   = "refund"
 )
+
+// User roles, see migrations/7_roles.up.sql and 12_service_accounts.up.sql.
+// Service accounts have no password and authenticate with API keys only.
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleService = "service"
+)
+
+// Order statuses, see migrations/16_orders.up.sql.
+const (
+	OrderPlaced     = "placed"
+	OrderProcessing = "processing"
+	OrderShipped    = "shipped"
+	OrderDelivered  = "delivered"
+	OrderCancelled  = "cancelled"
+)
+
+// orderSteps are the fulfilment statuses in the order they are reached.
+var orderSteps = []string{OrderPlaced, OrderProcessing, OrderShipped, OrderDelivered}
+
+// OrderStatusAdvances reports whether an order may be advanced from status from to status to.
+// Orders only move forward, steps may be skipped. Cancelled orders do not move at all.
+func OrderStatusAdvances(from, to string) bool {
+	fromStep, toStep := slices.Index(orderSteps, from), slices.Index(orderSteps, to)
+	return fromStep >= 0 && toStep > fromStep
+}
+
+// StartBalance is granted to every new user on signup.
+const StartBalance = 1000
+
+// InfoHistoryLimit caps the number of transfers of each direction, coin adjustments,
+// refunds and purchases returned by UserInfo. The full history is available through
+// CoinHistory and Orders.
+const InfoHistoryLimit = 100
+
+// Directions of a transfer relative to the user the history belongs to.
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+type UserInfo struct {
+	CoinHistory CoinHistory
+	Coins       int
+	Inventory   []InventoryEntry
+	Purchases   []Purchase
+}
+type CoinHistory struct {
+	Received    []TransactionReceived
+	Sent        []TransactionSent
+	Adjustments []Adjustment
+	Refunds     []Refund
+}
+type TransactionReceived struct {
+	ID        int
+	Amount    int
+	FromUser  string
+	CreatedAt time.Time
+}
+type TransactionSent struct {
+	ID        int
+	Amount    int
+	ToUser    string
+	CreatedAt time.Time
+}
+
+// Adjustment is a grant or a clawback of coins made by an admin.
+// Amount is positive for both kinds.
+type Adjustment struct {
+	ID        int
+	Kind      string
+	Amount    int
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Refund is the return of the coins paid for a cancelled order.
+type Refund struct {
+	ID        int
+	OrderID   int
+	Amount    int
+	CreatedAt time.Time
+}
+type InventoryEntry struct {
+	Quantity int
+	Type     string
+}
+
+// Purchase is an item bought by a user, Price is the price paid at the time of purchase.
+type Purchase struct {
+	Item string
+	// Price is the price of one item, the purchase cost Price * Quantity.
+	Price     int
+	Quantity  int
+	CreatedAt time.Time
+}
+
+// LedgerEntry is a single posting to a user account.
+// Amount is positive when coins were added to the account.
+type LedgerEntry struct {
+	TransactionID int
+	Kind          string
+	Amount        int
+}
+
+// HistoryFilter selects a page of a user's transfer history.
+// Zero values of the fields mean "no restriction".
+type HistoryFilter struct {
+	Direction    string
+	Counterparty string
+	// From is inclusive, To is exclusive.
+	From time.Time
+	To   time.Time
+	// After is the cursor returned with the previous page.
+	After *HistoryCursor
+	Limit int
+}
+
+// HistoryCursor points at the last entry of a history page.
+type HistoryCursor struct {
+	ID        int
+	Direction string
+}
+
+// HistoryEntry is a transfer as seen by one of its sides.
+type HistoryEntry struct {
+	ID           int
+	Direction    string
+	Counterparty string
+	Amount       int
+	CreatedAt    time.Time
+}
+
+// HistoryPage is a page of transfers ordered newest first.
+// Next is nil on the last page.
+type HistoryPage struct {
+	Entries []HistoryEntry
+	Next    *HistoryCursor
+}
+
+// MerchItem is an entry of the merch catalog.
+type MerchItem struct {
+	Name        string
+	Price       int
+	Description string
+	Available   bool
+}
+
+// MerchUpdate lists the catalog item fields to change, nil fields are left as is.
+type MerchUpdate struct {
+	Name        *string
+	Price       *int
+	Description *string
+	Available   *bool
+}
+
+// RefreshToken is a refresh token as kept by the storage, Hash is the hex encoded
+// SHA-256 of the token. Tokens of one Family descend from the same login.
+type RefreshToken struct {
+	Hash      string
+	Family    string
+	ExpiresAt time.Time
+}
+
+// LoginAttempts are the failed logins counted for a key, a username or a client address.
+// Zero LockedUntil means the key is not locked.
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// LoginLockout tells when a login key is locked: after MaxFailures failures for
+// LockDuration, doubled with every further failure up to MaxLockDuration.
+type LoginLockout struct {
+	MaxFailures     int
+	LockDuration    time.Duration
+	MaxLockDuration time.Duration
+}
+
+// LockFor returns how long to lock a key after its failures-th failed login.
+func (l LoginLockout) LockFor(failures int) time.Duration {
+	if l.MaxFailures <= 0 || failures < l.MaxFailures {
+		return 0
+	}
+	d := float64(l.LockDuration) * math.Pow(2, float64(failures-l.MaxFailures))
+	if d > float64(l.MaxLockDuration) {
+		return l.MaxLockDuration
+	}
+	return time.Duration(d)
+}
+
+// IdempotentResponse is the response recorded for an idempotency key,
+// it is sent again when the request is retried with the same key.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// APIKey is a key of a service account. Hash is the hex encoded SHA-256 of the key,
+// Prefix is the start of the key, shown to tell keys apart.
+type APIKey struct {
+	ID          int
+	Account     string
+	Prefix      string
+	Hash        string
+	Description string
+	Scopes      []string
+	CreatedAt   time.Time
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+}
+
+// Cart is the content of a user's cart at current prices, oldest items first.
+// Total is the cost of all items.
+type Cart struct {
+	Items []CartItem
+	Total int
+}
+
+// CartItem is a line of a cart. Available is false for items that cannot be
+// bought at the moment, including items retired from the catalog.
+type CartItem struct {
+	Item      string
+	Price     int
+	Quantity  int
+	Available bool
+}
+
+// CheckoutError is returned when a cart cannot be bought as a whole. Lines lists
+// the items that cannot be bought, the coins are not enough if Balance is less than Total.
+type CheckoutError struct {
+	Lines   []CheckoutLineError
+	Total   int
+	Balance int
+}
+
+// CheckoutLineError is an item of a cart that cannot be bought,
+// Err is ErrItemNotFound or ErrItemUnavailable.
+type CheckoutLineError struct {
+	Item string
+	Err  error
+}
+
+func (e *CheckoutError) Error() string {
+	if len(e.Lines) > 0 {
+		return fmt.Sprintf("%d cart items cannot be bought", len(e.Lines))
+	}
+	return fmt.Sprintf("cart costs %d coins, balance is %d", e.Total, e.Balance)
+}
+
+// Unwrap makes errors.Is match the errors of the lines and ErrUnsufficientBalance.
+func (e *CheckoutError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Lines)+1)
+	for _, line := range e.Lines {
+		errs = append(errs, line.Err)
+	}
+	if e.Balance < e.Total {
+		errs = append(errs, ErrUnsufficientBalance)
+	}
+	return errs
+}
+
+// Order is the delivery of the items bought in one purchase, Total is their cost.
+// The timestamps of the statuses the order has not reached are nil.
+type Order struct {
+	ID           int
+	User         string
+	Status       string
+	Total        int
+	Items        []OrderItem
+	PlacedAt     time.Time
+	ProcessingAt *time.Time
+	ShippedAt    *time.Time
+	DeliveredAt  *time.Time
+	CancelledAt  *time.Time
+}
+
+// OrderItem is a line of an order, Price is the price of one item at the time of purchase.
+type OrderItem struct {
+	Item     string
+	Price    int
+	Quantity int
+}
+
+// OrderFilter selects a page of orders.
+// Zero values of the fields mean "no restriction".
+type OrderFilter struct {
+	Status string
+	// After is the cursor returned with the previous page.
+	After *OrderCursor
+	Limit int
+}
+
+// OrderCursor points at the last order of an order page.
+type OrderCursor struct {
+	PlacedAt time.Time
+	ID       int
+}
+
+// OrderPage is a page of orders, Next is nil on the last page.
+type OrderPage struct {
+	Orders []Order
+	Next   *OrderCursor
+}
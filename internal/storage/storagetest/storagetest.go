@@ -0,0 +1,1453 @@
+// Package storagetest provides a behavioral test suite shared by all
+// storage implementations.
+package storagetest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ST359/avito-trainee-backend-winter-2025/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const startBalance = storage.StartBalance
+
+// Storage is the contract checked by Run.
+type Storage interface {
+	SendCoins(fromUser string, toUser string, amount int) error
+	Buy(item string, user string, quantity int) error
+	AddUser(name, passHash string) error
+	AddExternalUser(name, issuer string) error
+	UserIssuer(name string) (string, error)
+	UserPassHash(name string) (string, error)
+	SetUserPassHash(name, passHash string) error
+	ChangeUserPassHash(name, passHash string, at time.Time) error
+	UserExist(name string) (bool, error)
+	UserInfo(user string) (*storage.UserInfo, error)
+	ItemExist(name string) (bool, error)
+	UserLedger(user string) ([]storage.LedgerEntry, error)
+	CoinHistory(user string, filter storage.HistoryFilter) (*storage.HistoryPage, error)
+	MerchList() ([]storage.MerchItem, error)
+	MerchItem(name string) (*storage.MerchItem, error)
+	AddMerchItem(item storage.MerchItem) error
+	UpdateMerchItem(name string, update storage.MerchUpdate) (*storage.MerchItem, error)
+	RetireMerchItem(name string) error
+	UserRole(name string) (string, error)
+	SetUserRole(name, role string) error
+	GrantCoins(admin string, users []string, amount int, reason string) error
+	ClawbackCoins(admin string, user string, amount int, reason string) error
+	AddRefreshToken(user string, token storage.RefreshToken) error
+	RotateRefreshToken(hash, nextHash string, nextExpiresAt time.Time) (string, error)
+	RevokeRefreshToken(hash string) error
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeUserTokens(user string, at time.Time) error
+	TokenRevoked(user, jti string, issuedAt time.Time) (bool, error)
+	LoginAttempts(key string) (*storage.LoginAttempts, error)
+	AddLoginFailure(key string, since time.Time, lockout storage.LoginLockout) (*storage.LoginAttempts, error)
+	ResetLoginAttempts(key string) error
+	UserBalance(name string) (int, error)
+	AddServiceAccount(name string) error
+	AddAPIKey(key storage.APIKey) (int, error)
+	APIKeys(account string) ([]storage.APIKey, error)
+	RevokeAPIKey(account string, id int) error
+	UseAPIKey(hash string) (*storage.APIKey, error)
+	ReserveIdempotencyKey(user, key, fingerprint string, since, pendingSince time.Time) (*storage.IdempotentResponse, error)
+	CompleteIdempotencyKey(user, key string, resp storage.IdempotentResponse) error
+	ReleaseIdempotencyKey(user, key string) error
+	SetCartItem(user, item string, quantity int) error
+	RemoveCartItem(user, item string) error
+	Cart(user string) (*storage.Cart, error)
+	Checkout(user string) (*storage.Cart, error)
+	Orders(user string, filter storage.OrderFilter) (*storage.OrderPage, error)
+	AllOrders(filter storage.OrderFilter) (*storage.OrderPage, error)
+	SetOrderStatus(id int, status string) (*storage.Order, error)
+	CancelOrder(id int, user string, since time.Time) (*storage.Order, error)
+}
+
+var userSeq atomic.Int64
+
+// Run checks the storage contract. newStorage is called once per subtest.
+// Storages are expected to be seeded with the merch list from migrations/1_init.up.sql.
+// User names are unique per run, so a persistent database may be reused between runs.
+func Run(t *testing.T, newStorage func(t *testing.T) Storage) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s Storage)
+	}{
+		{"AddUser", testAddUser},
+		{"ExternalUsers", testExternalUsers},
+		{"StartBalance", testStartBalance},
+		{"Roles", testRoles},
+		{"SendCoins", testSendCoins},
+		{"SendCoinsInsufficientBalance", testSendCoinsInsufficientBalance},
+		{"SendCoinsToUnknownUser", testSendCoinsToUnknownUser},
+		{"SendCoinsInvalidAmount", testSendCoinsInvalidAmount},
+		{"CoinHistory", testCoinHistory},
+		{"Buy", testBuy},
+		{"BuyQuantity", testBuyQuantity},
+		{"BuyInsufficientBalance", testBuyInsufficientBalance},
+		{"InfoPurchasesLimit", testInfoPurchasesLimit},
+		{"ItemExist", testItemExist},
+		{"MerchCatalog", testMerchCatalog},
+		{"MerchAdmin", testMerchAdmin},
+		{"BuyUnavailableItem", testBuyUnavailableItem},
+		{"Ledger", testLedger},
+		{"GrantCoins", testGrantCoins},
+		{"ClawbackCoins", testClawbackCoins},
+		{"RefreshTokens", testRefreshTokens},
+		{"RefreshTokenReuse", testRefreshTokenReuse},
+		{"RefreshTokenPruning", testRefreshTokenPruning},
+		{"TokenRevocation", testTokenRevocation},
+		{"RevokeUserTokens", testRevokeUserTokens},
+		{"ChangeUserPassHash", testChangeUserPassHash},
+		{"LoginAttempts", testLoginAttempts},
+		{"ConcurrentLoginFailures", testConcurrentLoginFailures},
+		{"ServiceAccounts", testServiceAccounts},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"IdempotentRetryAfterCrash", testIdempotentRetryAfterCrash},
+		{"Cart", testCart},
+		{"CheckoutFailure", testCheckoutFailure},
+		{"Orders", testOrders},
+		{"OrderPages", testOrderPages},
+		{"CancelOrder", testCancelOrder},
+		{"ConcurrentSendCoins", testConcurrentSendCoins},
+		{"ConcurrentBuy", testConcurrentBuy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStorage(t))
+		})
+	}
+}
+
+// newItem adds an available catalog item with a unique name and returns that name.
+func newItem(t *testing.T, s Storage, price int) string {
+	t.Helper()
+	name := fmt.Sprintf("item-%d-%d", time.Now().UnixNano(), userSeq.Add(1))
+	require.NoError(t, s.AddMerchItem(storage.MerchItem{Name: name, Price: price, Description: "test item", Available: true}))
+	return name
+}
+
+// NewUser creates a user with a unique name and returns that name.
+func NewUser(t *testing.T, s Storage) string {
+	t.Helper()
+	name := fmt.Sprintf("user-%d-%d", time.Now().UnixNano(), userSeq.Add(1))
+	require.NoError(t, s.AddUser(name, "hash-"+name))
+	return name
+}
+
+func userInfo(t *testing.T, s Storage, name string) *storage.UserInfo {
+	t.Helper()
+	info, err := s.UserInfo(name)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	return info
+}
+
+func testAddUser(t *testing.T, s Storage) {
+	name := NewUser(t, s)
+
+	exists, err := s.UserExist(name)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	passHash, err := s.UserPassHash(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "hash-"+name, passHash)
+
+	exists, err = s.UserExist(name + "-missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorIs(t, s.AddUser(name, "otherhash"), storage.ErrUserExists)
+
+	require.NoError(t, s.SetUserPassHash(name, "newhash"))
+	passHash, err = s.UserPassHash(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "newhash", passHash)
+	assert.ErrorIs(t, s.SetUserPassHash(name+"-missing", "newhash"), storage.ErrUserNotFound)
+}
+
+func testExternalUsers(t *testing.T, s Storage) {
+	local := NewUser(t, s)
+	external := fmt.Sprintf("ext-%d-%d@example.com", time.Now().UnixNano(), userSeq.Add(1))
+	require.NoError(t, s.AddExternalUser(external, "https://idp.example.com"))
+	assert.ErrorIs(t, s.AddExternalUser(local, "https://idp.example.com"), storage.ErrUserExists)
+
+	issuer, err := s.UserIssuer(external)
+	require.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com", issuer)
+	issuer, err = s.UserIssuer(local)
+	require.NoError(t, err)
+	assert.Empty(t, issuer)
+	_, err = s.UserIssuer(external + "-missing")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	//external users are regular users without a password
+	passHash, err := s.UserPassHash(external)
+	require.NoError(t, err)
+	assert.Empty(t, passHash)
+	role, err := s.UserRole(external)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleUser, role)
+	assert.Equal(t, startBalance, userInfo(t, s, external).Coins)
+}
+
+func testStartBalance(t *testing.T, s Storage) {
+	info := userInfo(t, s, NewUser(t, s))
+	assert.Equal(t, startBalance, info.Coins)
+	assert.Empty(t, info.Inventory)
+	assert.Empty(t, info.CoinHistory.Sent)
+	assert.Empty(t, info.CoinHistory.Received)
+	assert.Empty(t, info.Purchases)
+}
+
+func testRoles(t *testing.T, s Storage) {
+	name := NewUser(t, s)
+	role, err := s.UserRole(name)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleUser, role)
+
+	require.NoError(t, s.SetUserRole(name, storage.RoleAdmin))
+	role, err = s.UserRole(name)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleAdmin, role)
+
+	_, err = s.UserRole(name + "-missing")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.ErrorIs(t, s.SetUserRole(name+"-missing", storage.RoleAdmin), storage.ErrUserNotFound)
+}
+
+func testSendCoins(t *testing.T, s Storage) {
+	from, to := NewUser(t, s), NewUser(t, s)
+
+	require.NoError(t, s.SendCoins(from, to, 10))
+	require.NoError(t, s.SendCoins(from, to, 15))
+
+	//history is listed newest first
+	fromInfo := userInfo(t, s, from)
+	assert.Equal(t, startBalance-25, fromInfo.Coins)
+	require.Len(t, fromInfo.CoinHistory.Sent, 2)
+	assert.Equal(t, 15, fromInfo.CoinHistory.Sent[0].Amount)
+	assert.Equal(t, 10, fromInfo.CoinHistory.Sent[1].Amount)
+	for _, tr := range fromInfo.CoinHistory.Sent {
+		assert.Equal(t, to, tr.ToUser)
+		assert.NotZero(t, tr.ID)
+		assert.False(t, tr.CreatedAt.IsZero())
+	}
+	assert.Greater(t, fromInfo.CoinHistory.Sent[0].ID, fromInfo.CoinHistory.Sent[1].ID)
+	assert.Empty(t, fromInfo.CoinHistory.Received)
+
+	toInfo := userInfo(t, s, to)
+	assert.Equal(t, startBalance+25, toInfo.Coins)
+	require.Len(t, toInfo.CoinHistory.Received, 2)
+	for i, tr := range toInfo.CoinHistory.Received {
+		//both sides see the same transaction
+		sent := fromInfo.CoinHistory.Sent[i]
+		assert.Equal(t, sent.ID, tr.ID)
+		assert.Equal(t, sent.Amount, tr.Amount)
+		assert.True(t, sent.CreatedAt.Equal(tr.CreatedAt))
+		assert.Equal(t, from, tr.FromUser)
+	}
+	assert.Empty(t, toInfo.CoinHistory.Sent)
+}
+
+func testSendCoinsInsufficientBalance(t *testing.T, s Storage) {
+	from, to := NewUser(t, s), NewUser(t, s)
+
+	err := s.SendCoins(from, to, startBalance+1)
+	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
+
+	//failed transfer must not move any coins or leave a history record
+	fromInfo, toInfo := userInfo(t, s, from), userInfo(t, s, to)
+	assert.Equal(t, startBalance, fromInfo.Coins)
+	assert.Equal(t, startBalance, toInfo.Coins)
+	assert.Empty(t, fromInfo.CoinHistory.Sent)
+	assert.Empty(t, toInfo.CoinHistory.Received)
+
+	//the whole balance can be sent
+	require.NoError(t, s.SendCoins(from, to, startBalance))
+	assert.Equal(t, 0, userInfo(t, s, from).Coins)
+	assert.ErrorIs(t, s.SendCoins(from, to, 1), storage.ErrUnsufficientBalance)
+}
+
+func testSendCoinsToUnknownUser(t *testing.T, s Storage) {
+	from := NewUser(t, s)
+
+	assert.Error(t, s.SendCoins(from, from+"-missing", 10))
+
+	info := userInfo(t, s, from)
+	assert.Equal(t, startBalance, info.Coins)
+	assert.Empty(t, info.CoinHistory.Sent)
+}
+
+func testSendCoinsInvalidAmount(t *testing.T, s Storage) {
+	from, to := NewUser(t, s), NewUser(t, s)
+
+	for _, amount := range []int{0, -10} {
+		assert.ErrorIs(t, s.SendCoins(from, to, amount), storage.ErrInvalidAmount)
+	}
+	//a negative transfer must not take coins from the receiver
+	fromInfo, toInfo := userInfo(t, s, from), userInfo(t, s, to)
+	assert.Equal(t, startBalance, fromInfo.Coins)
+	assert.Equal(t, startBalance, toInfo.Coins)
+	assert.Empty(t, fromInfo.CoinHistory.Sent)
+	assert.Empty(t, toInfo.CoinHistory.Sent)
+}
+
+type historyItem struct {
+	Direction    string
+	Counterparty string
+	Amount       int
+}
+
+func historyItems(page *storage.HistoryPage) []historyItem {
+	items := make([]historyItem, len(page.Entries))
+	for i, e := range page.Entries {
+		items[i] = historyItem{Direction: e.Direction, Counterparty: e.Counterparty, Amount: e.Amount}
+	}
+	return items
+}
+
+func testCoinHistory(t *testing.T, s Storage) {
+	a, b, c := NewUser(t, s), NewUser(t, s), NewUser(t, s)
+	require.NoError(t, s.SendCoins(a, b, 10))
+	require.NoError(t, s.SendCoins(b, a, 20))
+	require.NoError(t, s.SendCoins(a, c, 30))
+	require.NoError(t, s.SendCoins(c, a, 40))
+	require.NoError(t, s.SendCoins(a, b, 50))
+	//transfers between other users must not show up
+	require.NoError(t, s.SendCoins(b, c, 60))
+
+	sent, received := storage.DirectionSent, storage.DirectionReceived
+	all := []historyItem{
+		{sent, b, 50},
+		{received, c, 40},
+		{sent, c, 30},
+		{received, b, 20},
+		{sent, b, 10},
+	}
+
+	page, err := s.CoinHistory(a, storage.HistoryFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, all, historyItems(page))
+	assert.Nil(t, page.Next)
+	for _, e := range page.Entries {
+		assert.NotZero(t, e.ID)
+		assert.False(t, e.CreatedAt.IsZero())
+	}
+
+	//pagination
+	var paged []historyItem
+	filter := storage.HistoryFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "too many pages")
+		page, err := s.CoinHistory(a, filter)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Entries), 2)
+		paged = append(paged, historyItems(page)...)
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+	assert.Equal(t, all, paged)
+
+	//filters
+	page, err = s.CoinHistory(a, storage.HistoryFilter{Direction: sent})
+	require.NoError(t, err)
+	assert.Equal(t, []historyItem{all[0], all[2], all[4]}, historyItems(page))
+
+	page, err = s.CoinHistory(a, storage.HistoryFilter{Direction: received, Counterparty: b})
+	require.NoError(t, err)
+	assert.Equal(t, []historyItem{all[3]}, historyItems(page))
+
+	page, err = s.CoinHistory(a, storage.HistoryFilter{Counterparty: c, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []historyItem{all[1]}, historyItems(page))
+	require.NotNil(t, page.Next)
+
+	now := time.Now()
+	page, err = s.CoinHistory(a, storage.HistoryFilter{From: now.Add(-time.Hour), To: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, all, historyItems(page))
+
+	page, err = s.CoinHistory(a, storage.HistoryFilter{From: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, page.Entries)
+
+	page, err = s.CoinHistory(a, storage.HistoryFilter{To: now.Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, page.Entries)
+}
+
+func testBuy(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+
+	require.NoError(t, s.Buy("pen", buyer, 1))
+	require.NoError(t, s.Buy("pen", buyer, 1))
+	require.NoError(t, s.Buy("cup", buyer, 1))
+
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, startBalance-10-10-20, info.Coins)
+	assert.ElementsMatch(t, []storage.InventoryEntry{
+		{Type: "pen", Quantity: 2},
+		{Type: "cup", Quantity: 1},
+	}, info.Inventory)
+
+	//purchases are listed newest first
+	require.Len(t, info.Purchases, 3)
+	assert.Equal(t, []string{"cup", "pen", "pen"}, []string{info.Purchases[0].Item, info.Purchases[1].Item, info.Purchases[2].Item})
+	assert.Equal(t, []int{20, 10, 10}, []int{info.Purchases[0].Price, info.Purchases[1].Price, info.Purchases[2].Price})
+	for _, p := range info.Purchases {
+		assert.False(t, p.CreatedAt.IsZero())
+	}
+}
+
+func testBuyQuantity(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+
+	require.NoError(t, s.Buy("pen", buyer, 5))
+	require.NoError(t, s.Buy("pen", buyer, 1))
+	assert.ErrorIs(t, s.Buy("pen", buyer, 0), storage.ErrInvalidQuantity)
+	assert.ErrorIs(t, s.Buy("pen", buyer, -1), storage.ErrInvalidQuantity)
+	//the whole purchase fails if the balance is not enough for all items
+	assert.ErrorIs(t, s.Buy("pink-hoody", buyer, 2), storage.ErrUnsufficientBalance)
+
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, startBalance-6*10, info.Coins)
+	assert.Equal(t, []storage.InventoryEntry{{Type: "pen", Quantity: 6}}, info.Inventory)
+	require.Len(t, info.Purchases, 2)
+	assert.Equal(t, 1, info.Purchases[0].Quantity)
+	assert.Equal(t, 5, info.Purchases[1].Quantity)
+	assert.Equal(t, 10, info.Purchases[1].Price)
+
+	//a single ledger transaction for the whole purchase
+	entries, err := s.UserLedger(buyer)
+	require.NoError(t, err)
+	var purchases []int
+	for _, e := range entries {
+		if e.Kind == storage.KindPurchase {
+			purchases = append(purchases, e.Amount)
+		}
+	}
+	assert.ElementsMatch(t, []int{-50, -10}, purchases)
+}
+
+func testBuyInsufficientBalance(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+
+	require.NoError(t, s.Buy("pink-hoody", buyer, 1))
+	require.NoError(t, s.Buy("pink-hoody", buyer, 1))
+	assert.ErrorIs(t, s.Buy("pink-hoody", buyer, 1), storage.ErrUnsufficientBalance)
+
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, 0, info.Coins)
+	assert.Equal(t, []storage.InventoryEntry{{Type: "pink-hoody", Quantity: 2}}, info.Inventory)
+	assert.Len(t, info.Purchases, 2)
+}
+
+func testInfoPurchasesLimit(t *testing.T, s Storage) {
+	admin, buyer := NewUser(t, s), NewUser(t, s)
+	require.NoError(t, s.GrantCoins(admin, []string{buyer}, 100, "purchases limit"))
+
+	for i := 0; i < storage.InfoHistoryLimit; i++ {
+		require.NoError(t, s.Buy("pen", buyer, 1))
+	}
+	require.NoError(t, s.Buy("cup", buyer, 1))
+
+	//only the newest purchases are returned
+	info := userInfo(t, s, buyer)
+	require.Len(t, info.Purchases, storage.InfoHistoryLimit)
+	assert.Equal(t, "cup", info.Purchases[0].Item)
+	assert.Equal(t, "pen", info.Purchases[storage.InfoHistoryLimit-1].Item)
+}
+
+func testItemExist(t *testing.T, s Storage) {
+	exists, err := s.ItemExist("t-shirt")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = s.ItemExist("no-such-item")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testMerchCatalog(t *testing.T, s Storage) {
+	items, err := s.MerchList()
+	require.NoError(t, err)
+	prices := make(map[string]int, len(items))
+	for _, item := range items {
+		prices[item.Name] = item.Price
+		assert.NotEmpty(t, item.Description, item.Name)
+	}
+	assert.Equal(t, 10, prices["pen"])
+	assert.Equal(t, 500, prices["pink-hoody"])
+
+	item, err := s.MerchItem("pen")
+	require.NoError(t, err)
+	assert.Equal(t, "pen", item.Name)
+	assert.Equal(t, 10, item.Price)
+	assert.True(t, item.Available)
+
+	_, err = s.MerchItem("no-such-item")
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+}
+
+func testMerchAdmin(t *testing.T, s Storage) {
+	item := newItem(t, s, 30)
+	assert.ErrorIs(t, s.AddMerchItem(storage.MerchItem{Name: item, Price: 1}), storage.ErrItemExists)
+
+	buyer := NewUser(t, s)
+	require.NoError(t, s.Buy(item, buyer, 1))
+
+	//reprice and rename
+	renamed := item + "-renamed"
+	price := 40
+	updated, err := s.UpdateMerchItem(item, storage.MerchUpdate{Name: &renamed, Price: &price})
+	require.NoError(t, err)
+	assert.Equal(t, storage.MerchItem{Name: renamed, Price: 40, Description: "test item", Available: true}, *updated)
+	_, err = s.MerchItem(item)
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+	_, err = s.UpdateMerchItem(renamed, storage.MerchUpdate{Name: ptr("pen")})
+	assert.ErrorIs(t, err, storage.ErrItemExists)
+
+	//the purchase made before the update keeps its price and follows the rename
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, []storage.InventoryEntry{{Type: renamed, Quantity: 1}}, info.Inventory)
+	require.Len(t, info.Purchases, 1)
+	assert.Equal(t, renamed, info.Purchases[0].Item)
+	assert.Equal(t, 30, info.Purchases[0].Price)
+
+	//retire
+	require.NoError(t, s.RetireMerchItem(renamed))
+	assert.ErrorIs(t, s.RetireMerchItem(renamed), storage.ErrItemNotFound)
+	exists, err := s.ItemExist(renamed)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, err = s.MerchItem(renamed)
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+	items, err := s.MerchList()
+	require.NoError(t, err)
+	for _, i := range items {
+		assert.NotEqual(t, renamed, i.Name)
+	}
+	_, err = s.UpdateMerchItem(renamed, storage.MerchUpdate{Price: &price})
+	assert.ErrorIs(t, err, storage.ErrItemNotFound)
+	assert.ErrorIs(t, s.Buy(renamed, buyer, 1), storage.ErrItemNotFound)
+
+	//retired items stay in the inventory
+	info = userInfo(t, s, buyer)
+	assert.Equal(t, []storage.InventoryEntry{{Type: renamed, Quantity: 1}}, info.Inventory)
+	assert.Equal(t, startBalance-30, info.Coins)
+}
+
+func testBuyUnavailableItem(t *testing.T, s Storage) {
+	item := newItem(t, s, 10)
+	available := false
+	_, err := s.UpdateMerchItem(item, storage.MerchUpdate{Available: &available})
+	require.NoError(t, err)
+
+	buyer := NewUser(t, s)
+	assert.ErrorIs(t, s.Buy(item, buyer, 1), storage.ErrItemUnavailable)
+	assert.Equal(t, startBalance, userInfo(t, s, buyer).Coins)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testGrantCoins(t *testing.T, s Storage) {
+	admin := NewUser(t, s)
+	alice := NewUser(t, s)
+	bob := NewUser(t, s)
+
+	require.NoError(t, s.GrantCoins(admin, []string{alice, bob}, 50, "quarterly bonus"))
+	for _, name := range []string{alice, bob} {
+		info := userInfo(t, s, name)
+		assert.Equal(t, startBalance+50, info.Coins)
+		require.Len(t, info.CoinHistory.Adjustments, 1)
+		a := info.CoinHistory.Adjustments[0]
+		assert.Equal(t, storage.KindGrant, a.Kind)
+		assert.Equal(t, 50, a.Amount)
+		assert.Equal(t, "quarterly bonus", a.Reason)
+		assert.False(t, a.CreatedAt.IsZero())
+		//grants are not transfers
+		assert.Empty(t, info.CoinHistory.Received)
+	}
+	assert.Equal(t, startBalance, userInfo(t, s, admin).Coins)
+
+	//bulk grants are all or nothing
+	err := s.GrantCoins(admin, []string{alice, alice + "-missing"}, 50, "quarterly bonus")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.Equal(t, startBalance+50, userInfo(t, s, alice).Coins)
+}
+
+func testClawbackCoins(t *testing.T, s Storage) {
+	admin := NewUser(t, s)
+	user := NewUser(t, s)
+
+	require.NoError(t, s.ClawbackCoins(admin, user, 300, "duplicate account"))
+	assert.ErrorIs(t, s.ClawbackCoins(admin, user, startBalance, "duplicate account"), storage.ErrUnsufficientBalance)
+	assert.ErrorIs(t, s.ClawbackCoins(admin, user+"-missing", 1, "duplicate account"), storage.ErrUserNotFound)
+
+	info := userInfo(t, s, user)
+	assert.Equal(t, startBalance-300, info.Coins)
+	require.Len(t, info.CoinHistory.Adjustments, 1)
+	assert.Equal(t, storage.KindClawback, info.CoinHistory.Adjustments[0].Kind)
+	assert.Equal(t, 300, info.CoinHistory.Adjustments[0].Amount)
+	assert.Equal(t, "duplicate account", info.CoinHistory.Adjustments[0].Reason)
+	assert.Empty(t, info.CoinHistory.Sent)
+
+	ledger, err := s.UserLedger(user)
+	require.NoError(t, err)
+	require.Len(t, ledger, 2)
+	assert.Equal(t, storage.KindClawback, ledger[1].Kind)
+	assert.Equal(t, -300, ledger[1].Amount)
+}
+
+// tokenHash returns a unique hash shaped like the ones stored by the service.
+func tokenHash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("token-%d-%d", time.Now().UnixNano(), userSeq.Add(1))))
+	return hex.EncodeToString(sum[:])
+}
+
+func testRefreshTokens(t *testing.T, s Storage) {
+	user := NewUser(t, s)
+	first := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, first))
+	assert.ErrorIs(t, s.AddRefreshToken(user+"-missing", storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}), storage.ErrUserNotFound)
+
+	second := tokenHash()
+	owner, err := s.RotateRefreshToken(first.Hash, second, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, user, owner)
+
+	owner, err = s.RotateRefreshToken(second, tokenHash(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, user, owner)
+
+	_, err = s.RotateRefreshToken(tokenHash(), tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	expired := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, s.AddRefreshToken(user, expired))
+	_, err = s.RotateRefreshToken(expired.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenExpired)
+}
+
+func testRefreshTokenReuse(t *testing.T, s Storage) {
+	user := NewUser(t, s)
+	first := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, first))
+	other := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, other))
+
+	second := tokenHash()
+	_, err := s.RotateRefreshToken(first.Hash, second, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	//presenting a rotated token again revokes its successors
+	_, err = s.RotateRefreshToken(first.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenReused)
+	_, err = s.RotateRefreshToken(second, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenRevoked)
+
+	//other families are untouched
+	_, err = s.RotateRefreshToken(other.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+}
+
+func testRefreshTokenPruning(t *testing.T, s Storage) {
+	user, other := NewUser(t, s), NewUser(t, s)
+	expired := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, s.AddRefreshToken(user, expired))
+	othersExpired := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, s.AddRefreshToken(other, othersExpired))
+	//the family stays alive while its latest token is valid, even if its successor has expired
+	first := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, first))
+	_, err := s.RotateRefreshToken(first.Hash, tokenHash(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	require.NoError(t, s.AddRefreshToken(user, storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}))
+	_, err = s.RotateRefreshToken(expired.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+	_, err = s.RotateRefreshToken(first.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenReused)
+	//families of other users are left for their own logins
+	_, err = s.RotateRefreshToken(othersExpired.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenExpired)
+}
+
+func testTokenRevocation(t *testing.T, s Storage) {
+	user := NewUser(t, s)
+	jti, other := tokenHash(), tokenHash()
+
+	revoked, err := s.TokenRevoked(user, jti, time.Now())
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, s.RevokeToken(jti, time.Now().Add(time.Hour)))
+	//revoking twice is not an error
+	require.NoError(t, s.RevokeToken(jti, time.Now().Add(time.Hour)))
+	revoked, err = s.TokenRevoked(user, jti, time.Now())
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = s.TokenRevoked(user, other, time.Now())
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	//logout revokes the refresh token family
+	token := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, token))
+	next := tokenHash()
+	_, err = s.RotateRefreshToken(token.Hash, next, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.RevokeRefreshToken(token.Hash))
+	_, err = s.RotateRefreshToken(next, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenRevoked)
+	assert.NoError(t, s.RevokeRefreshToken(tokenHash()))
+}
+
+func testRevokeUserTokens(t *testing.T, s Storage) {
+	user, other := NewUser(t, s), NewUser(t, s)
+	token := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, token))
+
+	require.NoError(t, s.RevokeUserTokens(user, time.Now()))
+	assert.ErrorIs(t, s.RevokeUserTokens(user+"-missing", time.Now()), storage.ErrUserNotFound)
+
+	revoked, err := s.TokenRevoked(user, tokenHash(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	//tokens issued after the revocation are valid
+	revoked, err = s.TokenRevoked(user, tokenHash(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = s.TokenRevoked(other, tokenHash(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = s.RotateRefreshToken(token.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenRevoked)
+}
+
+func testChangeUserPassHash(t *testing.T, s Storage) {
+	user := NewUser(t, s)
+	token := storage.RefreshToken{Hash: tokenHash(), Family: tokenHash(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddRefreshToken(user, token))
+
+	//the cutoff is stored as given, whatever the clock of the database says
+	at := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	require.NoError(t, s.ChangeUserPassHash(user, "newhash", at))
+	assert.ErrorIs(t, s.ChangeUserPassHash(user+"-missing", "newhash", at), storage.ErrUserNotFound)
+	passHash, err := s.UserPassHash(user)
+	require.NoError(t, err)
+	assert.Equal(t, "newhash", passHash)
+
+	revoked, err := s.TokenRevoked(user, tokenHash(), at.Add(-time.Microsecond))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = s.TokenRevoked(user, tokenHash(), at)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	_, err = s.RotateRefreshToken(token.Hash, tokenHash(), time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrTokenRevoked)
+}
+
+func testIdempotencyKeys(t *testing.T, s Storage) {
+	user, other := NewUser(t, s), NewUser(t, s)
+	since, pendingSince := time.Now().Add(-time.Hour), time.Now().Add(-time.Minute)
+	fingerprint := tokenHash()
+
+	resp, err := s.ReserveIdempotencyKey(user, "key-1", fingerprint, since, pendingSince)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	_, err = s.ReserveIdempotencyKey(user, "key-1", fingerprint, since, pendingSince)
+	assert.ErrorIs(t, err, storage.ErrIdempotencyKeyInProgress)
+	_, err = s.ReserveIdempotencyKey(user, "key-1", tokenHash(), since, pendingSince)
+	assert.ErrorIs(t, err, storage.ErrIdempotencyKeyReused)
+	//keys are per user
+	resp, err = s.ReserveIdempotencyKey(other, "key-1", tokenHash(), since, pendingSince)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	_, err = s.ReserveIdempotencyKey(user+"-missing", "key-1", fingerprint, since, pendingSince)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	recorded := storage.IdempotentResponse{StatusCode: 400, Body: []byte(`{"errors":"Insufficient balance"}`)}
+	require.NoError(t, s.CompleteIdempotencyKey(user, "key-1", recorded))
+	resp, err = s.ReserveIdempotencyKey(user, "key-1", fingerprint, since, pendingSince)
+	require.NoError(t, err)
+	assert.Equal(t, &recorded, resp)
+	//completed keys are not released
+	require.NoError(t, s.ReleaseIdempotencyKey(user, "key-1"))
+	resp, err = s.ReserveIdempotencyKey(user, "key-1", fingerprint, since, pendingSince)
+	require.NoError(t, err)
+	assert.Equal(t, &recorded, resp)
+	//expired keys may be used again
+	resp, err = s.ReserveIdempotencyKey(user, "key-1", tokenHash(), time.Now().Add(time.Minute), pendingSince)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+
+	_, err = s.ReserveIdempotencyKey(user, "key-2", fingerprint, since, pendingSince)
+	require.NoError(t, err)
+	require.NoError(t, s.ReleaseIdempotencyKey(user, "key-2"))
+	retried := tokenHash()
+	resp, err = s.ReserveIdempotencyKey(user, "key-2", retried, since, pendingSince)
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	//keys of requests that never finished are not freed, their outcome is unknown
+	_, err = s.ReserveIdempotencyKey(user, "key-2", retried, since, time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, storage.ErrIdempotencyKeyOutcomeUnknown)
+	//completed keys are replayed however old they are
+	require.NoError(t, s.CompleteIdempotencyKey(user, "key-2", recorded))
+	resp, err = s.ReserveIdempotencyKey(user, "key-2", retried, since, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, &recorded, resp)
+}
+
+func testIdempotentRetryAfterCrash(t *testing.T, s Storage) {
+	from, to := NewUser(t, s), NewUser(t, s)
+	fingerprint := tokenHash()
+	since := time.Now().Add(-time.Hour)
+	//runs the transfer the way the idempotency middleware does
+	send := func(pendingSince time.Time) error {
+		resp, err := s.ReserveIdempotencyKey(from, "transfer", fingerprint, since, pendingSince)
+		if err != nil || resp != nil {
+			return err
+		}
+		return s.SendCoins(from, to, 100)
+	}
+
+	//the transfer is committed, but the process stops before the response is recorded
+	require.NoError(t, send(time.Now().Add(-time.Minute)))
+	assert.ErrorIs(t, send(time.Now().Add(-time.Minute)), storage.ErrIdempotencyKeyInProgress)
+	//the reservation is stale, still the retry must not transfer again
+	assert.ErrorIs(t, send(time.Now().Add(time.Minute)), storage.ErrIdempotencyKeyOutcomeUnknown)
+	assert.Equal(t, startBalance-100, userInfo(t, s, from).Coins)
+	assert.Equal(t, startBalance+100, userInfo(t, s, to).Coins)
+}
+
+func testLoginAttempts(t *testing.T, s Storage) {
+	key := "user:" + NewUser(t, s)
+	lockout := storage.LoginLockout{MaxFailures: 3, LockDuration: time.Minute, MaxLockDuration: time.Hour}
+	attempts, err := s.LoginAttempts(key)
+	require.NoError(t, err)
+	assert.Zero(t, attempts.Failures)
+	assert.True(t, attempts.LockedUntil.IsZero())
+
+	for i := 1; i <= 2; i++ {
+		attempts, err := s.AddLoginFailure(key, time.Now().Add(-time.Hour), lockout)
+		require.NoError(t, err)
+		assert.Equal(t, i, attempts.Failures)
+		assert.True(t, attempts.LockedUntil.IsZero())
+	}
+	//the failure that runs out of attempts locks the key
+	locked, err := s.AddLoginFailure(key, time.Now().Add(-time.Hour), lockout)
+	require.NoError(t, err)
+	assert.Equal(t, 3, locked.Failures)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), locked.LockedUntil, 5*time.Second)
+	attempts, err = s.LoginAttempts(key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts.Failures)
+	assert.False(t, attempts.LastFailureAt.IsZero())
+	assert.True(t, locked.LockedUntil.Equal(attempts.LockedUntil))
+
+	//failures of a locked key are not counted
+	attempts, err = s.AddLoginFailure(key, time.Now().Add(-time.Hour), lockout)
+	assert.ErrorIs(t, err, storage.ErrLoginLocked)
+	require.NotNil(t, attempts)
+	assert.Equal(t, 3, attempts.Failures)
+	assert.True(t, locked.LockedUntil.Equal(attempts.LockedUntil))
+
+	require.NoError(t, s.ResetLoginAttempts(key))
+	attempts, err = s.LoginAttempts(key)
+	require.NoError(t, err)
+	assert.Zero(t, attempts.Failures)
+	assert.True(t, attempts.LockedUntil.IsZero())
+
+	//failures before since are forgotten
+	_, err = s.AddLoginFailure(key, time.Now().Add(-time.Hour), lockout)
+	require.NoError(t, err)
+	attempts, err = s.AddLoginFailure(key, time.Now().Add(time.Minute), lockout)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures)
+}
+
+func testConcurrentLoginFailures(t *testing.T, s Storage) {
+	const (
+		maxFailures = 3
+		guesses     = 50
+	)
+	key := "user:" + NewUser(t, s)
+	lockout := storage.LoginLockout{MaxFailures: maxFailures, LockDuration: time.Minute, MaxLockDuration: time.Hour}
+
+	var (
+		wg      sync.WaitGroup
+		counted atomic.Int64
+	)
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := s.AddLoginFailure(key, time.Now().Add(-time.Hour), lockout)
+			if err == nil {
+				counted.Add(1)
+				return
+			}
+			if !errors.Is(err, storage.ErrLoginLocked) {
+				t.Errorf("unexpected error on guess %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	//only the failures before the lock are counted, the rest find the key locked
+	assert.Equal(t, int64(maxFailures), counted.Load())
+	attempts, err := s.LoginAttempts(key)
+	require.NoError(t, err)
+	assert.Equal(t, maxFailures, attempts.Failures)
+	assert.True(t, attempts.LockedUntil.After(time.Now()))
+}
+
+func testServiceAccounts(t *testing.T, s Storage) {
+	user := NewUser(t, s)
+	svc := fmt.Sprintf("svc-%d-%d", time.Now().UnixNano(), userSeq.Add(1))
+	require.NoError(t, s.AddServiceAccount(svc))
+	assert.ErrorIs(t, s.AddServiceAccount(svc), storage.ErrUserExists)
+	assert.ErrorIs(t, s.AddServiceAccount(user), storage.ErrUserExists)
+
+	role, err := s.UserRole(svc)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleService, role)
+	//service accounts get no start balance
+	coins, err := s.UserBalance(svc)
+	require.NoError(t, err)
+	assert.Zero(t, coins)
+	coins, err = s.UserBalance(user)
+	require.NoError(t, err)
+	assert.Equal(t, startBalance, coins)
+	_, err = s.UserBalance(user + "-missing")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	hash := tokenHash()
+	_, err = s.AddAPIKey(storage.APIKey{Account: user, Prefix: "abcd1234", Hash: hash, Scopes: []string{"coins:grant"}})
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	id, err := s.AddAPIKey(storage.APIKey{Account: svc, Prefix: "abcd1234", Hash: hash, Description: "hr", Scopes: []string{"coins:grant", "balances:read"}})
+	require.NoError(t, err)
+
+	key, err := s.UseAPIKey(hash)
+	require.NoError(t, err)
+	assert.Equal(t, id, key.ID)
+	assert.Equal(t, svc, key.Account)
+	assert.Equal(t, []string{"coins:grant", "balances:read"}, key.Scopes)
+	_, err = s.UseAPIKey(tokenHash())
+	assert.ErrorIs(t, err, storage.ErrAPIKeyNotFound)
+
+	//keys act on behalf of the service account
+	require.NoError(t, s.GrantCoins(svc, []string{user}, 10, "hr bonus"))
+	coins, err = s.UserBalance(user)
+	require.NoError(t, err)
+	assert.Equal(t, startBalance+10, coins)
+
+	//a service account can't become a user, nor a user a service account,
+	//so its keys are always listed and revoked by the same rules they are used by
+	assert.ErrorIs(t, s.SetUserRole(svc, storage.RoleAdmin), storage.ErrServiceAccountRole)
+	assert.ErrorIs(t, s.SetUserRole(svc, storage.RoleUser), storage.ErrServiceAccountRole)
+	assert.ErrorIs(t, s.SetUserRole(user, storage.RoleService), storage.ErrServiceAccountRole)
+	role, err = s.UserRole(svc)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleService, role)
+	role, err = s.UserRole(user)
+	require.NoError(t, err)
+	assert.Equal(t, storage.RoleUser, role)
+	_, err = s.UseAPIKey(hash)
+	require.NoError(t, err)
+
+	keys, err := s.APIKeys(svc)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "abcd1234", keys[0].Prefix)
+	assert.Equal(t, "hr", keys[0].Description)
+	assert.Empty(t, keys[0].Hash)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.Nil(t, keys[0].RevokedAt)
+	_, err = s.APIKeys(user)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	assert.ErrorIs(t, s.RevokeAPIKey(user, id), storage.ErrAPIKeyNotFound)
+	require.NoError(t, s.RevokeAPIKey(svc, id))
+	assert.ErrorIs(t, s.RevokeAPIKey(svc, id), storage.ErrAPIKeyNotFound)
+	_, err = s.UseAPIKey(hash)
+	assert.ErrorIs(t, err, storage.ErrAPIKeyNotFound)
+	keys, err = s.APIKeys(svc)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].RevokedAt)
+}
+
+func testLedger(t *testing.T, s Storage) {
+	from, to := NewUser(t, s), NewUser(t, s)
+	require.NoError(t, s.SendCoins(from, to, 30))
+	require.NoError(t, s.Buy("cup", from, 1))
+	require.NoError(t, s.Buy("pink-hoody", to, 1))
+	assert.ErrorIs(t, s.SendCoins(from, to, startBalance), storage.ErrUnsufficientBalance)
+
+	fromLedger, err := s.UserLedger(from)
+	require.NoError(t, err)
+	assert.Equal(t, []string{storage.KindSignup, storage.KindTransfer, storage.KindPurchase}, ledgerKinds(fromLedger))
+	assert.Equal(t, []int{startBalance, -30, -20}, ledgerAmounts(fromLedger))
+	assert.Equal(t, userInfo(t, s, from).Coins, ledgerSum(fromLedger))
+
+	toLedger, err := s.UserLedger(to)
+	require.NoError(t, err)
+	assert.Equal(t, []string{storage.KindSignup, storage.KindTransfer, storage.KindPurchase}, ledgerKinds(toLedger))
+	assert.Equal(t, []int{startBalance, 30, -500}, ledgerAmounts(toLedger))
+	assert.Equal(t, userInfo(t, s, to).Coins, ledgerSum(toLedger))
+
+	//both sides of the transfer are postings of the same transaction
+	assert.Equal(t, fromLedger[1].TransactionID, toLedger[1].TransactionID)
+}
+
+func ledgerKinds(entries []storage.LedgerEntry) []string {
+	kinds := make([]string, len(entries))
+	for i, e := range entries {
+		kinds[i] = e.Kind
+	}
+	return kinds
+}
+
+func ledgerAmounts(entries []storage.LedgerEntry) []int {
+	amounts := make([]int, len(entries))
+	for i, e := range entries {
+		amounts[i] = e.Amount
+	}
+	return amounts
+}
+
+func ledgerSum(entries []storage.LedgerEntry) int {
+	sum := 0
+	for _, e := range entries {
+		sum += e.Amount
+	}
+	return sum
+}
+
+func testCart(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+
+	cart, err := s.Cart(buyer)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items)
+	_, err = s.Checkout(buyer)
+	assert.ErrorIs(t, err, storage.ErrCartEmpty)
+
+	require.NoError(t, s.SetCartItem(buyer, "pen", 2))
+	require.NoError(t, s.SetCartItem(buyer, "cup", 1))
+	require.NoError(t, s.SetCartItem(buyer, "pen", 3))
+	require.NoError(t, s.SetCartItem(buyer, "book", 1))
+	assert.ErrorIs(t, s.SetCartItem(buyer, "pen", 0), storage.ErrInvalidQuantity)
+	assert.ErrorIs(t, s.SetCartItem(buyer, "no-such-item", 1), storage.ErrItemNotFound)
+	_, err = s.Cart("no-such-user")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	require.NoError(t, s.RemoveCartItem(buyer, "book"))
+	assert.ErrorIs(t, s.RemoveCartItem(buyer, "book"), storage.ErrCartItemNotFound)
+
+	//setting an item again changes its quantity and keeps its place
+	cart, err = s.Cart(buyer)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.Cart{
+		Items: []storage.CartItem{
+			{Item: "pen", Price: 10, Quantity: 3, Available: true},
+			{Item: "cup", Price: 20, Quantity: 1, Available: true},
+		},
+		Total: 50,
+	}, cart)
+	//nothing is bought until the checkout
+	assert.Equal(t, startBalance, userInfo(t, s, buyer).Coins)
+
+	bought, err := s.Checkout(buyer)
+	require.NoError(t, err)
+	assert.Equal(t, cart, bought)
+
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, startBalance-50, info.Coins)
+	assert.ElementsMatch(t, []storage.InventoryEntry{
+		{Type: "pen", Quantity: 3},
+		{Type: "cup", Quantity: 1},
+	}, info.Inventory)
+	require.Len(t, info.Purchases, 2)
+	assert.ElementsMatch(t, []storage.Purchase{
+		{Item: "pen", Price: 10, Quantity: 3, CreatedAt: info.Purchases[0].CreatedAt},
+		{Item: "cup", Price: 20, Quantity: 1, CreatedAt: info.Purchases[0].CreatedAt},
+	}, info.Purchases)
+
+	//the whole cart is a single ledger transaction
+	entries, err := s.UserLedger(buyer)
+	require.NoError(t, err)
+	var purchases []int
+	for _, e := range entries {
+		if e.Kind == storage.KindPurchase {
+			purchases = append(purchases, e.Amount)
+		}
+	}
+	assert.Equal(t, []int{-50}, purchases)
+
+	//the cart is empty after the checkout
+	cart, err = s.Cart(buyer)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items)
+	_, err = s.Checkout(buyer)
+	assert.ErrorIs(t, err, storage.ErrCartEmpty)
+}
+
+func testCheckoutFailure(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+	unavailable := newItem(t, s, 10)
+	retired := newItem(t, s, 10)
+	require.NoError(t, s.SetCartItem(buyer, "pink-hoody", 3))
+	require.NoError(t, s.SetCartItem(buyer, unavailable, 1))
+	require.NoError(t, s.SetCartItem(buyer, retired, 1))
+
+	//items changed in the catalog stay in the cart and fail the checkout
+	available := false
+	_, err := s.UpdateMerchItem(unavailable, storage.MerchUpdate{Available: &available})
+	require.NoError(t, err)
+	require.NoError(t, s.RetireMerchItem(retired))
+	assert.ErrorIs(t, s.SetCartItem(buyer, unavailable, 2), storage.ErrItemUnavailable)
+	cart, err := s.Cart(buyer)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 3)
+	assert.True(t, cart.Items[0].Available)
+	assert.False(t, cart.Items[1].Available)
+	assert.False(t, cart.Items[2].Available)
+
+	_, err = s.Checkout(buyer)
+	var checkoutErr *storage.CheckoutError
+	require.ErrorAs(t, err, &checkoutErr)
+	assert.Equal(t, []storage.CheckoutLineError{
+		{Item: unavailable, Err: storage.ErrItemUnavailable},
+		{Item: retired, Err: storage.ErrItemNotFound},
+	}, checkoutErr.Lines)
+	assert.ErrorIs(t, err, storage.ErrItemUnavailable)
+	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
+	assert.Equal(t, 1520, checkoutErr.Total)
+	assert.Equal(t, startBalance, checkoutErr.Balance)
+
+	//the balance is checked for the whole cart
+	require.NoError(t, s.RemoveCartItem(buyer, unavailable))
+	require.NoError(t, s.RemoveCartItem(buyer, retired))
+	_, err = s.Checkout(buyer)
+	require.ErrorAs(t, err, &checkoutErr)
+	assert.Empty(t, checkoutErr.Lines)
+	assert.ErrorIs(t, err, storage.ErrUnsufficientBalance)
+
+	//nothing is bought
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, startBalance, info.Coins)
+	assert.Empty(t, info.Inventory)
+	assert.Empty(t, info.Purchases)
+
+	require.NoError(t, s.SetCartItem(buyer, "pink-hoody", 1))
+	_, err = s.Checkout(buyer)
+	require.NoError(t, err)
+	assert.Equal(t, startBalance-500, userInfo(t, s, buyer).Coins)
+}
+
+func testOrders(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+	page, err := s.Orders(buyer, storage.OrderFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Orders)
+	assert.Nil(t, page.Next)
+	_, err = s.Orders("no-such-user", storage.OrderFilter{})
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	//every purchase places an order, newest first
+	require.NoError(t, s.Buy("pen", buyer, 2))
+	require.NoError(t, s.SetCartItem(buyer, "cup", 1))
+	require.NoError(t, s.SetCartItem(buyer, "socks", 3))
+	_, err = s.Checkout(buyer)
+	require.NoError(t, err)
+	page, err = s.Orders(buyer, storage.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 2)
+	cartOrder, penOrder := page.Orders[0], page.Orders[1]
+	assert.Equal(t, buyer, cartOrder.User)
+	assert.Equal(t, storage.OrderPlaced, cartOrder.Status)
+	assert.Equal(t, 50, cartOrder.Total)
+	assert.Equal(t, []storage.OrderItem{
+		{Item: "cup", Price: 20, Quantity: 1},
+		{Item: "socks", Price: 10, Quantity: 3},
+	}, cartOrder.Items)
+	assert.Equal(t, []storage.OrderItem{{Item: "pen", Price: 10, Quantity: 2}}, penOrder.Items)
+	assert.Equal(t, 20, penOrder.Total)
+	assert.False(t, penOrder.PlacedAt.IsZero())
+	assert.Nil(t, penOrder.ProcessingAt)
+	assert.Nil(t, penOrder.ShippedAt)
+	assert.Nil(t, penOrder.DeliveredAt)
+	assert.Nil(t, penOrder.CancelledAt)
+
+	//orders move forward only, steps may be skipped
+	order, err := s.SetOrderStatus(penOrder.ID, storage.OrderProcessing)
+	require.NoError(t, err)
+	assert.Equal(t, storage.OrderProcessing, order.Status)
+	assert.NotNil(t, order.ProcessingAt)
+	assert.Equal(t, penOrder.Items, order.Items)
+	_, err = s.SetOrderStatus(penOrder.ID, storage.OrderProcessing)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+	_, err = s.SetOrderStatus(penOrder.ID, storage.OrderPlaced)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+	order, err = s.SetOrderStatus(penOrder.ID, storage.OrderDelivered)
+	require.NoError(t, err)
+	assert.Equal(t, storage.OrderDelivered, order.Status)
+	assert.NotNil(t, order.ProcessingAt)
+	assert.Nil(t, order.ShippedAt)
+	assert.NotNil(t, order.DeliveredAt)
+	_, err = s.SetOrderStatus(penOrder.ID, storage.OrderShipped)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+	_, err = s.SetOrderStatus(cartOrder.ID, storage.OrderCancelled)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+	_, err = s.SetOrderStatus(1<<30, storage.OrderShipped)
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	//the warehouse sees the orders of all users
+	delivered, err := s.AllOrders(storage.OrderFilter{Status: storage.OrderDelivered})
+	require.NoError(t, err)
+	assert.Contains(t, orderIDs(delivered.Orders), penOrder.ID)
+	assert.NotContains(t, orderIDs(delivered.Orders), cartOrder.ID)
+	for _, o := range delivered.Orders {
+		assert.Equal(t, storage.OrderDelivered, o.Status)
+	}
+	page, err = s.AllOrders(storage.OrderFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, page.Orders, 1)
+	assert.NotNil(t, page.Next)
+
+	//orders keep the items when they are renamed
+	renamed := newItem(t, s, 10)
+	require.NoError(t, s.Buy(renamed, buyer, 1))
+	newName := renamed + "-v2"
+	_, err = s.UpdateMerchItem(renamed, storage.MerchUpdate{Name: &newName})
+	require.NoError(t, err)
+	page, err = s.Orders(buyer, storage.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 3)
+	assert.Equal(t, []storage.OrderItem{{Item: newName, Price: 10, Quantity: 1}}, page.Orders[0].Items)
+}
+
+func testOrderPages(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+	for range 5 {
+		require.NoError(t, s.Buy("pen", buyer, 1))
+	}
+	page, err := s.Orders(buyer, storage.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 5)
+	newestFirst := orderIDs(page.Orders)
+	oldest := page.Orders[4]
+
+	//the user's orders are paged newest first
+	var paged []int
+	filter := storage.OrderFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "too many pages")
+		page, err := s.Orders(buyer, filter)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Orders), 2)
+		paged = append(paged, orderIDs(page.Orders)...)
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+	assert.Equal(t, newestFirst, paged)
+
+	//the warehouse pages all orders oldest first, starting after the cursor
+	filter = storage.OrderFilter{After: &storage.OrderCursor{PlacedAt: oldest.PlacedAt, ID: oldest.ID}, Limit: 3}
+	page, err = s.AllOrders(filter)
+	require.NoError(t, err)
+	assert.Equal(t, []int{newestFirst[3], newestFirst[2], newestFirst[1]}, orderIDs(page.Orders))
+	require.NotNil(t, page.Next)
+	filter.After = page.Next
+	page, err = s.AllOrders(filter)
+	require.NoError(t, err)
+	assert.Equal(t, []int{newestFirst[0]}, orderIDs(page.Orders))
+	assert.Nil(t, page.Next)
+
+	//the status applies to the pages too
+	_, err = s.SetOrderStatus(newestFirst[1], storage.OrderShipped)
+	require.NoError(t, err)
+	page, err = s.Orders(buyer, storage.OrderFilter{Status: storage.OrderShipped, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{newestFirst[1]}, orderIDs(page.Orders))
+	assert.Nil(t, page.Next)
+}
+
+func testCancelOrder(t *testing.T, s Storage) {
+	buyer := NewUser(t, s)
+	other := NewUser(t, s)
+	require.NoError(t, s.Buy("pen", buyer, 3))
+	require.NoError(t, s.SetCartItem(buyer, "pen", 2))
+	require.NoError(t, s.SetCartItem(buyer, "hoody", 1))
+	_, err := s.Checkout(buyer)
+	require.NoError(t, err)
+	page, err := s.Orders(buyer, storage.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 2)
+	cartOrder, penOrder := page.Orders[0], page.Orders[1]
+	_, err = s.SetOrderStatus(cartOrder.ID, storage.OrderDelivered)
+	require.NoError(t, err)
+
+	//users cancel only their own orders within the window
+	_, err = s.CancelOrder(cartOrder.ID, other, time.Time{})
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+	_, err = s.CancelOrder(cartOrder.ID, buyer, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrRefundWindowExpired)
+	_, err = s.CancelOrder(1<<30, buyer, time.Time{})
+	assert.ErrorIs(t, err, storage.ErrOrderNotFound)
+
+	//delivered orders are returned, the coins and the items go back at once
+	order, err := s.CancelOrder(cartOrder.ID, buyer, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, storage.OrderCancelled, order.Status)
+	assert.NotNil(t, order.CancelledAt)
+	assert.NotNil(t, order.DeliveredAt)
+	assert.Equal(t, cartOrder.Items, order.Items)
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, startBalance-30, info.Coins)
+	assert.Equal(t, []storage.InventoryEntry{{Type: "pen", Quantity: 3}}, info.Inventory)
+	require.Len(t, info.CoinHistory.Refunds, 1)
+	assert.Equal(t, cartOrder.ID, info.CoinHistory.Refunds[0].OrderID)
+	assert.Equal(t, 320, info.CoinHistory.Refunds[0].Amount)
+	//purchases stay in the history
+	assert.Len(t, info.Purchases, 3)
+
+	_, err = s.CancelOrder(cartOrder.ID, buyer, time.Time{})
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+	_, err = s.SetOrderStatus(cartOrder.ID, storage.OrderDelivered)
+	assert.ErrorIs(t, err, storage.ErrOrderStatus)
+
+	//admins cancel any order, whenever it was placed
+	order, err = s.CancelOrder(penOrder.ID, "", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, storage.OrderCancelled, order.Status)
+	info = userInfo(t, s, buyer)
+	assert.Equal(t, startBalance, info.Coins)
+	assert.Empty(t, info.Inventory)
+	require.Len(t, info.CoinHistory.Refunds, 2)
+	assert.Equal(t, penOrder.ID, info.CoinHistory.Refunds[0].OrderID)
+
+	entries, err := s.UserLedger(buyer)
+	require.NoError(t, err)
+	var refunds []int
+	for _, e := range entries {
+		if e.Kind == storage.KindRefund {
+			refunds = append(refunds, e.Amount)
+		}
+	}
+	assert.ElementsMatch(t, []int{320, 30}, refunds)
+}
+
+func orderIDs(orders []storage.Order) []int {
+	ids := make([]int, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	return ids
+}
+
+func testConcurrentSendCoins(t *testing.T, s Storage) {
+	const (
+		usersCount    = 5
+		transfers     = 300
+		maxTransfer   = 300
+		expectedTotal = usersCount * startBalance
+	)
+	users := make([]string, usersCount)
+	for i := range users {
+		users[i] = NewUser(t, s)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < transfers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			//opposite directions between the same pairs to provoke lock ordering issues
+			from, to := users[i%usersCount], users[(i+1+i/usersCount)%usersCount]
+			if i%2 == 1 {
+				from, to = to, from
+			}
+			err := s.SendCoins(from, to, 1+i%maxTransfer)
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+			if !errors.Is(err, storage.ErrUnsufficientBalance) {
+				t.Errorf("unexpected error on transfer %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var total, sent, received int
+	for _, name := range users {
+		info := userInfo(t, s, name)
+		assert.GreaterOrEqual(t, info.Coins, 0, "balance of %s", name)
+		total += info.Coins
+		sent += len(info.CoinHistory.Sent)
+		received += len(info.CoinHistory.Received)
+	}
+	assert.Equal(t, expectedTotal, total)
+	for _, name := range users {
+		entries, err := s.UserLedger(name)
+		require.NoError(t, err)
+		assert.Equal(t, userInfo(t, s, name).Coins, ledgerSum(entries), "ledger of %s", name)
+	}
+	assert.Equal(t, int(succeeded.Load()), sent)
+	assert.Equal(t, int(succeeded.Load()), received)
+}
+
+func testConcurrentBuy(t *testing.T, s Storage) {
+	const attempts = 50
+	buyer := NewUser(t, s)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Buy("pink-hoody", buyer, 1)
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+			if !errors.Is(err, storage.ErrUnsufficientBalance) {
+				t.Errorf("unexpected error on buy: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	//pink-hoody costs 500, so exactly two purchases fit into the start balance
+	assert.Equal(t, int64(2), succeeded.Load())
+	info := userInfo(t, s, buyer)
+	assert.Equal(t, 0, info.Coins)
+	assert.Equal(t, []storage.InventoryEntry{{Type: "pink-hoody", Quantity: 2}}, info.Inventory)
+}
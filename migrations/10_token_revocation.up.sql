@@ -0,0 +1,9 @@
+-- access tokens revoked before they expire, e.g. on logout.
+-- Rows are only needed until expires_at and are pruned on every insert.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- access tokens issued before this moment are rejected, see /api/admin/users/{username}/revoke-sessions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;
@@ -0,0 +1,8 @@
+-- failed logins per key: "user:<name>" or "ip:<address>".
+-- Counters are shared between service instances, see internal/http-server/lockout.go.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(300) PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
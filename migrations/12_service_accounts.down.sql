@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_keys;
+UPDATE users SET role = 'user' WHERE role = 'service';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
@@ -0,0 +1,2 @@
+-- a purchase of several items is a single row, price is the price of one item
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0);
@@ -0,0 +1,9 @@
+-- carts keep items by merch_id, so they follow renames; retired items stay in the cart
+-- and fail the checkout until removed.
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merch_id INT NOT NULL REFERENCES merch(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, merch_id)
+);
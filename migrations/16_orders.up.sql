@@ -0,0 +1,25 @@
+-- every purchase transaction places an order, which is delivered to the user.
+-- Timestamps of the statuses an order has not reached are NULL.
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_id INT NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'placed'
+        CHECK (status IN ('placed', 'processing', 'shipped', 'delivered', 'cancelled')),
+    total INT NOT NULL,
+    placed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processing_at TIMESTAMPTZ,
+    shipped_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id, placed_at);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status, placed_at);
+
+-- purchases made before orders existed are considered delivered
+INSERT INTO orders (user_id, transaction_id, status, total, placed_at, delivered_at)
+SELECT user_id, transaction_id, 'delivered', SUM(price * quantity), MIN(created_at), MIN(created_at)
+FROM purchases
+GROUP BY user_id, transaction_id
+ON CONFLICT (transaction_id) DO NOTHING;
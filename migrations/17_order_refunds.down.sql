@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transactions_refund_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_transaction_id;
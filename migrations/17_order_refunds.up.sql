@@ -0,0 +1,5 @@
+-- a cancelled order is refunded by a 'refund' transaction from the shop to the user,
+-- the items are taken back from the user's inventory.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refund_transaction_id INT UNIQUE REFERENCES transactions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_refund_idx ON transactions (to_user_id, id DESC) WHERE kind = 'refund';
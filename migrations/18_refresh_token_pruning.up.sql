@@ -0,0 +1,2 @@
+-- expired families of a user are deleted when the user gets a new family
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_expires_at_idx ON refresh_tokens (user_id, expires_at);
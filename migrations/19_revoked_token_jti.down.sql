@@ -0,0 +1,2 @@
+DELETE FROM revoked_tokens WHERE LENGTH(jti) > 64;
+ALTER TABLE revoked_tokens ALTER COLUMN jti TYPE VARCHAR(64);
@@ -0,0 +1,2 @@
+-- jti of identity provider tokens is chosen by the provider and may be of any length
+ALTER TABLE revoked_tokens ALTER COLUMN jti TYPE TEXT;
@@ -0,0 +1,6 @@
+-- users created from ID tokens of an identity provider are linked to its issuer,
+-- provider tokens are only accepted for users linked to the same issuer.
+-- Local users, including password accounts and service accounts, have no issuer.
+-- Users created from provider tokens before this migration must be linked by hand:
+--   UPDATE users SET auth_issuer = '<OIDC_ISSUER>' WHERE name = '<name>';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_issuer TEXT;
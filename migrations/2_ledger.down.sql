@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS coins INT DEFAULT 1000;
+UPDATE users u SET coins = COALESCE((SELECT SUM(amount) FROM ledger l WHERE l.account = 'user' AND l.user_id = u.id), 0);
+
+DROP TABLE IF EXISTS ledger;
+DROP FUNCTION IF EXISTS ledger_check_balanced();
+
+DELETE FROM transactions WHERE kind <> 'transfer';
+ALTER TABLE transactions ALTER COLUMN from_user_id SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN to_user_id SET NOT NULL;
+ALTER TABLE transactions DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,60 @@
+-- transactions becomes the journal: one row per coin movement of any kind.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS kind VARCHAR(32) NOT NULL DEFAULT 'transfer';
+ALTER TABLE transactions ALTER COLUMN from_user_id DROP NOT NULL;
+ALTER TABLE transactions ALTER COLUMN to_user_id DROP NOT NULL;
+
+-- ledger holds the postings of every journal entry. Postings to user accounts
+-- reference the user, postings to system accounts ('issuance', 'shop') do not.
+-- Positive amounts add coins to the account, negative ones take them away.
+CREATE TABLE IF NOT EXISTS ledger (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    account VARCHAR(32) NOT NULL,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    amount INT NOT NULL,
+    CHECK ((account = 'user') = (user_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS ledger_user_id_idx ON ledger (user_id);
+CREATE INDEX IF NOT EXISTS ledger_transaction_id_idx ON ledger (transaction_id);
+
+-- every journal entry must be balanced when the database transaction commits
+CREATE OR REPLACE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT COALESCE(SUM(amount), 0) FROM ledger WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'unbalanced ledger transaction %', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_balanced ON ledger;
+CREATE CONSTRAINT TRIGGER ledger_balanced
+    AFTER INSERT ON ledger
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+-- backfill postings for existing transfers
+INSERT INTO ledger (transaction_id, account, user_id, amount)
+SELECT id, 'user', from_user_id, -amount FROM transactions WHERE kind = 'transfer'
+UNION ALL
+SELECT id, 'user', to_user_id, amount FROM transactions WHERE kind = 'transfer';
+
+-- open every account with the balance it had before its transfers
+WITH opening AS (
+    SELECT u.id, u.coins
+        + COALESCE((SELECT SUM(amount) FROM transactions WHERE from_user_id = u.id AND kind = 'transfer'), 0)
+        - COALESCE((SELECT SUM(amount) FROM transactions WHERE to_user_id = u.id AND kind = 'transfer'), 0) AS amount
+    FROM users u
+), t AS (
+    INSERT INTO transactions (kind, to_user_id, amount)
+    SELECT 'opening', id, amount FROM opening
+    RETURNING id, to_user_id, amount
+)
+INSERT INTO ledger (transaction_id, account, user_id, amount)
+SELECT id, 'user', to_user_id, amount FROM t
+UNION ALL
+SELECT id, 'issuance', NULL, -amount FROM t;
+
+-- balances are derived from the ledger from now on
+ALTER TABLE users DROP COLUMN IF EXISTS coins;
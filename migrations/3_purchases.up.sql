@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS purchases (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merch_id INT NOT NULL REFERENCES merch(id) ON DELETE CASCADE,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    price INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS purchases_user_id_idx ON purchases (user_id, created_at);
@@ -0,0 +1,3 @@
+-- transfer history is read per user and paginated by id
+CREATE INDEX IF NOT EXISTS transactions_from_user_id_idx ON transactions (from_user_id, id DESC) WHERE kind = 'transfer';
+CREATE INDEX IF NOT EXISTS transactions_to_user_id_idx ON transactions (to_user_id, id DESC) WHERE kind = 'transfer';
@@ -0,0 +1,2 @@
+ALTER TABLE merch DROP COLUMN IF EXISTS available;
+ALTER TABLE merch DROP COLUMN IF EXISTS description;
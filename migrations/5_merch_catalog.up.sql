@@ -0,0 +1,17 @@
+ALTER TABLE merch ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE merch ADD COLUMN IF NOT EXISTS available BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE merch SET description = d.description
+FROM (VALUES
+    ('t-shirt', 'Футболка с логотипом'),
+    ('cup', 'Кружка с логотипом'),
+    ('book', 'Блокнот'),
+    ('pen', 'Ручка'),
+    ('powerbank', 'Внешний аккумулятор'),
+    ('hoody', 'Худи с логотипом'),
+    ('umbrella', 'Зонт'),
+    ('socks', 'Носки'),
+    ('wallet', 'Кошелек'),
+    ('pink-hoody', 'Розовое худи')
+) AS d(name, description)
+WHERE merch.name = d.name AND merch.description = '';
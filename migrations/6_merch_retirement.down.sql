@@ -0,0 +1 @@
+ALTER TABLE merch DROP COLUMN IF EXISTS retired_at;
@@ -0,0 +1,2 @@
+-- retired items are hidden from the catalog but kept for inventory and purchase records
+ALTER TABLE merch ADD COLUMN IF NOT EXISTS retired_at TIMESTAMP;
@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_adjustment_reason;
+ALTER TABLE transactions DROP COLUMN IF EXISTS created_by;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reason;
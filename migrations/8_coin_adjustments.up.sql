@@ -0,0 +1,6 @@
+-- grants and clawbacks are made by admins and must be explained
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reason TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS created_by INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_adjustment_reason;
+ALTER TABLE transactions ADD CONSTRAINT transactions_adjustment_reason
+    CHECK (kind NOT IN ('grant', 'clawback') OR COALESCE(reason, '') <> '');